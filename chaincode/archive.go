@@ -0,0 +1,157 @@
+package chaincode
+
+import (
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Cows are never removed from the world state, bundles on the shelves and the
+// trace certificates printed for them keep referring to them. An archived cow
+// stays readable by its id but takes no further records, is left out of the
+// listings and of the grade, death and progeny statistics, and can be restored
+// by an administrator.
+
+// ArchiveCow archives a cow that has no bundles
+func (s *SmartContract) ArchiveCow(ctx contractapi.TransactionContextInterface, cowRef string, reason string) error {
+	//'{"Args":["archiveCow", "180501-2", "registered twice"]}'
+	//cowRef	-- cow (Id_no or legacy COW key)
+	//reason	-- why the cow is archived
+
+	return s.ArchiveCowJSON(ctx, ArchivePayload{Cow: cowRef, Reason: reason})
+}
+
+// ArchiveCowJSON is archiveCow with named fields
+func (s *SmartContract) ArchiveCowJSON(ctx contractapi.TransactionContextInterface, payload ArchivePayload) error {
+	variables := []string{"archiveCow.reason"}
+
+	if strings.TrimSpace(payload.Reason) == "" {
+		return invalidArgument("Incorrect value. reason must not be empty")
+	}
+
+	APIstub := ctx.GetStub()
+
+	key, cow, err := getCow(APIstub, payload.Cow)
+	if err != nil {
+		return err
+	}
+	if cow.Archived != nil {
+		return invalidState("Cow %s is already archived", cow.Id_no)
+	}
+	barcodes, err := cowBundles(APIstub, cow)
+	if err != nil {
+		return err
+	}
+	if len(barcodes) > 0 {
+		return conflict("Cow %s can not be archived, bundles refer to it: %s", cow.Id_no, strings.Join(barcodes, ", "))
+	}
+
+	stamp, err := newStamp(APIstub)
+	if err != nil {
+		return err
+	}
+
+	cow.Archived = &Archive{Reason: payload.Reason, Archived_at: stamp.Tx_time, Msp_id: stamp.Msp_id, Subject: stamp.Subject}
+	cow.setCowRemarks(variables, []string{payload.Reason})
+
+	if err := delCowIndexes(APIstub, cow); err != nil {
+		return err
+	}
+	return putRecord(APIstub, key, &cow)
+}
+
+// deleteReason is the archive reason of the cows archived by deleteCow
+const deleteReason = "deleted"
+
+// DeleteCow archives a cow, it is kept for the clients of the former delete and
+// takes its single argument. archiveCow takes a reason.
+func (s *SmartContract) DeleteCow(ctx contractapi.TransactionContextInterface, cowRef string) error {
+	//'{"Args":["deleteCow", "COW3"]}'
+
+	return s.ArchiveCowJSON(ctx, ArchivePayload{Cow: cowRef, Reason: deleteReason})
+}
+
+// RestoreCow brings an archived cow back (administrators only, see adminTransactions)
+func (s *SmartContract) RestoreCow(ctx contractapi.TransactionContextInterface, cowRef string) error {
+	//'{"Args":["restoreCow", "180501-2"]}'
+
+	variables := []string{"restoreCow.archive_reason"}
+
+	APIstub := ctx.GetStub()
+
+	key, cow, err := getCow(APIstub, cowRef)
+	if err != nil {
+		return err
+	}
+	if cow.Archived == nil {
+		return invalidState("Cow %s is not archived", cow.Id_no)
+	}
+
+	// the remarks are stamped with who archived and who restored the cow
+	cow.setCowRemarks(variables, []string{cow.Archived.Reason})
+	cow.Archived = nil
+
+	if err := putCowIndexes(APIstub, cow); err != nil {
+		return err
+	}
+	return putRecord(APIstub, key, &cow)
+}
+
+// QueryArchivedCows returns the archived cows with their Id_no as the key
+func (s *SmartContract) QueryArchivedCows(ctx contractapi.TransactionContextInterface) ([]CowQueryResult, error) {
+	return queryCows(ctx.GetStub(), true)
+}
+
+// cowBundles lists the barcodes of the bundles packed from cow
+func cowBundles(APIstub shim.ChaincodeStubInterface, cow Cow) ([]string, error) {
+	barcodes := []string{}
+	for _, function := range []string{"registerInProcessesBundleNum", "registerInSalesBundleNum"} {
+		for _, event := range remarkEvents(cow.Remarks, function) {
+			bundleKey, err := makeBundleKey(APIstub, event["barcode_id"])
+			if err != nil {
+				continue
+			}
+			bundleAsBytes, err := APIstub.GetState(bundleKey)
+			if err != nil {
+				return nil, err
+			}
+			if bundleAsBytes != nil {
+				barcodes = append(barcodes, event["barcode_id"])
+			}
+		}
+	}
+	return barcodes, nil
+}
+
+// delCowIndexes takes a cow out of the sire, grade and death indexes
+func delCowIndexes(APIstub shim.ChaincodeStubInterface, cow Cow) error {
+	if err := delSireIndex(APIstub, cow); err != nil {
+		return err
+	}
+	if err := delDeathIndex(APIstub, cow); err != nil {
+		return err
+	}
+	return delGradeIndex(APIstub, cow)
+}
+
+// putCowIndexes puts a cow back into the sire, grade and death indexes
+func putCowIndexes(APIstub shim.ChaincodeStubInterface, cow Cow) error {
+	if err := putSireIndex(APIstub, cow); err != nil {
+		return err
+	}
+	// records with an unreadable date were never indexed, they stay out
+	if cow.Dead {
+		if _, _, err := deathIndexKey(APIstub, cow); err == nil {
+			if err := putDeathIndex(APIstub, cow); err != nil {
+				return err
+			}
+		}
+	}
+	if event := lastRemarkEvent(cow.Remarks, "addInfoGradeResult"); event != nil {
+		if _, err := gradeIndexKey(APIstub, cow, event); err == nil {
+			return putGradeIndex(APIstub, cow, nil, event)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package chaincode_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lotty02cho/fabcow-test/chaincode"
+	"github.com/lotty02cho/fabcow-test/ledgertest"
+)
+
+func TestArchiveRestore(t *testing.T) {
+	c := newContract(t)
+	c.registerFarm("", "FARM0")
+	c.registerCow("COW0", "180501-1", "F", "FARM0")
+	c.registerCow("", "180501-2", "F", "FARM0")
+
+	tests := []struct {
+		name     string
+		identity *ledgertest.Identity
+		function string
+		args     []string
+		code     string
+		// archived is the archive reason of 180501-1 afterwards, empty when it is not archived
+		archived string
+	}{
+		{"archive without a reason", c.farmer, "archiveCow", []string{"180501-1", " "}, "invalid_argument", ""},
+		{"archive", c.farmer, "archiveCow", []string{"COW0", "registered twice"}, "", "registered twice"},
+		{"archive again", c.farmer, "archiveCow", []string{"180501-1", "registered twice"}, "invalid_state", "registered twice"},
+		{"remark on an archived cow", c.farmer, "addRemark", []string{"180501-1", "note", "fed"}, "invalid_state", "registered twice"},
+		{"death of an archived cow", c.farmer, "confirmDeath", []string{"180501-1", "VET-1", "Kim", "20191230"}, "invalid_state", "registered twice"},
+		{"restore by a farmer", c.farmer, "restoreCow", []string{"180501-1"}, "forbidden", "registered twice"},
+		{"restore", c.admin, "restoreCow", []string{"COW0"}, "", ""},
+		{"restore again", c.admin, "restoreCow", []string{"180501-1"}, "invalid_state", ""},
+		{"remark on a restored cow", c.farmer, "addRemark", []string{"180501-1", "note", "fed"}, "", ""},
+		{"delete", c.farmer, "deleteCow", []string{"180501-1"}, "", "deleted"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := c.ledger.Submit(test.identity, test.function, test.args...)
+			if code := errorCode(t, result); code != test.code {
+				t.Fatalf("%s failed with %q, want %q (%s)", test.function, code, test.code, result.Message)
+			}
+
+			// an archived cow stays readable
+			cow := c.cow("180501-1")
+			reason := ""
+			if cow.Archived != nil {
+				reason = cow.Archived.Reason
+			}
+			if reason != test.archived {
+				t.Errorf("cow archived for %q, want %q", reason, test.archived)
+			}
+		})
+	}
+
+	t.Run("listings", func(t *testing.T) {
+		for function, want := range map[string]string{"queryAllCows": "180501-2", "queryArchivedCows": "180501-1"} {
+			result := c.ledger.Evaluate(c.farmer, function)
+			if err := result.Err(); err != nil {
+				t.Fatalf("%s: %v", function, err)
+			}
+			cows := []chaincode.CowQueryResult{}
+			if err := json.Unmarshal(result.Payload, &cows); err != nil {
+				t.Fatal(err)
+			}
+			if len(cows) != 1 || cows[0].Record.Id_no != want {
+				t.Errorf("%s returned %+v, want only cow %s", function, cows, want)
+			}
+		}
+	})
+}
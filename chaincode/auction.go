@@ -0,0 +1,357 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// An auction sale is an auctionSale remark on the cow and the transfer of the
+// cow from the seller to the buyer, written together. The sale is kept under
+// ("auctionlot", [Auction_house, YYYYMMDD, Lot_no]), so a lot is sold once,
+// and indexed under ("auction", [YYYYMM, Region, YYYYMMDD, Id_no, n]) by the
+// region of the auction house for the price history. n numbers the auction
+// sales of a cow.
+const (
+	auctionLotObjectType   = "auctionlot"
+	auctionIndexObjectType = "auction"
+)
+
+// auctionAgeBands groups the sales by the age of the cow in months, a band holds the ages below its limit
+var auctionAgeBands = []struct {
+	label string
+	below int
+}{
+	{"0-6", 7},
+	{"7-12", 13},
+	{"13-24", 25},
+	{"25-36", 37},
+	{"37+", 0},
+}
+
+// AuctionSale is a cow sold at a livestock auction. Prices are in won.
+type AuctionSale struct {
+	Id_no         string `json:"id_no"`
+	Sex           string `json:"sex"`
+	Age_months    int    `json:"age_months"`
+	Sale_date     string `json:"sale_date"`
+	Auction_house string `json:"auction_house"`
+	Region        string `json:"region"`
+	Lot_no        string `json:"lot_no"`
+	Hammer_price  int64  `json:"hammer_price"`
+	// Weight is the live weight in kg at the sale, 0 when the cow was not weighed
+	Weight       float64 `json:"weight"`
+	Price_per_kg float64 `json:"price_per_kg"`
+	Seller       string  `json:"seller"`
+	Seller_nm    string  `json:"seller_nm"`
+	Buyer        string  `json:"buyer"`
+	Buyer_nm     string  `json:"buyer_nm"`
+}
+
+type AuctionPriceHistory struct {
+	Region string        `json:"region"`
+	From   string        `json:"from"`
+	To     string        `json:"to"`
+	Sales  []AuctionSale `json:"sales"`
+}
+
+type AuctionPriceStats struct {
+	// Group is the sex, age band, region or month (YYYYMM) of a breakdown, empty for the total
+	Group         string  `json:"group"`
+	Sales         int     `json:"sales"`
+	Average_price float64 `json:"average_price"`
+	Min_price     int64   `json:"min_price"`
+	Max_price     int64   `json:"max_price"`
+	// Average_price_per_kg is the average of the Weighed sales with a live weight
+	Weighed              int     `json:"weighed"`
+	Average_price_per_kg float64 `json:"average_price_per_kg"`
+}
+
+type AuctionPriceReport struct {
+	Region  string              `json:"region"`
+	From    string              `json:"from"`
+	To      string              `json:"to"`
+	Total   AuctionPriceStats   `json:"total"`
+	Sexes   []AuctionPriceStats `json:"sexes"`
+	Ages    []AuctionPriceStats `json:"ages"`
+	Regions []AuctionPriceStats `json:"regions"`
+	Months  []AuctionPriceStats `json:"months"`
+}
+
+// AddAuctionSale notes the sale of a cow at an auction and transfers it to the buyer
+func (s *SmartContract) AddAuctionSale(ctx contractapi.TransactionContextInterface, cowRef string, saleDate string, auctionHouse string, region string, lotNo string, hammerPrice string, sellerRef string, buyerRef string, weight string) error {
+	//'{"Args":["addAuctionSale","180501-1", "20190301", "Iksan livestock market", "Jeonbuk", "117", "3250000", "FARM0", "FARM1", "285"]}'
+	//saleDate		-- YYYYMMDD
+	//auctionHouse	-- auction market the cow was sold at
+	//region		-- region of the auction market
+	//lotNo			-- lot number of the cow at the sale
+	//hammerPrice	-- price in won
+	//sellerRef		-- current owner of the cow
+	//buyerRef		-- new owner of the cow
+	//weight		-- live weight in kg at the sale (may be empty)
+
+	return s.AddAuctionSaleJSON(ctx, AuctionSalePayload{Cow: cowRef, Sale_date: saleDate, Auction_house: auctionHouse, Region: region, Lot_no: lotNo, Hammer_price: hammerPrice, Seller: sellerRef, Buyer: buyerRef, Weight: weight})
+}
+
+// AddAuctionSaleJSON is addAuctionSale with named fields
+func (s *SmartContract) AddAuctionSaleJSON(ctx contractapi.TransactionContextInterface, payload AuctionSalePayload) error {
+	variables := []string{"auctionSale.sale_date", "auctionSale.auction_house", "auctionSale.region", "auctionSale.lot_no", "auctionSale.hammer_price", "auctionSale.weight", "auctionSale.seller", "auctionSale.buyer"}
+
+	sale, err := newAuctionSale(payload)
+	if err != nil {
+		return err
+	}
+
+	APIstub := ctx.GetStub()
+
+	lotKey, err := APIstub.CreateCompositeKey(auctionLotObjectType, []string{sale.Auction_house, sale.Sale_date, sale.Lot_no})
+	if err != nil {
+		return err
+	}
+	if err := assertNotExists(APIstub, lotKey, "Lot "+sale.Lot_no+" of "+sale.Auction_house+" on "+sale.Sale_date); err != nil {
+		return err
+	}
+
+	key, cow, err := getLiveCow(APIstub, payload.Cow)
+	if err != nil {
+		return err
+	}
+
+	// the sale and the transfer are one write of the cow
+	seller := cow.Owner
+	if err := transferCow(APIstub, &cow, payload.Seller, payload.Buyer); err != nil {
+		return err
+	}
+	if cow.Owner.Biz_no == seller.Biz_no {
+		return invalidArgument("Cow %s is sold to its own owner %s", cow.Id_no, seller.Biz_no)
+	}
+	sale.Id_no, sale.Sex, sale.Age_months = cow.Id_no, strings.ToUpper(strings.TrimSpace(cow.Sex)), ageInDays(cow.Birth_date, sale.Sale_date)*12/365
+	sale.Seller, sale.Seller_nm = seller.Biz_no, seller.Owner_nm
+	sale.Buyer, sale.Buyer_nm = cow.Owner.Biz_no, cow.Owner.Owner_nm
+
+	weight := ""
+	if sale.Weight > 0 {
+		weight = strconv.FormatFloat(sale.Weight, 'f', -1, 64)
+	}
+	cow.setCowRemarks(variables, []string{sale.Sale_date, sale.Auction_house, sale.Region, sale.Lot_no, strconv.FormatInt(sale.Hammer_price, 10), weight, sale.Seller, sale.Buyer})
+
+	saleAsBytes, err := json.Marshal(sale)
+	if err != nil {
+		return err
+	}
+	if err := APIstub.PutState(lotKey, saleAsBytes); err != nil {
+		return err
+	}
+	n := len(remarkEvents(cow.Remarks, "auctionSale")) - 1
+	indexKey, err := APIstub.CreateCompositeKey(auctionIndexObjectType, []string{sale.Sale_date[:6], sale.Region, sale.Sale_date, cow.Id_no, strconv.Itoa(n)})
+	if err != nil {
+		return err
+	}
+	if err := APIstub.PutState(indexKey, saleAsBytes); err != nil {
+		return err
+	}
+
+	return putRecord(APIstub, key, &cow)
+}
+
+// AddAuctionSaleBatch records the results of an auction day in one transaction
+func (s *SmartContract) AddAuctionSaleBatch(ctx contractapi.TransactionContextInterface, payloads []AuctionSalePayload) error {
+	//'{"Args":["addAuctionSaleBatch", "[{\"cow\":\"180501-1\",\"sale_date\":\"20190301\",\"auction_house\":\"Iksan livestock market\",...},...]"]}'
+
+	return runBatch(ctx, len(payloads), func(batchCtx contractapi.TransactionContextInterface, i int) error {
+		return s.AddAuctionSaleJSON(batchCtx, payloads[i])
+	})
+}
+
+// GetAuctionPriceHistory returns the auction sales of a period
+func (s *SmartContract) GetAuctionPriceHistory(ctx contractapi.TransactionContextInterface, region string, from string, to string) (*AuctionPriceHistory, error) {
+	//'{"Args":["getAuctionPriceHistory", "Jeonbuk", "20190101", "20191231"]}'
+	//region	-- region of the auction markets, empty for all regions
+	//from		-- first sale date, YYYYMMDD
+	//to		-- last sale date, YYYYMMDD
+
+	fromDate, toDate, months, err := statsPeriod(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	history := AuctionPriceHistory{Region: region, From: fromDate, To: toDate, Sales: []AuctionSale{}}
+	err = scanAuctionSales(ctx, region, fromDate, toDate, months, func(sale AuctionSale) {
+		history.Sales = append(history.Sales, sale)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(history.Sales, func(i, j int) bool {
+		a, b := history.Sales[i], history.Sales[j]
+		if a.Sale_date != b.Sale_date {
+			return a.Sale_date < b.Sale_date
+		}
+		if a.Auction_house != b.Auction_house {
+			return a.Auction_house < b.Auction_house
+		}
+		return a.Lot_no < b.Lot_no
+	})
+
+	return &history, nil
+}
+
+// GetAuctionPriceStats returns the average auction prices of a period by sex, age, region and month
+func (s *SmartContract) GetAuctionPriceStats(ctx contractapi.TransactionContextInterface, region string, from string, to string) (*AuctionPriceReport, error) {
+	//'{"Args":["getAuctionPriceStats", "Jeonbuk", "20190101", "20191231"]}'
+	//region	-- region of the auction markets, empty for all regions
+	//from		-- first sale date, YYYYMMDD
+	//to		-- last sale date, YYYYMMDD
+
+	fromDate, toDate, months, err := statsPeriod(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	total := &auctionPriceAggregate{}
+	sexes, ages, regions, monthly := map[string]*auctionPriceAggregate{}, map[string]*auctionPriceAggregate{}, map[string]*auctionPriceAggregate{}, map[string]*auctionPriceAggregate{}
+	err = scanAuctionSales(ctx, region, fromDate, toDate, months, func(sale AuctionSale) {
+		total.add(sale)
+		addAuctionPrice(sexes, sale.Sex, sale)
+		addAuctionPrice(ages, auctionAgeBand(sale.Age_months), sale)
+		addAuctionPrice(regions, sale.Region, sale)
+		addAuctionPrice(monthly, sale.Sale_date[:6], sale)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := AuctionPriceReport{Region: region, From: fromDate, To: toDate, Total: total.stats("")}
+	report.Sexes = auctionPriceBreakdown(sexes, func(a, b string) bool { return a < b })
+	report.Ages = auctionPriceBreakdown(ages, func(a, b string) bool { return auctionAgeBandIndex(a) < auctionAgeBandIndex(b) })
+	report.Regions = auctionPriceBreakdown(regions, func(a, b string) bool { return a < b })
+	report.Months = auctionPriceBreakdown(monthly, func(a, b string) bool { return a < b })
+
+	return &report, nil
+}
+
+// newAuctionSale checks the sale of an auction payload, the cow and its owners are filled in by the caller
+func newAuctionSale(payload AuctionSalePayload) (AuctionSale, error) {
+	sale := AuctionSale{Auction_house: strings.TrimSpace(payload.Auction_house), Region: strings.TrimSpace(payload.Region), Lot_no: strings.TrimSpace(payload.Lot_no)}
+	if sale.Auction_house == "" {
+		return sale, invalidArgument("Incorrect value. auction_house must not be empty")
+	}
+	if sale.Region == "" {
+		return sale, invalidArgument("Incorrect value. region must not be empty")
+	}
+	if sale.Lot_no == "" {
+		return sale, invalidArgument("Incorrect value. lot_no must not be empty")
+	}
+	date, err := normalizeDate(payload.Sale_date)
+	if err != nil {
+		return sale, withContext("sale_date", err)
+	}
+	sale.Sale_date = date
+	price, err := strconv.ParseInt(strings.ReplaceAll(strings.TrimSpace(payload.Hammer_price), ",", ""), 10, 64)
+	if err != nil || price <= 0 {
+		return sale, invalidArgument("Incorrect hammer_price %q, expecting won", payload.Hammer_price)
+	}
+	sale.Hammer_price = price
+	if weight := strings.TrimSuffix(strings.TrimSpace(payload.Weight), "kg"); weight != "" {
+		if sale.Weight, err = strconv.ParseFloat(weight, 64); err != nil || sale.Weight <= 0 {
+			return sale, invalidArgument("Incorrect weight %q, expecting kg", payload.Weight)
+		}
+		sale.Price_per_kg = round(float64(price)/sale.Weight, 1)
+	}
+	return sale, nil
+}
+
+// scanAuctionSales calls sale for every auction sale of region (all regions when empty) from fromDate to toDate
+func scanAuctionSales(ctx contractapi.TransactionContextInterface, region string, fromDate string, toDate string, months []string, sale func(AuctionSale)) error {
+	for _, month := range months {
+		err := scanMonthIndex(ctx.GetStub(), auctionIndexObjectType, month, region, fromDate, toDate, func(value []byte) error {
+			entry := AuctionSale{}
+			if err := json.Unmarshal(value, &entry); err != nil {
+				return err
+			}
+			sale(entry)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// auctionAgeBand is the label of the age band of an age in months
+func auctionAgeBand(months int) string {
+	for _, band := range auctionAgeBands {
+		if band.below == 0 || months < band.below {
+			return band.label
+		}
+	}
+	return ""
+}
+
+func auctionAgeBandIndex(label string) int {
+	for i, band := range auctionAgeBands {
+		if band.label == label {
+			return i
+		}
+	}
+	return len(auctionAgeBands)
+}
+
+// auctionPriceAggregate sums the prices of auction sales
+type auctionPriceAggregate struct {
+	sales      int
+	priceTotal int64
+	min, max   int64
+	weighed    int
+	perKgTotal float64
+}
+
+func (a *auctionPriceAggregate) add(sale AuctionSale) {
+	if a.sales == 0 || sale.Hammer_price < a.min {
+		a.min = sale.Hammer_price
+	}
+	if sale.Hammer_price > a.max {
+		a.max = sale.Hammer_price
+	}
+	a.sales++
+	a.priceTotal += sale.Hammer_price
+	if sale.Weight > 0 {
+		a.weighed++
+		a.perKgTotal += float64(sale.Hammer_price) / sale.Weight
+	}
+}
+
+func (a *auctionPriceAggregate) stats(group string) AuctionPriceStats {
+	stats := AuctionPriceStats{Group: group, Sales: a.sales, Min_price: a.min, Max_price: a.max, Weighed: a.weighed}
+	if a.sales > 0 {
+		stats.Average_price = round(float64(a.priceTotal)/float64(a.sales), 0)
+	}
+	if a.weighed > 0 {
+		stats.Average_price_per_kg = round(a.perKgTotal/float64(a.weighed), 1)
+	}
+	return stats
+}
+
+// addAuctionPrice adds sale to the aggregate of its group
+func addAuctionPrice(groups map[string]*auctionPriceAggregate, group string, sale AuctionSale) {
+	aggregate, ok := groups[group]
+	if !ok {
+		aggregate = &auctionPriceAggregate{}
+		groups[group] = aggregate
+	}
+	aggregate.add(sale)
+}
+
+// auctionPriceBreakdown is the stats of every group in the order of less
+func auctionPriceBreakdown(groups map[string]*auctionPriceAggregate, less func(a, b string) bool) []AuctionPriceStats {
+	breakdown := []AuctionPriceStats{}
+	for group, aggregate := range groups {
+		breakdown = append(breakdown, aggregate.stats(group))
+	}
+	sort.Slice(breakdown, func(i, j int) bool { return less(breakdown[i].Group, breakdown[j].Group) })
+	return breakdown
+}
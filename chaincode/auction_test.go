@@ -0,0 +1,67 @@
+package chaincode_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lotty02cho/fabcow-test/chaincode"
+)
+
+func TestAuctionTransfer(t *testing.T) {
+	c := newContract(t)
+	c.registerFarm("", "FARM0")
+	c.registerFarm("", "FARM1")
+	c.registerFarm("", "FARM2")
+	c.registerCow("", "180501-1", "M", "FARM0")
+
+	sale := func(lotNo string, seller string, buyer string) chaincode.AuctionSalePayload {
+		return chaincode.AuctionSalePayload{Cow: "180501-1", Sale_date: "20191201", Auction_house: "Iksan livestock market", Region: "Jeonbuk", Lot_no: lotNo, Hammer_price: "3,250,000", Seller: seller, Buyer: buyer, Weight: "285"}
+	}
+
+	tests := []struct {
+		name    string
+		payload chaincode.AuctionSalePayload
+		code    string
+		// owner is the owner of the cow afterwards
+		owner string
+	}{
+		{"unknown buyer", sale("117", "FARM0", "FARM9"), "not_found", "FARM0"},
+		{"sold to its owner", sale("117", "FARM0", "FARM0"), "invalid_argument", "FARM0"},
+		{"seller is not the owner", sale("117", "FARM1", "FARM2"), "conflict", "FARM0"},
+		{"no price", sale("117", "FARM0", "FARM1"), "invalid_argument", "FARM0"},
+		{"sale", sale("117", "FARM0", "FARM1"), "", "FARM1"},
+		{"lot sold again", sale("117", "FARM1", "FARM2"), "conflict", "FARM1"},
+		{"resale from the former owner", sale("118", "FARM0", "FARM2"), "conflict", "FARM1"},
+		{"resale", sale("118", "FARM1", "FARM2"), "", "FARM2"},
+	}
+	tests[3].payload.Hammer_price = "0"
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := c.submitJSON("addAuctionSale", test.payload)
+			if code := errorCode(t, result); code != test.code {
+				t.Fatalf("addAuctionSale failed with %q, want %q (%s)", code, test.code, result.Message)
+			}
+			if owner := c.cow("180501-1").Owner; owner.Biz_no != test.owner || owner.Owner_nm != "ChukLim "+test.owner {
+				t.Errorf("cow owned by %s %q, want %s", owner.Biz_no, owner.Owner_nm, test.owner)
+			}
+		})
+	}
+
+	t.Run("price history", func(t *testing.T) {
+		result := c.ledger.Evaluate(c.farmer, "getAuctionPriceHistory", "Jeonbuk", "20191201", "20191231")
+		if err := result.Err(); err != nil {
+			t.Fatal(err)
+		}
+		history := chaincode.AuctionPriceHistory{}
+		if err := json.Unmarshal(result.Payload, &history); err != nil {
+			t.Fatal(err)
+		}
+		if len(history.Sales) != 2 {
+			t.Fatalf("price history has %d sales, want 2", len(history.Sales))
+		}
+		first := history.Sales[0]
+		if first.Lot_no != "117" || first.Seller != "FARM0" || first.Buyer != "FARM1" || first.Hammer_price != 3250000 || first.Price_per_kg != 11403.5 {
+			t.Errorf("first sale recorded as %+v", first)
+		}
+	})
+}
@@ -0,0 +1,167 @@
+package chaincode
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Batches run every item against a write buffer, so later items see what
+// earlier items wrote (two vaccinations of the same cow both end up on it, a
+// cow registered twice is refused). The buffer is only written to the ledger
+// when every item succeeded, otherwise the batch fails with the error of every
+// failed item and nothing is written.
+const (
+	// maxBatchItems keeps a batch inside the transaction size budget
+	maxBatchItems = 200
+	// maxBatchArgBytes is the largest batch argument accepted
+	maxBatchArgBytes = 512 * 1024
+	// maxBatchWriteBytes is the largest write set a batch may produce
+	maxBatchWriteBytes = 2 * 1024 * 1024
+)
+
+// BatchItemError is the error of one item, a rejected batch lists them in the details of its error
+type BatchItemError struct {
+	Index   int    `json:"index"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// batchError rejects a batch for its failed items. It has the code of the items
+// when they all failed for the same reason, invalid_argument otherwise.
+func batchError(size int, items []BatchItemError) error {
+	code := items[0].Code
+	for _, item := range items {
+		if item.Code != code {
+			code = codeInvalidArgument
+		}
+	}
+	return &Error{Code: code, Message: fmt.Sprintf("Batch rejected, %d of %d items failed", len(items), size), Details: items}
+}
+
+// RegisterCowBatch registers a herd in one transaction
+func (s *SmartContract) RegisterCowBatch(ctx contractapi.TransactionContextInterface, payloads []CowPayload) error {
+	//'{"Args":["registerCowBatch", "[{\"id_no\":\"180501-1\",...},{\"id_no\":\"180501-2\",...}]"]}'
+
+	return runBatch(ctx, len(payloads), func(batchCtx contractapi.TransactionContextInterface, i int) error {
+		return s.RegisterCowJSON(batchCtx, payloads[i])
+	})
+}
+
+// AddFAMDVaccineBatch records a foot and mouth disease vaccination campaign in one transaction
+func (s *SmartContract) AddFAMDVaccineBatch(ctx contractapi.TransactionContextInterface, payloads []FAMDVaccinePayload) error {
+	//'{"Args":["addFAMDVaccineBatch", "[{\"cow\":\"180501-1\",...}]"]}'
+
+	return runBatch(ctx, len(payloads), func(batchCtx contractapi.TransactionContextInterface, i int) error {
+		return s.AddFAMDVaccineJSON(batchCtx, payloads[i])
+	})
+}
+
+// AddBTVaccineBatch records tuberculosis/brucella inspections of many cows in one transaction
+func (s *SmartContract) AddBTVaccineBatch(ctx contractapi.TransactionContextInterface, payloads []BTVaccinePayload) error {
+	//'{"Args":["addBTVaccineBatch", "[{\"cow\":\"180501-1\",...}]"]}'
+
+	return runBatch(ctx, len(payloads), func(batchCtx contractapi.TransactionContextInterface, i int) error {
+		return s.AddBTVaccineJSON(batchCtx, payloads[i])
+	})
+}
+
+// ChangeCowOwnerBatch transfers many cows in one transaction
+func (s *SmartContract) ChangeCowOwnerBatch(ctx contractapi.TransactionContextInterface, payloads []OwnerChangePayload) error {
+	//'{"Args":["changeCowOwnerBatch", "[{\"cow\":\"180501-1\",\"from_owner\":\"FARM0\",\"to_owner\":\"409-81-00000\"}]"]}'
+
+	return runBatch(ctx, len(payloads), func(batchCtx contractapi.TransactionContextInterface, i int) error {
+		return s.ChangeCowOwnerJSON(batchCtx, payloads[i])
+	})
+}
+
+// runBatch runs item for every index of a batch of size items and writes the result if all succeeded
+func runBatch(ctx contractapi.TransactionContextInterface, size int, item func(batchCtx contractapi.TransactionContextInterface, i int) error) error {
+	if size == 0 {
+		return invalidArgument("Empty batch")
+	}
+	if size > maxBatchItems {
+		return invalidArgument("Batch too large: %d items, at most %d are allowed", size, maxBatchItems)
+	}
+	argBytes := 0
+	for _, arg := range ctx.GetStub().GetArgs() {
+		argBytes += len(arg)
+	}
+	if argBytes > maxBatchArgBytes {
+		return invalidArgument("Batch too large: %d bytes, at most %d are allowed", argBytes, maxBatchArgBytes)
+	}
+
+	stub := &batchStub{ChaincodeStubInterface: ctx.GetStub(), writes: map[string][]byte{}}
+	batchCtx := new(contractapi.TransactionContext)
+	batchCtx.SetStub(stub)
+	batchCtx.SetClientIdentity(ctx.GetClientIdentity())
+
+	failed := []BatchItemError{}
+	for i := 0; i < size; i++ {
+		if err := item(batchCtx, i); err != nil {
+			e := errorOf(err)
+			failed = append(failed, BatchItemError{Index: i, Code: e.Code, Message: e.Message})
+		}
+	}
+	if len(failed) > 0 {
+		return batchError(size, failed)
+	}
+
+	return stub.flush()
+}
+
+// batchStub buffers the writes of a batch and serves reads from the buffer first
+type batchStub struct {
+	shim.ChaincodeStubInterface
+	// writes holds the pending value of every written key, nil for a deleted key
+	writes map[string][]byte
+}
+
+func (stub *batchStub) GetState(key string) ([]byte, error) {
+	if value, ok := stub.writes[key]; ok {
+		return value, nil
+	}
+	return stub.ChaincodeStubInterface.GetState(key)
+}
+
+func (stub *batchStub) PutState(key string, value []byte) error {
+	if key == "" {
+		return invalidArgument("key must not be an empty string")
+	}
+	stub.writes[key] = value
+	return nil
+}
+
+func (stub *batchStub) DelState(key string) error {
+	stub.writes[key] = nil
+	return nil
+}
+
+// flush writes the buffer to the ledger in key order
+func (stub *batchStub) flush() error {
+	keys := make([]string, 0, len(stub.writes))
+	writeBytes := 0
+	for key, value := range stub.writes {
+		keys = append(keys, key)
+		writeBytes += len(key) + len(value)
+	}
+	if writeBytes > maxBatchWriteBytes {
+		return invalidArgument("Batch too large: writes %d bytes, at most %d are allowed", writeBytes, maxBatchWriteBytes)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		var err error
+		if value := stub.writes[key]; value == nil {
+			err = stub.ChaincodeStubInterface.DelState(key)
+		} else {
+			err = stub.ChaincodeStubInterface.PutState(key, value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
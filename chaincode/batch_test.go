@@ -0,0 +1,68 @@
+package chaincode_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lotty02cho/fabcow-test/chaincode"
+)
+
+func TestRegisterCowBatch(t *testing.T) {
+	cow := func(idNo string, owner string) chaincode.CowPayload {
+		return chaincode.CowPayload{Id_no: idNo, Birth_date: "180501", Sex: "F", Father_id: "901027", Mother_id: "910101", Origin: "Iksan", Owner: owner}
+	}
+
+	tests := []struct {
+		name  string
+		batch []chaincode.CowPayload
+		code  string
+		// failed are the indexes of the failed items
+		failed []int
+	}{
+		{"unknown owner", []chaincode.CowPayload{cow("180501-1", "FARM0"), cow("180501-2", "FARM9")}, "not_found", []int{1}},
+		{"registered twice in the batch", []chaincode.CowPayload{cow("180501-1", "FARM0"), cow("180501-1", "FARM0")}, "conflict", []int{1}},
+		{"different failures", []chaincode.CowPayload{cow("", "FARM0"), cow("180501-1", "FARM0"), cow("180501-2", "FARM9")}, "invalid_argument", []int{0, 2}},
+		{"empty batch", []chaincode.CowPayload{}, "invalid_argument", nil},
+		{"every item succeeds", []chaincode.CowPayload{cow("180501-1", "FARM0"), cow("180501-2", "FARM0")}, "", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := newContract(t)
+			c.registerFarm("", "FARM0")
+
+			batchAsBytes, err := json.Marshal(test.batch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := c.ledger.Submit(c.farmer, "registerCowBatch", string(batchAsBytes))
+			if code := errorCode(t, result); code != test.code {
+				t.Fatalf("batch failed with %q, want %q (%s)", code, test.code, result.Message)
+			}
+
+			if test.code != "" {
+				e := struct {
+					Details []chaincode.BatchItemError `json:"details"`
+				}{}
+				if err := json.Unmarshal([]byte(result.Message), &e); err != nil {
+					t.Fatal(err)
+				}
+				if len(e.Details) != len(test.failed) {
+					t.Fatalf("batch reported failed items %+v, want indexes %v", e.Details, test.failed)
+				}
+				for i, item := range e.Details {
+					if item.Index != test.failed[i] {
+						t.Errorf("batch reported failed items %+v, want indexes %v", e.Details, test.failed)
+					}
+				}
+			}
+
+			// a rejected batch writes nothing, not even its good items
+			for _, payload := range test.batch {
+				stored := payload.Id_no != "" && c.ledger.State("\x00cow\x00"+payload.Id_no+"\x00") != nil
+				if stored != (test.code == "") {
+					t.Errorf("cow %q stored: %v", payload.Id_no, stored)
+				}
+			}
+		})
+	}
+}
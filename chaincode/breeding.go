@@ -0,0 +1,349 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// The breeding records of a dam are addInsemination, addPregnancyCheck and
+// addCalving remarks on it. A calving registers the calf with the dam as its
+// mother and, unless it is given, the sire of the insemination that got her in
+// calf as its father (see calvingSire), owned by the owner of the dam. Calvings
+// are indexed under ("calving", [YYYYMM, Biz_no, YYYYMMDD, Id_no, n]) by the
+// owner of the dam, with the interval since the previous calving of the dam. n
+// numbers the calvings of a dam.
+const calvingIndexObjectType = "calving"
+
+// The shortest and longest gestations a calving is put down to an insemination for
+const (
+	minGestationDays = 260
+	maxGestationDays = 300
+)
+
+// The results of a pregnancy check
+const (
+	PregnancyPositive = "positive"
+	PregnancyNegative = "negative"
+)
+
+// calvingIndexEntry is the value of a calving index key
+type calvingIndexEntry struct {
+	Calf     string `json:"calf"`
+	Calf_sex string `json:"calf_sex"`
+	// Interval is the number of days since the previous calving of the dam, 0 for her first on record
+	Interval int `json:"interval"`
+}
+
+type CalvingStats struct {
+	// Period is the month (YYYYMM) of a monthly breakdown, empty for the total
+	Period   string `json:"period"`
+	Calvings int    `json:"calvings"`
+	Males    int    `json:"males"`
+	Females  int    `json:"females"`
+	// Intervals is the number of calvings after an earlier calving of the dam, the intervals are of those
+	Intervals             int     `json:"intervals"`
+	Average_interval_days float64 `json:"average_interval_days"`
+	Min_interval_days     int     `json:"min_interval_days"`
+	Max_interval_days     int     `json:"max_interval_days"`
+}
+
+type CalvingReport struct {
+	Farm   string         `json:"farm"`
+	From   string         `json:"from"`
+	To     string         `json:"to"`
+	Total  CalvingStats   `json:"total"`
+	Months []CalvingStats `json:"months"`
+}
+
+// AddInsemination notes an artificial insemination of a dam
+func (s *SmartContract) AddInsemination(ctx contractapi.TransactionContextInterface, cowRef string, inseminationDate string, sireId string, strawLot string, technicianNm string) error {
+	//'{"Args":["addInsemination","180501-2", "20200301", "KPN1234", "LOT-20-017", "Park"]}'
+	//inseminationDate	-- YYYYMMDD
+	//sireId			-- traceability (or KPN) number of the sire of the semen
+	//strawLot			-- lot number of the semen straw
+	//technicianNm		-- name of the inseminator (may be empty)
+
+	return s.AddInseminationJSON(ctx, InseminationPayload{Cow: cowRef, Insemination_date: inseminationDate, Sire_id: sireId, Straw_lot: strawLot, Technician_nm: technicianNm})
+}
+
+// AddInseminationJSON is addInsemination with named fields
+func (s *SmartContract) AddInseminationJSON(ctx contractapi.TransactionContextInterface, payload InseminationPayload) error {
+	variables := []string{"addInsemination.insemination_date", "addInsemination.sire_id", "addInsemination.straw_lot", "addInsemination.technician_nm"}
+
+	date, err := normalizeDate(payload.Insemination_date)
+	if err != nil {
+		return withContext("insemination_date", err)
+	}
+	if strings.TrimSpace(payload.Sire_id) == "" {
+		return invalidArgument("Incorrect value. sire_id must not be empty")
+	}
+
+	return s.addDamRemarks(ctx, payload.Cow, variables, []string{date, payload.Sire_id, payload.Straw_lot, payload.Technician_nm})
+}
+
+// AddPregnancyCheck notes the result of a pregnancy check of a dam
+func (s *SmartContract) AddPregnancyCheck(ctx contractapi.TransactionContextInterface, cowRef string, checkDate string, result string, method string, veterinarianNm string) error {
+	//'{"Args":["addPregnancyCheck","180501-2", "20200420", "positive", "ultrasound", "Kim"]}'
+	//checkDate			-- YYYYMMDD
+	//result			-- positive or negative
+	//method			-- rectal palpation, ultrasound, blood test, ... (may be empty)
+	//veterinarianNm	-- who checked the dam (may be empty)
+
+	return s.AddPregnancyCheckJSON(ctx, PregnancyCheckPayload{Cow: cowRef, Check_date: checkDate, Result: result, Method: method, Veterinarian_nm: veterinarianNm})
+}
+
+// AddPregnancyCheckJSON is addPregnancyCheck with named fields
+func (s *SmartContract) AddPregnancyCheckJSON(ctx contractapi.TransactionContextInterface, payload PregnancyCheckPayload) error {
+	variables := []string{"addPregnancyCheck.check_date", "addPregnancyCheck.result", "addPregnancyCheck.method", "addPregnancyCheck.veterinarian_nm"}
+
+	date, err := normalizeDate(payload.Check_date)
+	if err != nil {
+		return withContext("check_date", err)
+	}
+	result := strings.ToLower(strings.TrimSpace(payload.Result))
+	if result != PregnancyPositive && result != PregnancyNegative {
+		return invalidArgument("Incorrect result %q, expecting %s or %s", payload.Result, PregnancyPositive, PregnancyNegative)
+	}
+
+	return s.addDamRemarks(ctx, payload.Cow, variables, []string{date, result, payload.Method, payload.Veterinarian_nm})
+}
+
+// AddCalving notes a calving of a dam and registers the calf
+func (s *SmartContract) AddCalving(ctx contractapi.TransactionContextInterface, cowRef string, calvingDate string, calfId string, calfSex string, fatherId string, origin string) error {
+	//'{"Args":["addCalving","180501-2", "20201210", "201210-1", "F", "", ""]}'
+	//calvingDate	-- YYYYMMDD, the birth date of the calf
+	//calfId		-- traceability number of the calf
+	//calfSex		-- M or F
+	//fatherId		-- sire of the calf, empty for the sire of the insemination that got the dam in calf
+	//origin		-- origin of the calf, empty for the origin of the dam
+
+	return s.AddCalvingJSON(ctx, CalvingPayload{Cow: cowRef, Calving_date: calvingDate, Calf_id: calfId, Calf_sex: calfSex, Father_id: fatherId, Origin: origin})
+}
+
+// AddCalvingJSON is addCalving with named fields
+func (s *SmartContract) AddCalvingJSON(ctx contractapi.TransactionContextInterface, payload CalvingPayload) error {
+	variables := []string{"addCalving.calving_date", "addCalving.calf_id", "addCalving.calf_sex", "addCalving.sire_id"}
+
+	date, err := normalizeDate(payload.Calving_date)
+	if err != nil {
+		return withContext("calving_date", err)
+	}
+	if strings.TrimSpace(payload.Calf_id) == "" {
+		return invalidArgument("Incorrect value. calf_id must not be empty")
+	}
+	calfSex := strings.ToUpper(strings.TrimSpace(payload.Calf_sex))
+	if calfSex != "M" && calfSex != "F" {
+		return invalidArgument("Incorrect calf_sex %q, expecting M or F", payload.Calf_sex)
+	}
+
+	APIstub := ctx.GetStub()
+
+	key, dam, err := getLiveCow(APIstub, payload.Cow)
+	if err != nil {
+		return err
+	}
+	if err := assertDam(dam); err != nil {
+		return err
+	}
+
+	// like treatments and inspections, a calving is recorded once it happened
+	txDay, err := withdrawalQueryDate(APIstub, "")
+	if err != nil {
+		return err
+	}
+	calving, _ := parseDate(date)
+	if calving.After(txDay) {
+		return withContext("calving_date", invalidArgument("%s is after the transaction date %s", date, txDay.Format("20060102")))
+	}
+	if born, ok := birthDay(dam.Birth_date, txDay); ok && !calving.After(born) {
+		return withContext("calving_date", invalidArgument("%s is not after the birth of cow %s on %s", date, dam.Id_no, born.Format("20060102")))
+	}
+
+	interval := 0
+	if previous := lastRemarkEvent(dam.Remarks, "addCalving"); previous != nil {
+		if previous["calving_date"] >= date {
+			return invalidArgument("Calving on %s is not after the previous calving of cow %s on %s", date, dam.Id_no, previous["calving_date"])
+		}
+		interval = ageInDays(previous["calving_date"], date)
+	}
+
+	fatherId := strings.TrimSpace(payload.Father_id)
+	if fatherId == "" {
+		fatherId = calvingSire(dam, date)
+	}
+	origin := payload.Origin
+	if strings.TrimSpace(origin) == "" {
+		origin = dam.Origin
+	}
+
+	// the calf is registered first, a calf number already in use leaves the dam untouched
+	if err := s.RegisterCowJSON(ctx, CowPayload{Id_no: payload.Calf_id, Birth_date: date, Sex: calfSex, Father_id: fatherId, Mother_id: dam.Id_no, Origin: origin, Owner: dam.Owner.Biz_no}); err != nil {
+		return err
+	}
+
+	dam.setCowRemarks(variables, []string{date, payload.Calf_id, calfSex, fatherId})
+
+	n := len(remarkEvents(dam.Remarks, "addCalving")) - 1
+	indexKey, err := APIstub.CreateCompositeKey(calvingIndexObjectType, []string{date[:6], dam.Owner.Biz_no, date, dam.Id_no, strconv.Itoa(n)})
+	if err != nil {
+		return err
+	}
+	entryAsBytes, err := json.Marshal(calvingIndexEntry{Calf: payload.Calf_id, Calf_sex: calfSex, Interval: interval})
+	if err != nil {
+		return err
+	}
+	if err := APIstub.PutState(indexKey, entryAsBytes); err != nil {
+		return err
+	}
+
+	return putRecord(APIstub, key, &dam)
+}
+
+// calvingSire returns the sire of the insemination that got dam in calf for the
+// calving on date, empty when it can not be told. It looks at the inseminations
+// after the previous calving that lie a gestation length before date, and takes
+// the latest one a positive pregnancy check confirmed before the next
+// insemination, or the latest one when none was confirmed.
+func calvingSire(dam Cow, date string) string {
+	previous := ""
+	for _, calving := range remarkEvents(dam.Remarks, "addCalving") {
+		if calving["calving_date"] < date && calving["calving_date"] > previous {
+			previous = calving["calving_date"]
+		}
+	}
+
+	inseminations := remarkEvents(dam.Remarks, "addInsemination")
+	sort.SliceStable(inseminations, func(i, j int) bool {
+		return inseminations[i]["insemination_date"] < inseminations[j]["insemination_date"]
+	})
+	checks := remarkEvents(dam.Remarks, "addPregnancyCheck")
+
+	sire, confirmed := "", false
+	for i, insemination := range inseminations {
+		inseminated := insemination["insemination_date"]
+		if inseminated <= previous {
+			continue
+		}
+		if days := ageInDays(inseminated, date); days < minGestationDays || days > maxGestationDays {
+			continue
+		}
+		// a positive check before the next insemination found the dam in calf by this one
+		next := date
+		if i+1 < len(inseminations) && inseminations[i+1]["insemination_date"] < next {
+			next = inseminations[i+1]["insemination_date"]
+		}
+		positive := false
+		for _, check := range checks {
+			if check["result"] == PregnancyPositive && check["check_date"] > inseminated && check["check_date"] < next {
+				positive = true
+			}
+		}
+		if positive || !confirmed {
+			sire, confirmed = insemination["sire_id"], positive
+		}
+	}
+	return sire
+}
+
+// GetCalvingStats returns the calvings of a farm over a period with the calving intervals of its dams
+func (s *SmartContract) GetCalvingStats(ctx contractapi.TransactionContextInterface, farm string, from string, to string) (*CalvingReport, error) {
+	//'{"Args":["getCalvingStats", "FARM0", "20200101", "20201231"]}'
+	//farm	-- Biz_no of the farm, empty for all farms
+	//from	-- first calving date, YYYYMMDD
+	//to	-- last calving date, YYYYMMDD
+
+	fromDate, toDate, months, err := statsPeriod(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := CalvingReport{Farm: farm, From: fromDate, To: toDate, Months: []CalvingStats{}}
+	total := &calvingAggregate{}
+	for _, month := range months {
+		monthly := &calvingAggregate{period: month}
+		err := scanMonthIndex(ctx.GetStub(), calvingIndexObjectType, month, farm, fromDate, toDate, func(value []byte) error {
+			entry := calvingIndexEntry{}
+			if err := json.Unmarshal(value, &entry); err != nil {
+				return err
+			}
+			monthly.add(entry)
+			total.add(entry)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if monthly.stats.Calvings > 0 {
+			report.Months = append(report.Months, monthly.result())
+		}
+	}
+	report.Total = total.result()
+
+	return &report, nil
+}
+
+// addDamRemarks appends the remarks of a breeding record to a dam
+func (s *SmartContract) addDamRemarks(ctx contractapi.TransactionContextInterface, cowRef string, variables []string, values []string) error {
+	APIstub := ctx.GetStub()
+
+	key, dam, err := getLiveCow(APIstub, cowRef)
+	if err != nil {
+		return err
+	}
+	if err := assertDam(dam); err != nil {
+		return err
+	}
+
+	dam.setCowRemarks(variables, values)
+
+	return putRecord(APIstub, key, &dam)
+}
+
+// assertDam refuses breeding records on bulls and steers
+func assertDam(cow Cow) error {
+	if strings.ToUpper(strings.TrimSpace(cow.Sex)) == "M" {
+		return invalidState("Cow %s is male, breeding records are kept on dams", cow.Id_no)
+	}
+	return nil
+}
+
+// calvingAggregate counts calving index entries
+type calvingAggregate struct {
+	period        string
+	stats         CalvingStats
+	intervalTotal int
+}
+
+func (a *calvingAggregate) add(entry calvingIndexEntry) {
+	a.stats.Calvings++
+	switch entry.Calf_sex {
+	case "M":
+		a.stats.Males++
+	case "F":
+		a.stats.Females++
+	}
+	if entry.Interval <= 0 {
+		return
+	}
+	if a.stats.Intervals == 0 || entry.Interval < a.stats.Min_interval_days {
+		a.stats.Min_interval_days = entry.Interval
+	}
+	if entry.Interval > a.stats.Max_interval_days {
+		a.stats.Max_interval_days = entry.Interval
+	}
+	a.stats.Intervals++
+	a.intervalTotal += entry.Interval
+}
+
+func (a *calvingAggregate) result() CalvingStats {
+	stats := a.stats
+	stats.Period = a.period
+	if stats.Intervals > 0 {
+		stats.Average_interval_days = round(float64(a.intervalTotal)/float64(stats.Intervals), 1)
+	}
+	return stats
+}
@@ -0,0 +1,87 @@
+package chaincode_test
+
+import (
+	"testing"
+)
+
+func TestCalvingRegistration(t *testing.T) {
+	// the dam calved on 20181210 before, the calving registered is on 20191210
+	tests := []struct {
+		name string
+		// inseminations are date and sire, checks date and result
+		inseminations [][2]string
+		checks        [][2]string
+		fatherID      string
+		want          string
+	}{
+		{"single insemination", [][2]string{{"20190301", "KPN1"}}, nil, "", "KPN1"},
+		{"sire given", [][2]string{{"20190301", "KPN1"}}, nil, "KPN9", "KPN9"},
+		{"latest insemination", [][2]string{{"20190215", "KPN1"}, {"20190305", "KPN2"}}, nil, "", "KPN2"},
+		{"confirmed insemination", [][2]string{{"20190215", "KPN1"}, {"20190305", "KPN2"}}, [][2]string{{"20190301", "positive"}}, "", "KPN1"},
+		{"negative check", [][2]string{{"20190215", "KPN1"}, {"20190305", "KPN2"}}, [][2]string{{"20190301", "negative"}}, "", "KPN2"},
+		{"insemination too close to the calving", [][2]string{{"20190301", "KPN1"}, {"20190601", "KPN2"}}, nil, "", "KPN1"},
+		{"only inseminations too close to the calving", [][2]string{{"20190601", "KPN2"}}, [][2]string{{"20190701", "positive"}}, "", ""},
+		{"insemination before the previous calving", [][2]string{{"20181101", "KPN1"}}, nil, "", ""},
+		{"no insemination", nil, nil, "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := newContract(t)
+			c.registerFarm("", "FARM0")
+			c.registerCow("", "150501-2", "F", "FARM0")
+			c.submit("addCalving", "150501-2", "20181210", "181210-1", "M", "KPN0", "")
+			for _, insemination := range test.inseminations {
+				c.submit("addInsemination", "150501-2", insemination[0], insemination[1], "LOT-1", "Park")
+			}
+			for _, check := range test.checks {
+				c.submit("addPregnancyCheck", "150501-2", check[0], check[1], "ultrasound", "Kim")
+			}
+
+			c.submit("addCalving", "150501-2", "20191210", "191210-1", "F", test.fatherID, "")
+
+			calf := c.cow("191210-1")
+			if calf.Father_id != test.want {
+				t.Errorf("calf has father %q, want %q", calf.Father_id, test.want)
+			}
+			if calf.Mother_id != "150501-2" || calf.Birth_date != "20191210" || calf.Sex != "F" || calf.Owner.Biz_no != "FARM0" {
+				t.Errorf("calf registered as %+v", calf)
+			}
+			if sire := remark(c.cow("150501-2"), "addCalving.sire_id"); sire != test.want {
+				t.Errorf("calving noted with sire %q, want %q", sire, test.want)
+			}
+		})
+	}
+
+	t.Run("refused", func(t *testing.T) {
+		c := newContract(t)
+		c.registerFarm("", "FARM0")
+		c.registerCow("", "150501-1", "M", "FARM0")
+		c.registerCow("", "150501-2", "F", "FARM0")
+		c.registerCow("", "150501-3", "F", "FARM0")
+		c.registerCow("", "191210-9", "F", "FARM0")
+		c.submit("addCalving", "150501-2", "20181210", "181210-1", "M", "", "")
+
+		for _, test := range []struct {
+			name string
+			args []string
+			code string
+		}{
+			{"male dam", []string{"150501-1", "20191210", "191210-1", "F", "", ""}, "invalid_state"},
+			{"calf number in use", []string{"150501-2", "20191210", "191210-9", "F", "", ""}, "conflict"},
+			{"not after the previous calving", []string{"150501-2", "20181210", "191210-1", "F", "", ""}, "invalid_argument"},
+			{"calf sex", []string{"150501-2", "20191210", "191210-1", "X", "", ""}, "invalid_argument"},
+			// the ledger clock starts on 20200101, the dams are born on 180501
+			{"after the transaction date", []string{"150501-2", "20200201", "191210-1", "F", "", ""}, "invalid_argument"},
+			{"before the birth of the dam", []string{"150501-3", "20180401", "191210-1", "F", "", ""}, "invalid_argument"},
+			{"no calendar date", []string{"150501-2", "20190231", "191210-1", "F", "", ""}, "invalid_argument"},
+		} {
+			result := c.ledger.Submit(c.farmer, "addCalving", test.args...)
+			if code := errorCode(t, result); code != test.code {
+				t.Errorf("%s: addCalving failed with %q, want %q (%s)", test.name, code, test.code, result.Message)
+			}
+		}
+		if c.ledger.State("\x00cow\x00191210-1\x00") != nil {
+			t.Errorf("calf of a refused calving registered")
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package chaincode
+
+import (
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// RegisterInProcessesBundleNum registers a bundle packed by a processor
+func (s *SmartContract) RegisterInProcessesBundleNum(ctx contractapi.TransactionContextInterface, legacyKey string, idNo string, barcodeId string, packageDate string, part string, weight string, purchaseNm string, purchaseBizNo string) error {
+	//'{"Args":["registerInProcessesBundleNum","BUNDLE0", "180501-2", "8801234567890", "20190601", "sirloin", "10", "Daejeon Butcher", "305-81-00000"]}'
+	//legacyKey			-- legacy Bundle Key, kept as an alias (may be empty)
+	//idNo				-- traceability number of the cow
+	//barcodeId			-- barcode id
+	//packageDate		-- packing date
+	//part				-- cut
+	//weight			-- weight
+	//purchaseNm		-- buyer name
+	//purchaseBizNo		-- buyer business registration number
+
+	return s.RegisterInProcessesBundleNumJSON(ctx, BundlePayload{Legacy_key: legacyKey, Id_no: idNo, Barcode_id: barcodeId, Package_date: packageDate, Part: part, Weight: weight, Purchase_nm: purchaseNm, Purchase_biz_no: purchaseBizNo})
+}
+
+// RegisterInProcessesBundleNumJSON is registerInProcessesBundleNum with named fields
+func (s *SmartContract) RegisterInProcessesBundleNumJSON(ctx contractapi.TransactionContextInterface, payload BundlePayload) error {
+	variables := []string{"registerInProcessesBundleNum.id_no", "registerInProcessesBundleNum.barcode_id", "registerInProcessesBundleNum.package_date", "registerInProcessesBundleNum.part", "registerInProcessesBundleNum.weight", "registerInProcessesBundleNum.purchase_nm", "registerInProcessesBundleNum.purchase_biz_no"}
+
+	return s.registerBundle(ctx, payload, variables)
+}
+
+// RegisterInSalesBundleNum registers a bundle repacked by a seller
+func (s *SmartContract) RegisterInSalesBundleNum(ctx contractapi.TransactionContextInterface, legacyKey string, idNo string, barcodeId string, packageDate string, part string, weight string, purchaseNm string, purchaseBizNo string) error {
+	//'{"Args":["registerInSalesBundleNum","BUNDLE1", "180501-2", "8801234567891", "20190602", "sirloin", "2", "Kim", "Empty"]}'
+	//same arguments as registerInProcessesBundleNum
+
+	return s.RegisterInSalesBundleNumJSON(ctx, BundlePayload{Legacy_key: legacyKey, Id_no: idNo, Barcode_id: barcodeId, Package_date: packageDate, Part: part, Weight: weight, Purchase_nm: purchaseNm, Purchase_biz_no: purchaseBizNo})
+}
+
+// RegisterInSalesBundleNumJSON is registerInSalesBundleNum with named fields
+func (s *SmartContract) RegisterInSalesBundleNumJSON(ctx contractapi.TransactionContextInterface, payload BundlePayload) error {
+	variables := []string{"registerInSalesBundleNumbundle.id_no", "registerInSalesBundleNum.barcode_id", "registerInSalesBundleNum.package_date", "registerInSalesBundleNum.part", "registerInSalesBundleNum.weight", "registerInSalesBundleNum.purchase_nm", "registerInSalesBundleNum.purchase_biz_no"}
+
+	return s.registerBundle(ctx, payload, variables)
+}
+
+// registerBundle stores the bundle under its barcode and notes it on the cow,
+// variables are the remark keys of id_no, barcode_id, package_date, part, weight, purchase_nm, purchase_biz_no
+func (s *SmartContract) registerBundle(ctx contractapi.TransactionContextInterface, payload BundlePayload, variables []string) error {
+	APIstub := ctx.GetStub()
+
+	key, cow, err := getLiveCow(APIstub, payload.Id_no)
+	if err != nil {
+		return err
+	}
+
+	bundleKey, err := makeBundleKey(APIstub, payload.Barcode_id)
+	if err != nil {
+		return err
+	}
+	if err := assertNotExists(APIstub, bundleKey, "Bundle "+payload.Barcode_id); err != nil {
+		return err
+	}
+
+	var bundle = Bundle{SchemaVersion: currentSchemaVersion, Id_no: cow.Id_no, Barcode_id: payload.Barcode_id, Package_date: payload.Package_date, Part: payload.Part, Weight: payload.Weight, Purchase_nm: payload.Purchase_nm, Purchase_biz_no: payload.Purchase_biz_no}
+	if err := putRecord(APIstub, bundleKey, &bundle); err != nil {
+		return err
+	}
+	if err := putAlias(APIstub, payload.Legacy_key, bundleKey); err != nil {
+		return err
+	}
+
+	cow.setCowRemarks(variables, []string{payload.Id_no, payload.Barcode_id, payload.Package_date, payload.Part, payload.Weight, payload.Purchase_nm, payload.Purchase_biz_no})
+
+	return putRecord(APIstub, key, &cow)
+}
@@ -0,0 +1,124 @@
+package chaincode
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// Chaincode runs the contract. It is the contract API chaincode, except that a
+// transaction which has a JSON variant (addBTVaccine and addBTVaccineJSON) can
+// also be called with a single JSON object in place of its positional arguments,
+// and that failures answer with the error body and status of their code (see Error).
+type Chaincode struct {
+	*contractapi.ContractChaincode
+	// payloadTransactions are the transactions with a JSON variant
+	payloadTransactions map[string]bool
+}
+
+// NewChaincode returns the chaincode to start from main
+func NewChaincode() (*Chaincode, error) {
+	cc, err := contractapi.NewChaincode(NewSmartContract())
+	if err != nil {
+		return nil, err
+	}
+
+	payloadTransactions := map[string]bool{}
+	contractType := reflect.TypeOf(&SmartContract{})
+	for i := 0; i < contractType.NumMethod(); i++ {
+		name := contractType.Method(i).Name
+		if strings.HasSuffix(name, "JSON") {
+			payloadTransactions[strings.TrimSuffix(name, "JSON")] = true
+		}
+	}
+
+	return &Chaincode{ContractChaincode: cc, payloadTransactions: payloadTransactions}, nil
+}
+
+// Invoke calls the JSON variant for a single JSON object argument and the transaction itself otherwise
+func (cc *Chaincode) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+	function, args := stub.GetFunctionAndParameters()
+
+	if len(args) == 1 && strings.HasPrefix(strings.TrimSpace(args[0]), "{") {
+		if target := cc.payloadTransaction(function, args[0]); target != "" {
+			stub = &renamedStub{ChaincodeStubInterface: stub, function: target, args: args}
+		}
+	}
+
+	response := cc.ContractChaincode.Invoke(stub)
+	if response.Status >= shim.ERRORTHRESHOLD {
+		// the contract API answers every error with status 500 and the error text
+		e := responseError(response.Message)
+		response.Status = codeStatus[e.Code]
+		response.Message = e.Error()
+		txLogger(stub).warning("transaction failed", "status", response.Status, "code", e.Code, "error", e.Message)
+	}
+	return response
+}
+
+// payloadTransaction is the JSON variant to call for function, "" if there is none
+func (cc *Chaincode) payloadTransaction(function string, payload string) string {
+	prefix := function[:strings.LastIndex(function, ":")+1]
+	name := transactionName(function)
+
+	// the legacy registerOwner takes every owner type
+	if name == "RegisterOwner" {
+		name = ownerTransaction(payload)
+	}
+
+	if !cc.payloadTransactions[name] {
+		return ""
+	}
+	return prefix + name + "JSON"
+}
+
+// ownerTransaction picks the registration of a registerOwner payload by its id field
+func ownerTransaction(payload string) string {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal([]byte(payload), &fields); err != nil {
+		return ""
+	}
+
+	if _, ok := fields["farm_id"]; ok {
+		return "RegisterFarm"
+	} else if _, ok := fields["slaughter_id"]; ok {
+		return "RegisterSlaughterhouse"
+	} else if _, ok := fields["process_id"]; ok {
+		return "RegisterProcessor"
+	} else if _, ok := fields["sale_id"]; ok {
+		return "RegisterSeller"
+	}
+	return ""
+}
+
+// renamedStub is a stub called with another function name
+type renamedStub struct {
+	shim.ChaincodeStubInterface
+	function string
+	args     []string
+}
+
+func (stub *renamedStub) GetFunctionAndParameters() (string, []string) {
+	return stub.function, stub.args
+}
+
+func (stub *renamedStub) GetStringArgs() []string {
+	return append([]string{stub.function}, stub.args...)
+}
+
+func (stub *renamedStub) GetArgs() [][]byte {
+	args := [][]byte{[]byte(stub.function)}
+	for _, arg := range stub.args {
+		args = append(args, []byte(arg))
+	}
+	return args
+}
+
+func (stub *renamedStub) GetArgsSlice() ([]byte, error) {
+	return bytes.Join(stub.GetArgs(), nil), nil
+}
@@ -0,0 +1,127 @@
+package chaincode_test
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/lotty02cho/fabcow-test/chaincode"
+	"github.com/lotty02cho/fabcow-test/ledgertest"
+)
+
+// contract is the fabcow chaincode on an in-memory ledger with a farmer and an administrator
+type contract struct {
+	t      *testing.T
+	ledger *ledgertest.Ledger
+	farmer *ledgertest.Identity
+	admin  *ledgertest.Identity
+}
+
+// newChaincode builds the chaincode once, the contract API takes seconds to
+// read the contract and a chaincode keeps no state between transactions
+var newChaincode = sync.OnceValues(chaincode.NewChaincode)
+
+func newContract(t *testing.T) *contract {
+	t.Helper()
+
+	cc, err := newChaincode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	farmer, err := ledgertest.NewIdentity("Org1MSP", "farmer1", "client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin, err := ledgertest.NewIdentity("Org1MSP", "admin1", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &contract{t: t, ledger: ledgertest.New(seedChaincode{cc}, "mychannel"), farmer: farmer, admin: admin}
+}
+
+// seedChaincode also takes "seed", which writes a raw value the way earlier chaincode versions did
+type seedChaincode struct {
+	*chaincode.Chaincode
+}
+
+func (cc seedChaincode) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+	function, args := stub.GetFunctionAndParameters()
+	if function != "seed" {
+		return cc.Chaincode.Invoke(stub)
+	}
+	if err := stub.PutState(args[0], []byte(args[1])); err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(nil)
+}
+
+// submit runs a transaction of the farmer that has to succeed
+func (c *contract) submit(function string, args ...string) {
+	c.t.Helper()
+	if err := c.ledger.Submit(c.farmer, function, args...).Err(); err != nil {
+		c.t.Fatalf("%s: %v", function, err)
+	}
+}
+
+// submitJSON runs a transaction of the farmer with its payload as a JSON object
+func (c *contract) submitJSON(function string, payload interface{}) ledgertest.Result {
+	c.t.Helper()
+	payloadAsBytes, err := json.Marshal(payload)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return c.ledger.Submit(c.farmer, function, string(payloadAsBytes))
+}
+
+// registerFarm registers a farm with farmID as its business number
+func (c *contract) registerFarm(legacyKey string, farmID string) {
+	c.t.Helper()
+	c.submit("registerFarm", legacyKey, farmID, "ChukLim "+farmID, "Iksan", "C", "Kim", "530118")
+}
+
+// registerCow registers a cow of the farm farmID
+func (c *contract) registerCow(legacyKey string, idNo string, sex string, farmID string) {
+	c.t.Helper()
+	c.submit("registerCow", legacyKey, idNo, "180501", sex, "901027", "910101", "Iksan", farmID)
+}
+
+// cow reads the cow ref points to
+func (c *contract) cow(ref string) chaincode.Cow {
+	c.t.Helper()
+	result := c.ledger.Evaluate(c.farmer, "readCow", ref)
+	if err := result.Err(); err != nil {
+		c.t.Fatalf("readCow %q: %v", ref, err)
+	}
+	cow := chaincode.Cow{}
+	if err := json.Unmarshal(result.Payload, &cow); err != nil {
+		c.t.Fatal(err)
+	}
+	return cow
+}
+
+// errorCode is the code of the error a transaction failed with, empty if it succeeded
+func errorCode(t *testing.T, result ledgertest.Result) string {
+	t.Helper()
+	if result.Err() == nil {
+		return ""
+	}
+	e := chaincode.Error{}
+	if err := json.Unmarshal([]byte(result.Message), &e); err != nil {
+		t.Fatalf("error body %q: %v", result.Message, err)
+	}
+	return e.Code
+}
+
+// remark is the value of the last remark with key on cow, empty if it has none
+func remark(cow chaincode.Cow, key string) string {
+	value := ""
+	for _, remark := range cow.Remarks {
+		if remark.Key == key {
+			value = remark.Value
+		}
+	}
+	return value
+}
@@ -0,0 +1,536 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Bundles are kept chilled from packing to sale. Data loggers send their
+// temperature and humidity readings, usually many at a time with
+// AddColdChainReadingBatch. Every reading is kept under ("reading",
+// [Barcode_id, Read_at, Logger_id]) and checked against the rule of the part
+// of the bundle ("coldchainrule", [part]), or the "*" rule for parts without
+// their own. A breach starts with the first reading out of range and ends with
+// the next reading of that measure back in range. The bundle keeps its breaches
+// in Cold_chain, so it only changes when one starts or ends, and a transaction
+// that starts breaches sets a ColdChainBreach event listing them.
+//
+// The readings of a bundle must come in time order, the breaches depend on it.
+const (
+	readingObjectType       = "reading"
+	coldChainRuleObjectType = "coldchainrule"
+	coldChainLogObjectType  = "coldchain"
+	// defaultColdChainPart is the part of the rule for parts without one
+	defaultColdChainPart = "*"
+	coldChainBreachEvent = "ColdChainBreach"
+	// readAtLayout is the stored Read_at, in UTC so the reading keys sort by time
+	readAtLayout = "2006-01-02T15:04:05Z"
+)
+
+// The measures of a reading
+const (
+	MeasureTemperature = "temperature"
+	MeasureHumidity    = "humidity"
+)
+
+// ColdChainRange is the allowed range of a measure, both ends included
+type ColdChainRange struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// ColdChainRule holds the ranges a part is kept in, a measure without a range is not checked
+type ColdChainRule struct {
+	Part        string          `json:"Part"`
+	Temperature *ColdChainRange `json:"Temperature,omitempty" metadata:",optional"`
+	Humidity    *ColdChainRange `json:"Humidity,omitempty" metadata:",optional"`
+	Stamp       *Stamp          `json:"Stamp,omitempty" metadata:",optional"`
+}
+
+// ColdChain is the cold chain record of a bundle
+type ColdChain struct {
+	// Breached is set with the first breach and stays set
+	Breached bool              `json:"Breached"`
+	Breaches []ColdChainBreach `json:"Breaches"`
+}
+
+type ColdChainBreach struct {
+	Measure string         `json:"Measure"`
+	Range   ColdChainRange `json:"Range"`
+	// Value is the reading that started the breach
+	Value      float64 `json:"Value"`
+	Logger_id  string  `json:"Logger_id"`
+	Started_at string  `json:"Started_at"`
+	// Ended_at is the time of the first reading back in range, empty while the breach lasts
+	Ended_at string `json:"Ended_at,omitempty" metadata:",optional"`
+}
+
+type ColdChainReading struct {
+	Logger_id   string  `json:"Logger_id"`
+	Read_at     string  `json:"Read_at"`
+	Temperature float64 `json:"Temperature"`
+	Humidity    float64 `json:"Humidity,omitempty" metadata:",optional"`
+	// Humidity_measured is Humidity_measured of the payload of the reading
+	Humidity_measured bool `json:"-" metadata:"-"`
+}
+
+// UnmarshalJSON reads a left out humidity as not measured, not as 0%
+func (p *ColdChainReadingPayload) UnmarshalJSON(data []byte) error {
+	type payload ColdChainReadingPayload
+	fields := struct {
+		*payload
+		Humidity *float64 `json:"humidity"`
+	}{payload: (*payload)(p)}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	p.Humidity, p.Humidity_measured = 0, fields.Humidity != nil
+	if p.Humidity_measured {
+		p.Humidity = *fields.Humidity
+	}
+	return nil
+}
+
+// MarshalJSON writes a measured humidity of 0% and leaves out one not measured
+func (p ColdChainReadingPayload) MarshalJSON() ([]byte, error) {
+	type payload ColdChainReadingPayload
+	fields := struct {
+		payload
+		Humidity *float64 `json:"humidity,omitempty"`
+	}{payload: payload(p)}
+	if p.Humidity_measured {
+		fields.Humidity = &p.Humidity
+	}
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON reads a left out humidity as not measured, not as 0%
+func (r *ColdChainReading) UnmarshalJSON(data []byte) error {
+	type reading ColdChainReading
+	fields := struct {
+		*reading
+		Humidity *float64 `json:"Humidity"`
+	}{reading: (*reading)(r)}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	r.Humidity, r.Humidity_measured = 0, fields.Humidity != nil
+	if r.Humidity_measured {
+		r.Humidity = *fields.Humidity
+	}
+	return nil
+}
+
+// MarshalJSON writes a measured humidity of 0% and leaves out one not measured
+func (r ColdChainReading) MarshalJSON() ([]byte, error) {
+	type reading ColdChainReading
+	fields := struct {
+		reading
+		Humidity *float64 `json:"Humidity,omitempty"`
+	}{reading: reading(r)}
+	if r.Humidity_measured {
+		fields.Humidity = &r.Humidity
+	}
+	return json.Marshal(fields)
+}
+
+// ColdChainLog is every reading of a bundle with its breaches
+type ColdChainLog struct {
+	Barcode_id      string             `json:"Barcode_id"`
+	Part            string             `json:"Part"`
+	Rule            *ColdChainRule     `json:"Rule,omitempty" metadata:",optional"`
+	Cold_chain      *ColdChain         `json:"Cold_chain,omitempty" metadata:",optional"`
+	First_read_at   string             `json:"First_read_at"`
+	Last_read_at    string             `json:"Last_read_at"`
+	Min_temperature float64            `json:"Min_temperature"`
+	Max_temperature float64            `json:"Max_temperature"`
+	Readings        []ColdChainReading `json:"Readings"`
+}
+
+// coldChainStatus is the summary of the readings of a bundle, kept next to them
+type coldChainStatus struct {
+	Readings        int     `json:"readings"`
+	First_read_at   string  `json:"first_read_at"`
+	Last_read_at    string  `json:"last_read_at"`
+	Min_temperature float64 `json:"min_temperature"`
+	Max_temperature float64 `json:"max_temperature"`
+}
+
+// ColdChainBreachStart is a breach in the ColdChainBreach event
+type ColdChainBreachStart struct {
+	Barcode_id string         `json:"barcode_id"`
+	Part       string         `json:"part"`
+	Measure    string         `json:"measure"`
+	Value      float64        `json:"value"`
+	Range      ColdChainRange `json:"range"`
+	Logger_id  string         `json:"logger_id"`
+	Read_at    string         `json:"read_at"`
+}
+
+func (rule *ColdChainRule) stamp(stamp Stamp) {
+	rule.Stamp = &stamp
+}
+
+// SetColdChainRule sets the ranges a part is kept in (administrators only, see adminTransactions)
+func (s *SmartContract) SetColdChainRule(ctx contractapi.TransactionContextInterface, part string, minTemperature string, maxTemperature string, minHumidity string, maxHumidity string) error {
+	//'{"Args":["setColdChainRule", "sirloin", "-2", "4", "", ""]}'
+	//'{"Args":["setColdChainRule", "*", "-2", "5", "", ""]}'
+	//part				-- cut the rule is for, * for the cuts without their own rule
+	//minTemperature	-- lowest allowed temperature in °C, empty with maxTemperature to not check it
+	//maxTemperature	-- highest allowed temperature in °C
+	//minHumidity		-- lowest allowed relative humidity in %, empty with maxHumidity to not check it
+	//maxHumidity		-- highest allowed relative humidity in %
+
+	payload := ColdChainRulePayload{Part: part}
+	var err error
+	if payload.Temperature, err = parseColdChainRange(MeasureTemperature, minTemperature, maxTemperature); err != nil {
+		return err
+	}
+	if payload.Humidity, err = parseColdChainRange(MeasureHumidity, minHumidity, maxHumidity); err != nil {
+		return err
+	}
+	return s.SetColdChainRuleJSON(ctx, payload)
+}
+
+// SetColdChainRuleJSON is setColdChainRule with named fields (administrators only, see adminTransactions)
+func (s *SmartContract) SetColdChainRuleJSON(ctx contractapi.TransactionContextInterface, payload ColdChainRulePayload) error {
+	APIstub := ctx.GetStub()
+
+	part := coldChainPart(payload.Part)
+	if part == "" {
+		return invalidArgument("Incorrect value. part must not be empty")
+	}
+	if payload.Temperature == nil && payload.Humidity == nil {
+		return invalidArgument("A rule needs a temperature or a humidity range")
+	}
+	for measure, r := range map[string]*ColdChainRange{MeasureTemperature: payload.Temperature, MeasureHumidity: payload.Humidity} {
+		if r != nil && r.Min > r.Max {
+			return invalidArgument("Incorrect %s range: min %g is above max %g", measure, r.Min, r.Max)
+		}
+	}
+
+	key, err := APIstub.CreateCompositeKey(coldChainRuleObjectType, []string{part})
+	if err != nil {
+		return err
+	}
+	return putRecord(APIstub, key, &ColdChainRule{Part: part, Temperature: payload.Temperature, Humidity: payload.Humidity})
+}
+
+// QueryColdChainRules returns every cold chain rule
+func (s *SmartContract) QueryColdChainRules(ctx contractapi.TransactionContextInterface) ([]ColdChainRule, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(coldChainRuleObjectType, []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	rules := []ColdChainRule{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		rule := ColdChainRule{}
+		if err := json.Unmarshal(queryResponse.Value, &rule); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// AddColdChainReading records a temperature and humidity reading of a bundle
+func (s *SmartContract) AddColdChainReading(ctx contractapi.TransactionContextInterface, bundleRef string, loggerId string, readAt string, temperature string, humidity string) error {
+	//'{"Args":["addColdChainReading", "8801234567890", "LOGGER-7", "2019-06-01T09:30:00+09:00", "3.5", "85"]}'
+	//bundleRef		-- bundle (Barcode_id or legacy key)
+	//loggerId		-- data logger that took the reading
+	//readAt		-- time of the reading, RFC 3339
+	//temperature	-- °C
+	//humidity		-- relative humidity in %, empty when the logger does not measure it
+
+	payload := ColdChainReadingPayload{Bundle: bundleRef, Logger_id: loggerId, Read_at: readAt}
+	var err error
+	if payload.Temperature, err = strconv.ParseFloat(strings.TrimSpace(temperature), 64); err != nil {
+		return invalidArgument("Incorrect temperature %q, expecting a number", temperature)
+	}
+	if strings.TrimSpace(humidity) != "" {
+		if payload.Humidity, err = strconv.ParseFloat(strings.TrimSpace(humidity), 64); err != nil {
+			return invalidArgument("Incorrect humidity %q, expecting a number", humidity)
+		}
+		payload.Humidity_measured = true
+	}
+	return s.AddColdChainReadingJSON(ctx, payload)
+}
+
+// AddColdChainReadingJSON is addColdChainReading with named fields
+func (s *SmartContract) AddColdChainReadingJSON(ctx contractapi.TransactionContextInterface, payload ColdChainReadingPayload) error {
+	APIstub := ctx.GetStub()
+
+	breaches, err := addColdChainReading(APIstub, payload)
+	if err != nil {
+		return err
+	}
+	return setColdChainBreachEvent(APIstub, breaches)
+}
+
+// AddColdChainReadingBatch records the readings a data logger collected in one transaction
+func (s *SmartContract) AddColdChainReadingBatch(ctx contractapi.TransactionContextInterface, payloads []ColdChainReadingPayload) error {
+	//'{"Args":["addColdChainReadingBatch", "[{\"bundle\":\"8801234567890\",\"logger_id\":\"LOGGER-7\",\"read_at\":\"2019-06-01T09:30:00+09:00\",\"temperature\":3.5},...]"]}'
+
+	breaches := []ColdChainBreachStart{}
+	err := runBatch(ctx, len(payloads), func(batchCtx contractapi.TransactionContextInterface, i int) error {
+		started, err := addColdChainReading(batchCtx.GetStub(), payloads[i])
+		breaches = append(breaches, started...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	// a transaction has one event, it lists the breaches of every item
+	return setColdChainBreachEvent(ctx.GetStub(), breaches)
+}
+
+// ReadColdChainLog returns the readings and breaches of a bundle
+func (s *SmartContract) ReadColdChainLog(ctx contractapi.TransactionContextInterface, bundleRef string) (*ColdChainLog, error) {
+	APIstub := ctx.GetStub()
+
+	_, bundle, err := getBundle(APIstub, bundleRef)
+	if err != nil {
+		return nil, err
+	}
+	rule, err := getColdChainRule(APIstub, bundle.Part)
+	if err != nil {
+		return nil, err
+	}
+	_, status, err := getColdChainStatus(APIstub, bundle.Barcode_id)
+	if err != nil {
+		return nil, err
+	}
+
+	log := ColdChainLog{Barcode_id: bundle.Barcode_id, Part: bundle.Part, Rule: rule, Cold_chain: bundle.Cold_chain, First_read_at: status.First_read_at, Last_read_at: status.Last_read_at,
+		Min_temperature: status.Min_temperature, Max_temperature: status.Max_temperature, Readings: []ColdChainReading{}}
+
+	resultsIterator, err := APIstub.GetStateByPartialCompositeKey(readingObjectType, []string{bundle.Barcode_id})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		reading := ColdChainReading{}
+		if err := json.Unmarshal(queryResponse.Value, &reading); err != nil {
+			return nil, err
+		}
+		log.Readings = append(log.Readings, reading)
+	}
+	return &log, nil
+}
+
+// addColdChainReading stores a reading, updates the breaches of its bundle and returns the breaches it started
+func addColdChainReading(APIstub shim.ChaincodeStubInterface, payload ColdChainReadingPayload) ([]ColdChainBreachStart, error) {
+	if strings.TrimSpace(payload.Logger_id) == "" {
+		return nil, invalidArgument("Incorrect value. logger_id must not be empty")
+	}
+	readTime, err := time.Parse(time.RFC3339, strings.TrimSpace(payload.Read_at))
+	if err != nil {
+		return nil, invalidArgument("Incorrect read_at %q, expecting RFC 3339 (2019-06-01T09:30:00+09:00)", payload.Read_at)
+	}
+	readAt := readTime.UTC().Format(readAtLayout)
+	if payload.Humidity_measured && (payload.Humidity < 0 || payload.Humidity > 100) {
+		return nil, invalidArgument("Incorrect humidity %g, expecting a percentage", payload.Humidity)
+	}
+
+	bundleKey, bundle, err := getBundle(APIstub, payload.Bundle)
+	if err != nil {
+		return nil, err
+	}
+	statusKey, status, err := getColdChainStatus(APIstub, bundle.Barcode_id)
+	if err != nil {
+		return nil, err
+	}
+	if readAt < status.Last_read_at {
+		return nil, invalidArgument("Reading at %s is older than the last reading of bundle %s at %s, readings must be sent in time order", readAt, bundle.Barcode_id, status.Last_read_at)
+	}
+
+	readingKey, err := APIstub.CreateCompositeKey(readingObjectType, []string{bundle.Barcode_id, readAt, payload.Logger_id})
+	if err != nil {
+		return nil, err
+	}
+	if err := assertNotExists(APIstub, readingKey, "Reading of "+payload.Logger_id+" at "+readAt); err != nil {
+		return nil, err
+	}
+	readingAsBytes, err := json.Marshal(ColdChainReading{Logger_id: payload.Logger_id, Read_at: readAt, Temperature: payload.Temperature, Humidity: payload.Humidity, Humidity_measured: payload.Humidity_measured})
+	if err != nil {
+		return nil, err
+	}
+	if err := APIstub.PutState(readingKey, readingAsBytes); err != nil {
+		return nil, err
+	}
+
+	if status.Readings == 0 {
+		status.First_read_at, status.Min_temperature, status.Max_temperature = readAt, payload.Temperature, payload.Temperature
+	}
+	status.Readings++
+	status.Last_read_at = readAt
+	if payload.Temperature < status.Min_temperature {
+		status.Min_temperature = payload.Temperature
+	}
+	if payload.Temperature > status.Max_temperature {
+		status.Max_temperature = payload.Temperature
+	}
+	statusAsBytes, err := json.Marshal(status)
+	if err != nil {
+		return nil, err
+	}
+	if err := APIstub.PutState(statusKey, statusAsBytes); err != nil {
+		return nil, err
+	}
+
+	rule, err := getColdChainRule(APIstub, bundle.Part)
+	if err != nil {
+		return nil, err
+	}
+	var temperatureRange, humidityRange *ColdChainRange
+	if rule != nil {
+		temperatureRange, humidityRange = rule.Temperature, rule.Humidity
+	}
+	started := []ColdChainBreachStart{}
+	changed := false
+	for _, measure := range []struct {
+		name    string
+		value   float64
+		allowed *ColdChainRange
+	}{
+		{MeasureTemperature, payload.Temperature, temperatureRange},
+		{MeasureHumidity, payload.Humidity, humidityRange},
+	} {
+		// a logger without a humidity sensor sends none, its breach goes on
+		if measure.name == MeasureHumidity && !payload.Humidity_measured {
+			continue
+		}
+		out := measure.allowed != nil && (measure.value < measure.allowed.Min || measure.value > measure.allowed.Max)
+		open := openBreach(bundle.Cold_chain, measure.name)
+		switch {
+		case open != nil && !out:
+			open.Ended_at = readAt
+			changed = true
+		case open == nil && out:
+			if bundle.Cold_chain == nil {
+				bundle.Cold_chain = &ColdChain{Breaches: []ColdChainBreach{}}
+			}
+			bundle.Cold_chain.Breached = true
+			bundle.Cold_chain.Breaches = append(bundle.Cold_chain.Breaches, ColdChainBreach{Measure: measure.name, Range: *measure.allowed, Value: measure.value, Logger_id: payload.Logger_id, Started_at: readAt})
+			started = append(started, ColdChainBreachStart{Barcode_id: bundle.Barcode_id, Part: bundle.Part, Measure: measure.name, Value: measure.value, Range: *measure.allowed, Logger_id: payload.Logger_id, Read_at: readAt})
+			changed = true
+		}
+	}
+	if changed {
+		if err := putRecord(APIstub, bundleKey, &bundle); err != nil {
+			return nil, err
+		}
+	}
+	return started, nil
+}
+
+// openBreach is the breach of measure that has not ended, nil if there is none
+func openBreach(coldChain *ColdChain, measure string) *ColdChainBreach {
+	if coldChain == nil {
+		return nil
+	}
+	for i := range coldChain.Breaches {
+		if breach := &coldChain.Breaches[i]; breach.Measure == measure && breach.Ended_at == "" {
+			return breach
+		}
+	}
+	return nil
+}
+
+func setColdChainBreachEvent(APIstub shim.ChaincodeStubInterface, breaches []ColdChainBreachStart) error {
+	if len(breaches) == 0 {
+		return nil
+	}
+	eventAsBytes, err := json.Marshal(map[string][]ColdChainBreachStart{"breaches": breaches})
+	if err != nil {
+		return err
+	}
+	return APIstub.SetEvent(coldChainBreachEvent, eventAsBytes)
+}
+
+// getColdChainRule reads the rule of part, or the default rule, nil if neither is set
+func getColdChainRule(APIstub shim.ChaincodeStubInterface, part string) (*ColdChainRule, error) {
+	for _, rulePart := range []string{coldChainPart(part), defaultColdChainPart} {
+		key, err := APIstub.CreateCompositeKey(coldChainRuleObjectType, []string{rulePart})
+		if err != nil {
+			return nil, err
+		}
+		ruleAsBytes, err := APIstub.GetState(key)
+		if err != nil {
+			return nil, err
+		}
+		if ruleAsBytes != nil {
+			rule := ColdChainRule{}
+			if err := json.Unmarshal(ruleAsBytes, &rule); err != nil {
+				return nil, err
+			}
+			return &rule, nil
+		}
+	}
+	return nil, nil
+}
+
+func getColdChainStatus(APIstub shim.ChaincodeStubInterface, barcodeId string) (string, coldChainStatus, error) {
+	status := coldChainStatus{}
+	key, err := APIstub.CreateCompositeKey(coldChainLogObjectType, []string{barcodeId})
+	if err != nil {
+		return "", status, err
+	}
+	statusAsBytes, err := APIstub.GetState(key)
+	if err != nil || statusAsBytes == nil {
+		return key, status, err
+	}
+	return key, status, json.Unmarshal(statusAsBytes, &status)
+}
+
+// coldChainPart is the part a rule is stored under, cuts are matched without regard to case
+func coldChainPart(part string) string {
+	return strings.ToLower(strings.TrimSpace(part))
+}
+
+// parseColdChainRange reads the range of a positional rule, none if both ends are empty
+func parseColdChainRange(measure string, min string, max string) (*ColdChainRange, error) {
+	min, max = strings.TrimSpace(min), strings.TrimSpace(max)
+	if min == "" && max == "" {
+		return nil, nil
+	}
+	r := ColdChainRange{}
+	var err error
+	if r.Min, err = strconv.ParseFloat(min, 64); err != nil {
+		return nil, invalidArgument("Incorrect minimum %s %q, expecting a number", measure, min)
+	}
+	if r.Max, err = strconv.ParseFloat(max, 64); err != nil {
+		return nil, invalidArgument("Incorrect maximum %s %q, expecting a number", measure, max)
+	}
+	return &r, nil
+}
+
+// bundleBeforeBreaches is bundle as it was with only its first n breaches,
+// breaches are only ever added so this is an earlier state of its certificate
+func bundleBeforeBreaches(bundle *Bundle, n int) *Bundle {
+	earlier := *bundle
+	if n == 0 {
+		earlier.Cold_chain = nil
+		return &earlier
+	}
+	coldChain := *bundle.Cold_chain
+	coldChain.Breaches = coldChain.Breaches[:n]
+	earlier.Cold_chain = &coldChain
+	return &earlier
+}
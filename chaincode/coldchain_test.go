@@ -0,0 +1,58 @@
+package chaincode_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lotty02cho/fabcow-test/chaincode"
+)
+
+func TestColdChainHumidity(t *testing.T) {
+	c := newContract(t)
+	c.registerFarm("", "FARM0")
+	c.registerCow("", "180501-1", "M", "FARM0")
+	c.submit("registerInProcessesBundleNum", "", "180501-1", "8801234567890", "20190601", "sirloin", "10", "Daejeon Butcher", "305-81-00000")
+	if err := c.ledger.Submit(c.admin, "setColdChainRule", "sirloin", "-2", "4", "0", "90").Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	readings := []struct {
+		readAt   string
+		humidity string
+		// open tells whether a humidity breach lasts after the reading
+		open bool
+	}{
+		{"2019-06-01T09:00:00Z", "95", true},
+		// a logger without a humidity sensor leaves the breach as it is
+		{"2019-06-01T10:00:00Z", "", true},
+		// a dry room is a reading, not a missing sensor
+		{"2019-06-01T11:00:00Z", "0", false},
+	}
+	for _, reading := range readings {
+		c.submit("addColdChainReading", "8801234567890", "LOGGER-7", reading.readAt, "2", reading.humidity)
+
+		result := c.ledger.Evaluate(c.farmer, "readColdChainLog", "8801234567890")
+		if err := result.Err(); err != nil {
+			t.Fatal(err)
+		}
+		log := chaincode.ColdChainLog{}
+		if err := json.Unmarshal(result.Payload, &log); err != nil {
+			t.Fatal(err)
+		}
+		if log.Cold_chain == nil || len(log.Cold_chain.Breaches) != 1 {
+			t.Fatalf("after the reading at %s the breaches are %+v, want the humidity breach", reading.readAt, log.Cold_chain)
+		}
+		if open := log.Cold_chain.Breaches[0].Ended_at == ""; open != reading.open {
+			t.Errorf("after the reading at %s the breach is open: %v, want %v", reading.readAt, open, reading.open)
+		}
+		last := log.Readings[len(log.Readings)-1]
+		if last.Humidity_measured != (reading.humidity != "") {
+			t.Errorf("reading at %s has humidity measured: %v, want %q", reading.readAt, last.Humidity_measured, reading.humidity)
+		}
+	}
+
+	result := c.submitJSON("addColdChainReadingJSON", chaincode.ColdChainReadingPayload{Bundle: "8801234567890", Logger_id: "LOGGER-7", Read_at: "2019-06-01T12:00:00Z", Temperature: 2, Humidity: 101, Humidity_measured: true})
+	if code := errorCode(t, result); code != "invalid_argument" {
+		t.Errorf("humidity of 101%% failed with %q, want invalid_argument", code)
+	}
+}
@@ -0,0 +1,147 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+// Package chaincode is the fabcow livestock traceability contract.
+//
+// It is written on the Fabric contract API: every exported method of
+// SmartContract is a transaction with typed parameters and return values, and
+// the transaction metadata with the JSON schema of every parameter and return
+// value is generated from those signatures (query the
+// "org.hyperledger.fabric:GetMetadata" transaction to read it).
+//
+// The contract API upper-cases the first letter of the function name it is
+// called with, so the existing camelCase names ("registerCow", "addBTVaccine",
+// ...) keep working. registerOwner, whose arguments depend on the owner type,
+// is routed by legacyTransaction.
+package chaincode
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-contract-api-go/metadata"
+)
+
+// Define the Smart Contract structure
+type SmartContract struct {
+	contractapi.Contract
+}
+
+// adminTransactions can only be submitted by administrators, checked in beforeTransaction
+var adminTransactions = map[string]bool{
+	"Migrate":    true,
+	"RestoreCow": true,
+	// the cold chain rules decide which bundles are flagged
+	"SetColdChainRule":     true,
+	"SetColdChainRuleJSON": true,
+	// as do the growth references the cows are compared to
+	"SetGrowthReference":     true,
+	"SetGrowthReferenceJSON": true,
+}
+
+// NewSmartContract returns the contract with its hooks set up
+func NewSmartContract() *SmartContract {
+	s := new(SmartContract)
+	s.Name = "fabcow"
+	s.Info = metadata.InfoMetadata{
+		Title:       "fabcow",
+		Description: "Livestock traceability from farm to shelf",
+		Version:     "2.0.0",
+	}
+	s.BeforeTransaction = s.beforeTransaction
+	s.UnknownTransaction = s.legacyTransaction
+	return s
+}
+
+// GetEvaluateTransactions marks the read only transactions in the metadata
+func (s *SmartContract) GetEvaluateTransactions() []string {
+	return []string{
+		"Query",
+		"QueryAllCows",
+		"QueryAllOwners",
+		"ReadCow",
+		"ReadOwner",
+		"ReadBundle",
+		"ReadHACCP",
+		"ReadRFID",
+		"MigrationStatus",
+		"GetTraceCertificate",
+		"VerifyTraceCertificate",
+		"GetGradeStats",
+		"GetSireProgenyReport",
+		"CompareSires",
+		"GetDeathStats",
+		"QueryArchivedCows",
+		"QueryOwnersByType",
+		"GetEPCISDocument",
+		"GetEPCISEvents",
+		"GetTraceabilityReport",
+		"QueryColdChainRules",
+		"ReadColdChainLog",
+		"QueryGrowthReferences",
+		"GetGrowthCurve",
+		"GetGrowthReport",
+		"GetWithdrawalHolds",
+		"GetCalvingStats",
+		"GetAuctionPriceHistory",
+		"GetAuctionPriceStats",
+	}
+}
+
+// beforeTransaction runs before every transaction of the contract
+func (s *SmartContract) beforeTransaction(ctx contractapi.TransactionContextInterface) error {
+	function, _ := ctx.GetStub().GetFunctionAndParameters()
+	name := transactionName(function)
+
+	txLogger(ctx.GetStub()).info("transaction")
+
+	if adminTransactions[name] {
+		if err := assertAdmin(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// legacyTransaction handles the function names which have no transaction of the same name
+func (s *SmartContract) legacyTransaction(ctx contractapi.TransactionContextInterface) error {
+	function, args := ctx.GetStub().GetFunctionAndParameters()
+
+	switch function {
+	case "registerOwner":
+		return s.registerOwner(ctx, args)
+	}
+
+	return invalidArgument("Invalid Smart Contract function name: %s", function)
+}
+
+// transactionName is the name of the method the contract API calls for function
+func transactionName(function string) string {
+	// drop the contract name of "fabcow:registerCow"
+	if i := strings.LastIndex(function, ":"); i >= 0 {
+		function = function[i+1:]
+	}
+	if function == "" {
+		return function
+	}
+	name := []rune(function)
+	name[0] = unicode.ToUpper(name[0])
+	return string(name)
+}
@@ -0,0 +1,236 @@
+package chaincode
+
+import (
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// InitLedger stores sample owners and cows
+func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
+	//'{"Args":["initLedger"]}'
+
+	APIstub := ctx.GetStub()
+
+	owners := []Owner{
+		Owner{SchemaVersion: currentSchemaVersion, Owner_type: OwnerTypeFarm, Owner_id: "01", Owner_nm: "ChukLim1", Owner_addr: "Iksan", Livestock: "C", Owner_user_nm: "Kim Duck Bae", Owner_user_birth: "530118", Biz_no: "01"},
+		Owner{SchemaVersion: currentSchemaVersion, Owner_type: OwnerTypeFarm, Owner_id: "02", Owner_nm: "ChukLim2", Owner_addr: "Jeonju", Livestock: "C", Owner_user_nm: "Kim Sam Sun", Owner_user_birth: "520202", Biz_no: "02"},
+		Owner{SchemaVersion: currentSchemaVersion, Owner_type: OwnerTypeFarm, Owner_id: "03", Owner_nm: "ChukLim3", Owner_addr: "Daejeon", Livestock: "C", Owner_user_nm: "Kim Young Mi", Owner_user_birth: "610118", Biz_no: "03"},
+	}
+
+	cows := []Cow{
+		Cow{SchemaVersion: currentSchemaVersion, Id_no: "180501-2", Birth_date: "180501", Sex: "F", Father_id: "901027", Mother_id: "910101", Origin: "Korea Jeonbuk", Owner: owners[0]},
+		Cow{SchemaVersion: currentSchemaVersion, Id_no: "180502-1", Birth_date: "180502", Sex: "M", Father_id: "901027", Mother_id: "910101", Origin: "Korea Jeonbuk", Owner: owners[1]},
+		Cow{SchemaVersion: currentSchemaVersion, Id_no: "180503-1", Birth_date: "180503", Sex: "M", Father_id: "901027", Mother_id: "910101", Origin: "Korea Jeonbuk", Owner: owners[2]},
+	}
+
+	for _, owner := range owners {
+		key, err := makeOwnerKey(APIstub, owner.Biz_no)
+		if err != nil {
+			return err
+		}
+		if err := putRecord(APIstub, key, &owner); err != nil {
+			return err
+		}
+		if err := putOwnerTypeIndex(APIstub, owner); err != nil {
+			return err
+		}
+	}
+
+	for _, cow := range cows {
+		key, err := makeCowKey(APIstub, cow.Id_no)
+		if err != nil {
+			return err
+		}
+		if err := putRecord(APIstub, key, &cow); err != nil {
+			return err
+		}
+		if err := putSireIndex(APIstub, cow); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RegisterCow registers a cow under the key derived from its traceability number
+func (s *SmartContract) RegisterCow(ctx contractapi.TransactionContextInterface, legacyKey string, idNo string, birthDate string, sex string, fatherId string, motherId string, origin string, ownerRef string) error {
+	//'{"Args":["registerCow","COW0", "180501-1","180501", "M", "630118-1", "630331-2", "Ik-San", "OWNER0"]}'
+	//legacyKey		-- legacy COW Key, kept as an alias (may be empty)
+	//idNo			-- Id_no, traceability number
+	//birthDate		-- Birth_date
+	//sex			-- Sex
+	//fatherId		-- Father_id, traceability number of the sire
+	//motherId		-- Mother_id, traceability number of the dam
+	//origin		-- Origin
+	//ownerRef		-- owner (Biz_no or legacy OWNER key)
+
+	return s.RegisterCowJSON(ctx, CowPayload{Legacy_key: legacyKey, Id_no: idNo, Birth_date: birthDate, Sex: sex, Father_id: fatherId, Mother_id: motherId, Origin: origin, Owner: ownerRef})
+}
+
+// RegisterCowJSON is registerCow with named fields
+func (s *SmartContract) RegisterCowJSON(ctx contractapi.TransactionContextInterface, payload CowPayload) error {
+	APIstub := ctx.GetStub()
+
+	key, err := makeCowKey(APIstub, payload.Id_no)
+	if err != nil {
+		return err
+	}
+	if err := assertNotExists(APIstub, key, "Cow "+payload.Id_no); err != nil {
+		return err
+	}
+
+	_, owner, err := getOwner(APIstub, payload.Owner)
+	if err != nil {
+		return err
+	}
+
+	var cow = Cow{SchemaVersion: currentSchemaVersion, Id_no: payload.Id_no, Birth_date: payload.Birth_date, Sex: payload.Sex, Father_id: payload.Father_id, Mother_id: payload.Mother_id, Origin: payload.Origin, Owner: Owner{SchemaVersion: owner.SchemaVersion, Owner_type: owner.Owner_type, Owner_id: owner.Owner_id, Owner_nm: owner.Owner_nm, Owner_addr: owner.Owner_addr, Livestock: owner.Livestock, Owner_user_nm: owner.Owner_user_nm, Owner_user_birth: owner.Owner_user_birth, Biz_no: owner.Biz_no}}
+	if err := putRecord(APIstub, key, &cow); err != nil {
+		return err
+	}
+	if err := putSireIndex(APIstub, cow); err != nil {
+		return err
+	}
+
+	return putAlias(APIstub, payload.Legacy_key, key)
+}
+
+// RegisterRFID registers the RFID tag of a cow
+func (s *SmartContract) RegisterRFID(ctx contractapi.TransactionContextInterface, cowRef string, rfidNo string) error {
+	//'{"Args":["registerRFID", "COW3", "RFID0"]}'
+	//cowRef	-- cow (Id_no or legacy COW key)
+	//rfidNo	-- RFID number
+
+	return s.RegisterRFIDJSON(ctx, RFIDPayload{Cow: cowRef, Rfid_no: rfidNo})
+}
+
+// RegisterRFIDJSON is registerRFID with named fields
+func (s *SmartContract) RegisterRFIDJSON(ctx contractapi.TransactionContextInterface, payload RFIDPayload) error {
+	APIstub := ctx.GetStub()
+
+	key, cow, err := getLiveCow(APIstub, payload.Cow)
+	if err != nil {
+		return err
+	}
+
+	rfidKey, err := makeRFIDKey(APIstub, payload.Rfid_no)
+	if err != nil {
+		return err
+	}
+	// a tag is on one cow, registering it again for the same cow only restamps it
+	existingKey, err := resolveRFIDKey(APIstub, payload.Rfid_no)
+	if err != nil {
+		return err
+	}
+	existingAsBytes, err := APIstub.GetState(existingKey)
+	if err != nil {
+		return err
+	}
+	if existingAsBytes != nil {
+		existing := RFID{}
+		if err := decodeRFID(existingAsBytes, &existing); err != nil {
+			return err
+		}
+		// tags registered before the derived keys may name the cow by its legacy key
+		if taggedKey, err := resolveKey(APIstub, cowObjectType, existing.Id_no); err != nil || taggedKey != key {
+			return conflict("RFID %s already registered to cow %s", payload.Rfid_no, existing.Id_no)
+		}
+	}
+	var rfid = RFID{SchemaVersion: currentSchemaVersion, Id_no: cow.Id_no, Rfid_no: payload.Rfid_no}
+	if err := putRecord(APIstub, rfidKey, &rfid); err != nil {
+		return err
+	}
+
+	variables := []string{"rfid.Id_no", "rfid.Rfid_no"}
+	cow.setCowRemarks(variables, []string{cow.Id_no, payload.Rfid_no})
+
+	return putRecord(APIstub, key, &cow)
+}
+
+// ChangeCowOwner moves a cow to a new owner (farm transfer, slaughterhouse, processor, seller)
+func (s *SmartContract) ChangeCowOwner(ctx contractapi.TransactionContextInterface, cowRef string, fromOwnerRef string, toOwnerRef string) error {
+	//'{"Args":["changeCowOwner", "COW3", "OWNER1", "OWNER2"]}'
+	//cowRef		-- cow
+	//fromOwnerRef	-- current owner
+	//toOwnerRef	-- new owner
+
+	return s.ChangeCowOwnerJSON(ctx, OwnerChangePayload{Cow: cowRef, From_owner: fromOwnerRef, To_owner: toOwnerRef})
+}
+
+// ChangeCowOwnerJSON is changeCowOwner with named fields
+func (s *SmartContract) ChangeCowOwnerJSON(ctx contractapi.TransactionContextInterface, payload OwnerChangePayload) error {
+	APIstub := ctx.GetStub()
+
+	key, cow, err := getLiveCow(APIstub, payload.Cow)
+	if err != nil {
+		return err
+	}
+	if err := transferCow(APIstub, &cow, payload.From_owner, payload.To_owner); err != nil {
+		return err
+	}
+
+	return putRecord(APIstub, key, &cow)
+}
+
+// transferCow moves cow from fromOwnerRef to toOwnerRef, the caller writes it
+func transferCow(APIstub shim.ChaincodeStubInterface, cow *Cow, fromOwnerRef string, toOwnerRef string) error {
+	// a transfer names the owner it takes the cow from, a stale one means the cow moved meanwhile
+	_, fromOwner, err := getOwner(APIstub, fromOwnerRef)
+	if err != nil {
+		return err
+	}
+	if fromOwner.Biz_no != cow.Owner.Biz_no {
+		return conflict("Cow %s is owned by %s, not by %s", cow.Id_no, cow.Owner.Biz_no, fromOwner.Biz_no)
+	}
+
+	_, owner, err := getOwner(APIstub, toOwnerRef)
+	if err != nil {
+		return err
+	}
+
+	cow.Owner.Owner_type = owner.Owner_type
+	cow.Owner.Owner_id = owner.Owner_id
+	cow.Owner.Owner_nm = owner.Owner_nm
+	cow.Owner.Owner_addr = owner.Owner_addr
+	cow.Owner.Livestock = owner.Livestock
+	cow.Owner.Owner_user_nm = owner.Owner_user_nm
+	cow.Owner.Owner_user_birth = owner.Owner_user_birth
+	cow.Owner.Biz_no = owner.Biz_no
+	cow.Owner.Remarks = owner.Remarks
+	return nil
+}
+
+// AddRemark appends a free key/value remark to a cow
+func (s *SmartContract) AddRemark(ctx contractapi.TransactionContextInterface, cowRef string, remarkKey string, remarkValue string) error {
+	//'{"Args":["addRemark","COW4", "addVaccine", "True"]}'
+
+	return s.AddRemarkJSON(ctx, RemarkPayload{Cow: cowRef, Key: remarkKey, Value: remarkValue})
+}
+
+// AddRemarkJSON is addRemark with named fields
+func (s *SmartContract) AddRemarkJSON(ctx contractapi.TransactionContextInterface, payload RemarkPayload) error {
+	APIstub := ctx.GetStub()
+
+	key, cow, err := getLiveCow(APIstub, payload.Cow)
+	if err != nil {
+		return err
+	}
+
+	cow.setCowRemark(Remark{Key: payload.Key, Value: payload.Value})
+
+	return putRecord(APIstub, key, &cow)
+}
+
+// addCowRemarks appends the remarks of an addInfo* style transaction to a cow
+func (s *SmartContract) addCowRemarks(ctx contractapi.TransactionContextInterface, cowRef string, variables []string, values []string) error {
+	APIstub := ctx.GetStub()
+
+	key, cow, err := getLiveCow(APIstub, cowRef)
+	if err != nil {
+		return err
+	}
+
+	cow.setCowRemarks(variables, values)
+
+	return putRecord(APIstub, key, &cow)
+}
@@ -0,0 +1,251 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// A death closes the life of a cow: it is recorded once, marks the cow Dead,
+// and every later write for the cow is refused (see getLiveCow), except the
+// confirmation of the death by a veterinarian while the cow is not archived. Deaths are indexed under
+// ("death", [YYYYMM, farm, YYYYMMDD, Id_no]) like grade results.
+const deathIndexObjectType = "death"
+
+// deathIndexEntry is the value of a death index key
+type deathIndexEntry struct {
+	Reason    string `json:"reason"`
+	Method    string `json:"method"`
+	Confirmed bool   `json:"confirmed"`
+}
+
+type DeathStats struct {
+	// Period is the month (YYYYMM) of a monthly breakdown, empty for the total
+	Period    string        `json:"period"`
+	Count     int           `json:"count"`
+	Confirmed int           `json:"confirmed"`
+	Reasons   []ReasonCount `json:"reasons"`
+	Methods   []ReasonCount `json:"methods"`
+}
+
+type DeathReport struct {
+	Farm   string       `json:"farm"`
+	From   string       `json:"from"`
+	To     string       `json:"to"`
+	Total  DeathStats   `json:"total"`
+	Months []DeathStats `json:"months"`
+}
+
+// ConfirmDeath records the confirmation of the death of a cow by a veterinarian
+func (s *SmartContract) ConfirmDeath(ctx contractapi.TransactionContextInterface, cowRef string, veterinarianNo string, veterinarianNm string, confirmDate string) error {
+	//'{"Args":["confirmDeath","COW0", "veterinarian_no", "veterinarian_nm", "confirm_date"]}'
+
+	return s.ConfirmDeathJSON(ctx, DeathConfirmationPayload{Cow: cowRef, Veterinarian_no: veterinarianNo, Veterinarian_nm: veterinarianNm, Confirm_date: confirmDate})
+}
+
+// ConfirmDeathJSON is confirmDeath with named fields
+func (s *SmartContract) ConfirmDeathJSON(ctx contractapi.TransactionContextInterface, payload DeathConfirmationPayload) error {
+	variables := []string{"confirmDeath.veterinarian_no", "confirmDeath.veterinarian_nm", "confirmDeath.confirm_date"}
+
+	if strings.TrimSpace(payload.Veterinarian_no) == "" {
+		return invalidArgument("Incorrect value. veterinarian_no must not be empty")
+	}
+	if _, err := normalizeDate(payload.Confirm_date); err != nil {
+		return withContext("confirm_date", err)
+	}
+
+	APIstub := ctx.GetStub()
+
+	key, cow, err := getCow(APIstub, payload.Cow)
+	if err != nil {
+		return err
+	}
+	if !cow.Dead {
+		return invalidState("Cow %s is not dead", cow.Id_no)
+	}
+	// an archived cow is out of the death statistics, confirming would put it back
+	if cow.Archived != nil {
+		return invalidState("Cow %s is archived, no further records are accepted", cow.Id_no)
+	}
+	if deathConfirmed(cow) {
+		return conflict("Death of cow %s is already confirmed", cow.Id_no)
+	}
+
+	cow.setCowRemarks(variables, []string{payload.Veterinarian_no, payload.Veterinarian_nm, payload.Confirm_date})
+
+	if err := putDeathIndex(APIstub, cow); err != nil {
+		return err
+	}
+	return putRecord(APIstub, key, &cow)
+}
+
+// GetDeathStats returns the deaths of a farm over a period by reason and disposal method
+func (s *SmartContract) GetDeathStats(ctx contractapi.TransactionContextInterface, farm string, from string, to string) (*DeathReport, error) {
+	//'{"Args":["getDeathStats", "FARM0", "20190101", "20191231"]}'
+	//farm	-- Biz_no of the farm, empty for all farms
+	//from	-- first date of death, YYYYMMDD
+	//to	-- last date of death, YYYYMMDD
+
+	fromDate, toDate, months, err := statsPeriod(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := DeathReport{Farm: farm, From: fromDate, To: toDate, Months: []DeathStats{}}
+	total := newDeathAggregate("")
+	for _, month := range months {
+		monthly := newDeathAggregate(month)
+		err := scanMonthIndex(ctx.GetStub(), deathIndexObjectType, month, farm, fromDate, toDate, func(value []byte) error {
+			entry := deathIndexEntry{}
+			if err := json.Unmarshal(value, &entry); err != nil {
+				return err
+			}
+			monthly.add(entry)
+			total.add(entry)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if monthly.count > 0 {
+			report.Months = append(report.Months, monthly.stats())
+		}
+	}
+	report.Total = total.stats()
+
+	return &report, nil
+}
+
+// validateDeath checks the fields a death can not be recorded without
+func validateDeath(payload DeadPayload) error {
+	if _, err := normalizeDate(payload.Det_date); err != nil {
+		return withContext("det_date", err)
+	}
+	if strings.TrimSpace(payload.Det_reason) == "" {
+		return invalidArgument("Incorrect value. det_reason must not be empty")
+	}
+	if strings.TrimSpace(payload.Det_method) == "" {
+		return invalidArgument("Incorrect value. det_method, the disposal method, must not be empty")
+	}
+	if payload.Veterinarian_no == "" && payload.Veterinarian_nm != "" {
+		return invalidArgument("Incorrect value. veterinarian_nm needs veterinarian_no")
+	}
+	return nil
+}
+
+// deathConfirmed reports whether a veterinarian confirmed the death of cow
+func deathConfirmed(cow Cow) bool {
+	if event := lastRemarkEvent(cow.Remarks, "addInfoDead"); event != nil && event["veterinarian_no"] != "" {
+		return true
+	}
+	return lastRemarkEvent(cow.Remarks, "confirmDeath") != nil
+}
+
+// putDeathIndex indexes the death of cow
+func putDeathIndex(APIstub shim.ChaincodeStubInterface, cow Cow) error {
+	key, event, err := deathIndexKey(APIstub, cow)
+	if err != nil {
+		return err
+	}
+	entryAsBytes, err := json.Marshal(deathIndexEntry{Reason: event["det_reason"], Method: event["det_method"], Confirmed: deathConfirmed(cow)})
+	if err != nil {
+		return err
+	}
+	return APIstub.PutState(key, entryAsBytes)
+}
+
+// delDeathIndex drops the index entry of the death of cow
+func delDeathIndex(APIstub shim.ChaincodeStubInterface, cow Cow) error {
+	if !cow.Dead {
+		return nil
+	}
+	key, _, err := deathIndexKey(APIstub, cow)
+	if err != nil {
+		// deaths with an unreadable date were never indexed
+		return nil
+	}
+	return APIstub.DelState(key)
+}
+
+func deathIndexKey(APIstub shim.ChaincodeStubInterface, cow Cow) (string, map[string]string, error) {
+	event := lastRemarkEvent(cow.Remarks, "addInfoDead")
+	if event == nil {
+		return "", nil, invalidState("Cow %s has no death record", cow.Id_no)
+	}
+	date, err := normalizeDate(event["det_date"])
+	if err != nil {
+		return "", nil, withContext("det_date", err)
+	}
+	farm := event["farm_id"]
+	if farm == "" {
+		farm = cowFarm(cow)
+	}
+
+	key, err := APIstub.CreateCompositeKey(deathIndexObjectType, []string{date[:6], farm, date, cow.Id_no})
+	return key, event, err
+}
+
+// indexDeaths is the migration of cows that died before deaths were indexed
+func indexDeaths(APIstub shim.ChaincodeStubInterface, key string, value []byte, run *migrationRun) error {
+	cow := Cow{}
+	if err := decodeCow(value, &cow); err != nil {
+		txLogger(APIstub).warning("migration skipped a record", "key", key, "error", err)
+		run.status.Failed = append(run.status.Failed, key)
+		return nil
+	}
+	if !cow.Dead {
+		return nil
+	}
+	if err := putDeathIndex(APIstub, cow); err != nil {
+		// an unreadable date of death can not be indexed
+		txLogger(APIstub).warning("migration skipped a record", "key", key, "error", err)
+		run.status.Failed = append(run.status.Failed, key)
+		return nil
+	}
+	run.status.Upgraded++
+	return nil
+}
+
+// deathAggregate counts death index entries
+type deathAggregate struct {
+	period    string
+	count     int
+	confirmed int
+	reasons   map[string]int
+	methods   map[string]int
+}
+
+func newDeathAggregate(period string) *deathAggregate {
+	return &deathAggregate{period: period, reasons: map[string]int{}, methods: map[string]int{}}
+}
+
+func (a *deathAggregate) add(entry deathIndexEntry) {
+	a.count++
+	if entry.Confirmed {
+		a.confirmed++
+	}
+	a.reasons[entry.Reason]++
+	a.methods[entry.Method]++
+}
+
+func (a *deathAggregate) stats() DeathStats {
+	return DeathStats{Period: a.period, Count: a.count, Confirmed: a.confirmed, Reasons: reasonCounts(a.reasons, a.count), Methods: reasonCounts(a.methods, a.count)}
+}
+
+// reasonCounts lists the counts most frequent first
+func reasonCounts(counts map[string]int, total int) []ReasonCount {
+	results := []ReasonCount{}
+	for reason, count := range counts {
+		results = append(results, ReasonCount{Reason: reason, Count: count, Percent: percent(count, total)})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Count != results[j].Count {
+			return results[i].Count > results[j].Count
+		}
+		return results[i].Reason < results[j].Reason
+	})
+	return results
+}
@@ -0,0 +1,61 @@
+package chaincode_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lotty02cho/fabcow-test/chaincode"
+)
+
+func TestDeath(t *testing.T) {
+	c := newContract(t)
+	c.registerFarm("", "FARM0")
+	for _, idNo := range []string{"180501-1", "180501-2", "180501-3"} {
+		c.registerCow("", idNo, "M", "FARM0")
+	}
+	c.submit("addInfoDead", "180501-1", "FARM0", "180501-1", "20190601", "pneumonia", "incineration")
+	c.submit("addInfoDead", "180501-2", "FARM0", "180501-2", "2019-07-02", "accident", "burial")
+	c.submit("confirmDeath", "180501-1", "VET-9", "Park", "20190602")
+
+	if cow := c.cow("180501-1"); !cow.Dead || remark(cow, "addInfoDead.det_reason") != "pneumonia" {
+		t.Errorf("dead cow recorded as %+v", cow)
+	}
+
+	tests := []struct {
+		name     string
+		function string
+		args     []string
+		code     string
+	}{
+		{"record on a dead cow", "addRemark", []string{"180501-1", "note", "seen"}, "invalid_state"},
+		{"second death", "addInfoDead", []string{"180501-1", "FARM0", "180501-1", "20190603", "pneumonia", "burial"}, "invalid_state"},
+		{"second confirmation", "confirmDeath", []string{"180501-1", "VET-9", "Park", "20190603"}, "conflict"},
+		{"confirmation of a live cow", "confirmDeath", []string{"180501-3", "VET-9", "Park", "20190603"}, "invalid_state"},
+		{"confirmation without a veterinarian", "confirmDeath", []string{"180501-2", "", "Park", "20190703"}, "invalid_argument"},
+		{"no reason", "addInfoDead", []string{"180501-3", "FARM0", "180501-3", "20190601", "", "burial"}, "invalid_argument"},
+		{"no disposal method", "addInfoDead", []string{"180501-3", "FARM0", "180501-3", "20190601", "pneumonia", " "}, "invalid_argument"},
+		{"no date of death", "addInfoDead", []string{"180501-3", "FARM0", "180501-3", "June", "pneumonia", "burial"}, "invalid_argument"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := c.ledger.Submit(c.farmer, test.function, test.args...)
+			if code := errorCode(t, result); code != test.code {
+				t.Errorf("%s failed with %q, want %q (%s)", test.function, code, test.code, result.Message)
+			}
+		})
+	}
+
+	t.Run("statistics", func(t *testing.T) {
+		result := c.ledger.Evaluate(c.farmer, "getDeathStats", "FARM0", "20190101", "20191231")
+		if err := result.Err(); err != nil {
+			t.Fatal(err)
+		}
+		report := chaincode.DeathReport{}
+		if err := json.Unmarshal(result.Payload, &report); err != nil {
+			t.Fatal(err)
+		}
+		if report.Total.Count != 2 || report.Total.Confirmed != 1 || len(report.Total.Reasons) != 2 || len(report.Months) != 2 {
+			t.Errorf("death report %+v, want 2 deaths in 2 months, 1 confirmed, 2 reasons", report)
+		}
+	})
+}
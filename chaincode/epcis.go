@@ -0,0 +1,398 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// The EPCIS export gives trading partners the life of a cow as GS1 EPCIS 2.0
+// events in a JSON-LD document. The events are rebuilt from the key history
+// of the cow, one version at a time:
+//
+//	registration                 ObjectEvent ADD, commissioning
+//	owner change                 ObjectEvent OBSERVE, receiving, with the
+//	                             owning parties as source and destination
+//	addFAMDVaccine               ObjectEvent OBSERVE, fabcow:vaccinating
+//	addBTVaccine                 ObjectEvent OBSERVE, inspecting
+//	addInfoDead                  ObjectEvent DELETE, destroying
+//	addInfoInspect               TransformationEvent cow -> carcass, slaughtering
+//	addInfoGradeResult           ObjectEvent OBSERVE of the carcass, inspecting
+//	register*BundleNum           TransformationEvent carcass -> bundle, packing
+//
+// Only the traceability data goes out, the names and birth dates of people
+// in the remarks do not. Business dates are Korean dates, the event time of
+// an event dated YYYYMMDD is midnight KST, event times are all given in KST.
+//
+// Every write of a cow indexes the months of the events it adds under
+// ("epcis", [YYYYMM, Id_no]), so a time range reads the history of only the
+// cows with events in its months, like the grade and death statistics.
+const (
+	epcisContext   = "https://ref.gs1.org/standards/epcis/epcis-context.jsonld"
+	epcisNamespace = "https://github.com/lotty02cho/fabcow-test/epcis#"
+	// maxEPCISEvents bounds the document of a time range
+	maxEPCISEvents = 10000
+
+	epcisIndexObjectType = "epcis"
+)
+
+type EPCISDocument struct {
+	Context       []interface{} `json:"@context"`
+	Type          string        `json:"type"`
+	SchemaVersion string        `json:"schemaVersion"`
+	CreationDate  string        `json:"creationDate"`
+	EpcisBody     EPCISBody     `json:"epcisBody"`
+}
+
+type EPCISBody struct {
+	EventList []EPCISEvent `json:"eventList"`
+}
+
+type EPCISEvent struct {
+	Type                string             `json:"type"`
+	EventID             string             `json:"eventID"`
+	EventTime           string             `json:"eventTime"`
+	EventTimeZoneOffset string             `json:"eventTimeZoneOffset"`
+	RecordTime          string             `json:"recordTime,omitempty"`
+	EpcList             []string           `json:"epcList,omitempty"`
+	InputEPCList        []string           `json:"inputEPCList,omitempty"`
+	OutputEPCList       []string           `json:"outputEPCList,omitempty"`
+	TransformationID    string             `json:"transformationID,omitempty"`
+	Action              string             `json:"action,omitempty"`
+	BizStep             string             `json:"bizStep,omitempty"`
+	Disposition         string             `json:"disposition,omitempty"`
+	BizLocation         *EPCISLocation     `json:"bizLocation,omitempty"`
+	SourceList          []EPCISSource      `json:"sourceList,omitempty"`
+	DestinationList     []EPCISDestination `json:"destinationList,omitempty"`
+	Ilmd                map[string]string  `json:"ilmd,omitempty"`
+	// Details are the fabcow fields of an event that EPCIS has no place for
+	Details map[string]string `json:"fabcow:details,omitempty"`
+
+	// timestamp orders the events of a time range
+	timestamp int64
+}
+
+type EPCISLocation struct {
+	ID string `json:"id"`
+}
+
+type EPCISSource struct {
+	Type   string `json:"type"`
+	Source string `json:"source"`
+}
+
+type EPCISDestination struct {
+	Type        string `json:"type"`
+	Destination string `json:"destination"`
+}
+
+// GetEPCISDocument returns the EPCIS document of a cow, or of a bundle: the
+// events of its cow up to the packing of that bundle
+func (s *SmartContract) GetEPCISDocument(ctx contractapi.TransactionContextInterface, ref string) (string, error) {
+	versions, bundle, err := traceSubject(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	barcode := ""
+	if bundle != nil {
+		barcode = bundle.Barcode_id
+	}
+	return epcisDocument(ctx, epcisEvents(versions, barcode))
+}
+
+// GetEPCISEvents returns the EPCIS document of every event of every cow, archived
+// ones included, with an event time from..to (YYYYMMDD, KST)
+func (s *SmartContract) GetEPCISEvents(ctx contractapi.TransactionContextInterface, from string, to string) (string, error) {
+	APIstub := ctx.GetStub()
+
+	fromDate, toDate, months, err := statsPeriod(from, to)
+	if err != nil {
+		return "", err
+	}
+
+	// a cow with events in several months is listed in each of them
+	cowIds := []string{}
+	listed := map[string]bool{}
+	for _, month := range months {
+		resultsIterator, err := APIstub.GetStateByPartialCompositeKey(epcisIndexObjectType, []string{month})
+		if err != nil {
+			return "", err
+		}
+		for resultsIterator.HasNext() {
+			queryResponse, err := resultsIterator.Next()
+			if err != nil {
+				resultsIterator.Close()
+				return "", err
+			}
+			_, keyParts, err := APIstub.SplitCompositeKey(queryResponse.Key)
+			if err != nil {
+				resultsIterator.Close()
+				return "", err
+			}
+			if !listed[keyParts[1]] {
+				listed[keyParts[1]] = true
+				cowIds = append(cowIds, keyParts[1])
+			}
+		}
+		resultsIterator.Close()
+	}
+
+	events := []EPCISEvent{}
+	for _, id := range cowIds {
+		key, err := makeCowKey(APIstub, id)
+		if err != nil {
+			return "", err
+		}
+		versions, err := cowHistory(APIstub, key)
+		if err != nil {
+			return "", err
+		}
+		for _, event := range epcisEvents(versions, "") {
+			date, err := epcisEventDate(event)
+			if err != nil {
+				return "", err
+			}
+			if date < fromDate || date > toDate {
+				continue
+			}
+			if len(events) == maxEPCISEvents {
+				return "", invalidArgument("Too many events: more than %d from %s to %s, ask for a shorter period", maxEPCISEvents, fromDate, toDate)
+			}
+			events = append(events, event)
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].timestamp < events[j].timestamp
+	})
+	return epcisDocument(ctx, events)
+}
+
+// epcisEventDate is the day (YYYYMMDD, KST) of the event time of event
+func epcisEventDate(event EPCISEvent) (string, error) {
+	eventTime, err := time.Parse(time.RFC3339Nano, event.EventTime)
+	if err != nil {
+		return "", err
+	}
+	return eventTime.In(koreaZone).Format("20060102"), nil
+}
+
+// putEPCISIndex indexes the months of the events the write of cow at key adds.
+// The stored cow is the version before the write.
+func putEPCISIndex(APIstub shim.ChaincodeStubInterface, key string, cow Cow) error {
+	versions := []cowVersion{}
+	previousAsBytes, err := APIstub.GetState(key)
+	if err != nil {
+		return err
+	}
+	if previousAsBytes != nil {
+		previous := cowVersion{}
+		if err := decodeCow(previousAsBytes, &previous.cow); err != nil {
+			return err
+		}
+		versions = append(versions, previous)
+	}
+	timestamp, err := APIstub.GetTxTimestamp()
+	if err != nil {
+		return err
+	}
+	current := cowVersion{cow: cow, txID: APIstub.GetTxID(), timestamp: timestamp.Seconds*1e9 + int64(timestamp.Nanos)}
+	versions = append(versions, current)
+
+	added := []EPCISEvent{}
+	for _, event := range epcisEvents(versions, "") {
+		if event.timestamp == current.timestamp {
+			added = append(added, event)
+		}
+	}
+	return putEPCISMonths(APIstub, cow.Id_no, added)
+}
+
+// putEPCISMonths indexes cow id under the month of every event
+func putEPCISMonths(APIstub shim.ChaincodeStubInterface, id string, events []EPCISEvent) error {
+	months := map[string]bool{}
+	for _, event := range events {
+		date, err := epcisEventDate(event)
+		if err != nil {
+			return err
+		}
+		months[date[:6]] = true
+	}
+	for month := range months {
+		indexKey, err := APIstub.CreateCompositeKey(epcisIndexObjectType, []string{month, id})
+		if err != nil {
+			return err
+		}
+		// an empty value would delete the key
+		if err := APIstub.PutState(indexKey, []byte{0x00}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexEPCISEvents is the migration of cows written before their events were indexed
+func indexEPCISEvents(APIstub shim.ChaincodeStubInterface, key string, value []byte, run *migrationRun) error {
+	versions, err := cowHistory(APIstub, key)
+	if err != nil {
+		txLogger(APIstub).warning("migration skipped a record", "key", key, "error", err)
+		run.status.Failed = append(run.status.Failed, key)
+		return nil
+	}
+	if len(versions) == 0 {
+		return nil
+	}
+	if err := putEPCISMonths(APIstub, versions[len(versions)-1].cow.Id_no, epcisEvents(versions, "")); err != nil {
+		return err
+	}
+	run.status.Upgraded++
+	return nil
+}
+
+func epcisDocument(ctx contractapi.TransactionContextInterface, events []EPCISEvent) (string, error) {
+	creationDate, err := txTime(ctx.GetStub())
+	if err != nil {
+		return "", err
+	}
+	document := EPCISDocument{
+		Context:       []interface{}{epcisContext, map[string]string{"fabcow": epcisNamespace}},
+		Type:          "EPCISDocument",
+		SchemaVersion: "2.0",
+		CreationDate:  creationDate,
+		EpcisBody:     EPCISBody{EventList: events},
+	}
+	documentAsBytes, err := json.Marshal(document)
+	if err != nil {
+		return "", err
+	}
+	return string(documentAsBytes), nil
+}
+
+// epcisEvents rebuilds the events of a cow from its versions. With barcode set
+// the packing of other bundles of the cow is left out.
+func epcisEvents(versions []cowVersion, barcode string) []EPCISEvent {
+	events := []EPCISEvent{}
+	for i, version := range versions {
+		cow := version.cow
+		cowEPC := epcisCow(cow.Id_no)
+		carcassEPC := "urn:fabcow:carcass:" + cow.Id_no
+		location := epcisOwnerLocation(cow.Owner)
+		recordTime := time.Unix(0, version.timestamp).UTC()
+
+		// events are numbered within their version, so a cow and a bundle document agree on the ids
+		n := 0
+		add := func(event EPCISEvent, date string) {
+			event.EventID = fmt.Sprintf("urn:fabcow:event:%s:%s:%d", version.txID, cow.Id_no, n)
+			n++
+			event.EventTime, event.EventTimeZoneOffset = epcisTime(date, recordTime), "+09:00"
+			event.RecordTime = recordTime.Format(time.RFC3339Nano)
+			event.timestamp = version.timestamp
+			events = append(events, event)
+		}
+
+		remarks := addedRemarks(versions, i)
+		if i == 0 {
+			add(EPCISEvent{Type: "ObjectEvent", EpcList: []string{cowEPC}, Action: "ADD", BizStep: "commissioning", Disposition: "active", BizLocation: location,
+				Ilmd: epcisFields(map[string]string{"fabcow:birthDate": cow.Birth_date, "fabcow:sex": cow.Sex, "fabcow:fatherId": cow.Father_id, "fabcow:motherId": cow.Mother_id, "fabcow:origin": cow.Origin})}, cow.Birth_date)
+		} else {
+			previous := versions[i-1].cow
+			if cow.Owner.Biz_no != previous.Owner.Biz_no {
+				add(EPCISEvent{Type: "ObjectEvent", EpcList: []string{cowEPC}, Action: "OBSERVE", BizStep: "receiving", Disposition: "active", BizLocation: location,
+					SourceList:      []EPCISSource{{Type: "owning_party", Source: epcisParty(previous.Owner.Biz_no)}},
+					DestinationList: []EPCISDestination{{Type: "owning_party", Destination: epcisParty(cow.Owner.Biz_no)}}}, "")
+			}
+		}
+
+		for _, event := range remarkEvents(remarks, "addFAMDVaccine") {
+			add(EPCISEvent{Type: "ObjectEvent", EpcList: []string{cowEPC}, Action: "OBSERVE", BizStep: "fabcow:vaccinating", Disposition: "active", BizLocation: epcisFarm(event["farm_id"], location),
+				Details: epcisFields(map[string]string{"type": "FMD", "item": event["item"]})}, event["vaccination_date"])
+		}
+		for _, event := range remarkEvents(remarks, "addBTVaccine") {
+			add(EPCISEvent{Type: "ObjectEvent", EpcList: []string{cowEPC}, Action: "OBSERVE", BizStep: "inspecting", Disposition: "active", BizLocation: epcisFarm(event["farm_id"], location),
+				Details: epcisFields(map[string]string{"type": "TB/BR", "kind": event["kind"], "method": event["inspection_method"], "result": event["inspection_result"]})}, event["inspection_date"])
+		}
+		for _, event := range remarkEvents(remarks, "addInfoDead") {
+			add(EPCISEvent{Type: "ObjectEvent", EpcList: []string{cowEPC}, Action: "DELETE", BizStep: "destroying", Disposition: "destroyed", BizLocation: epcisFarm(event["farm_id"], location),
+				Details: epcisFields(map[string]string{"reason": event["det_reason"], "method": event["det_method"]})}, event["det_date"])
+		}
+		for _, event := range remarkEvents(remarks, "addInfoInspect") {
+			add(EPCISEvent{Type: "TransformationEvent", InputEPCList: []string{cowEPC}, OutputEPCList: []string{carcassEPC}, TransformationID: "urn:fabcow:slaughter:" + cow.Id_no, BizStep: "slaughtering", Disposition: "active", BizLocation: location,
+				Ilmd: epcisFields(map[string]string{"fabcow:weight": event["weight"], "fabcow:sealNo": event["seal_no"], "fabcow:haccp": event["haccp_yn"], "fabcow:inspectionDate": event["inspection_date"]})}, event["slaughter_date"])
+		}
+		for _, event := range remarkEvents(remarks, "addInfoGradeResult") {
+			add(EPCISEvent{Type: "ObjectEvent", EpcList: []string{carcassEPC}, Action: "OBSERVE", BizStep: "inspecting", Disposition: "active", BizLocation: location,
+				Details: epcisFields(map[string]string{"type": "grade", "qualityGrade": event["meat_quality_grade"], "yieldGrade": event["meat_weight_grade"], "weight": event["weight"]})}, event["grade_date"])
+		}
+		for _, function := range []string{"registerInProcessesBundleNum", "registerInSalesBundleNum"} {
+			for _, event := range remarkEvents(remarks, function) {
+				if barcode != "" && event["barcode_id"] != barcode {
+					// still numbered, the ids stay those of the cow document
+					n++
+					continue
+				}
+				packing := EPCISEvent{Type: "TransformationEvent", InputEPCList: []string{carcassEPC}, OutputEPCList: []string{"urn:fabcow:bundle:" + event["barcode_id"]}, BizStep: "packing", Disposition: "active", BizLocation: location,
+					Ilmd: epcisFields(map[string]string{"fabcow:part": event["part"], "fabcow:weight": event["weight"]})}
+				if event["purchase_biz_no"] != "" {
+					packing.DestinationList = []EPCISDestination{{Type: "owning_party", Destination: epcisParty(event["purchase_biz_no"])}}
+				}
+				add(packing, event["package_date"])
+			}
+		}
+	}
+	return events
+}
+
+func epcisCow(id string) string {
+	return "urn:fabcow:cow:" + id
+}
+
+func epcisParty(bizNo string) string {
+	return "urn:fabcow:party:" + bizNo
+}
+
+func epcisOwnerLocation(owner Owner) *EPCISLocation {
+	if owner.Biz_no == "" {
+		return nil
+	}
+	return &EPCISLocation{ID: "urn:fabcow:location:" + owner.Biz_no}
+}
+
+// epcisFarm is the location of the farm a record names, the owner's if it names
+// none. Farms are registered with their farm id as Biz_no.
+func epcisFarm(farmID string, owner *EPCISLocation) *EPCISLocation {
+	if farmID == "" {
+		return owner
+	}
+	return &EPCISLocation{ID: "urn:fabcow:location:" + farmID}
+}
+
+// epcisTime is midnight KST of a business date, the record time for no or a bad date
+func epcisTime(date string, recordTime time.Time) string {
+	digits := dateDigits(date)
+	for _, layout := range []string{"20060102", "060102"} {
+		if len(digits) != len(layout) {
+			continue
+		}
+		if day, err := time.ParseInLocation(layout, digits, koreaZone); err == nil {
+			return day.Format(time.RFC3339)
+		}
+	}
+	return recordTime.In(koreaZone).Format(time.RFC3339Nano)
+}
+
+// epcisFields drops the empty fields
+func epcisFields(fields map[string]string) map[string]string {
+	for name, value := range fields {
+		if value == "" {
+			delete(fields, name)
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
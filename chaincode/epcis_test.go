@@ -0,0 +1,77 @@
+package chaincode_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/lotty02cho/fabcow-test/chaincode"
+)
+
+func TestEPCISExport(t *testing.T) {
+	c := newContract(t)
+	c.registerFarm("", "FARM0")
+	c.registerFarm("", "FARM1")
+	c.registerCow("", "180501-1", "M", "FARM0")
+	c.submit("changeCowOwner", "180501-1", "FARM0", "FARM1")
+	if err := c.submitJSON("addInfoInspect", chaincode.InspectPayload{Cow: "180501-1", Livestock: "C", Id_no: "180501-1", Weight: "420", Slaughter_nm: "Iksan", Seal_no: "S-1", Slaughter_date: "20191130", Farm_id: "FARM1", Farm_addr: "Iksan", Haccp_yn: "Y", Inspection_date: "20191130", Inspection_part: "all", Inspection_user_nm: "Lee", Veterinarian_no: "VET-9"}).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.submitJSON("addInfoGradeResultJSON", chaincode.GradeResultPayload{Cow: "180501-1", Grade_date: "20191201", Subscriber_nm: "Seo", Subscriber_birth: "1985.10.27", Id_no: "180501-1", Weight: "420", Meat_quality_grade: "1++", Meat_weight_grade: "A"}).Err(); err != nil {
+		t.Fatal(err)
+	}
+	c.submit("registerInProcessesBundleNum", "", "180501-1", "8801234567890", "20191205", "sirloin", "10", "Daejeon Butcher", "305-81-00000")
+	c.submit("addRemark", "180501-1", "note", "after packing")
+
+	document := func(function string, args ...string) chaincode.EPCISDocument {
+		t.Helper()
+		result := c.ledger.Evaluate(c.farmer, function, args...)
+		if err := result.Err(); err != nil {
+			t.Fatal(err)
+		}
+		text := string(result.Payload)
+		if strings.Contains(text, "Seo") || strings.Contains(text, "1985.10.27") || strings.Contains(text, "530118") {
+			t.Errorf("%s carries personal data: %s", function, text)
+		}
+		document := chaincode.EPCISDocument{}
+		if err := json.Unmarshal([]byte(text), &document); err != nil {
+			t.Fatalf("%s returned %q: %v", function, text, err)
+		}
+		return document
+	}
+	steps := func(document chaincode.EPCISDocument) string {
+		steps := []string{}
+		for _, event := range document.EpcisBody.EventList {
+			steps = append(steps, event.BizStep)
+		}
+		return strings.Join(steps, ",")
+	}
+
+	cow := document("getEPCISDocument", "180501-1")
+	if cow.Type != "EPCISDocument" || cow.SchemaVersion != "2.0" {
+		t.Errorf("document of type %q, schema version %q", cow.Type, cow.SchemaVersion)
+	}
+	if got, want := steps(cow), "commissioning,receiving,slaughtering,inspecting,packing"; got != want {
+		t.Errorf("cow events %s, want %s", got, want)
+	}
+	// the events of a business date are at midnight in Korea
+	if commissioning := cow.EpcisBody.EventList[0]; commissioning.EventTime != "2018-05-01T00:00:00+09:00" || commissioning.EventTimeZoneOffset != "+09:00" {
+		t.Errorf("commissioning at %s %s, want the birth date", commissioning.EventTime, commissioning.EventTimeZoneOffset)
+	}
+	again := document("getEPCISDocument", "180501-1")
+	for i, event := range again.EpcisBody.EventList {
+		if event.EventID != cow.EpcisBody.EventList[i].EventID {
+			t.Errorf("event %d has the ID %s, then %s", i, cow.EpcisBody.EventList[i].EventID, event.EventID)
+		}
+	}
+
+	if got := steps(document("getEPCISDocument", "8801234567890")); got != "commissioning,receiving,slaughtering,inspecting,packing" {
+		t.Errorf("bundle events %s, want the cow up to the packing of the bundle", got)
+	}
+	if got := steps(document("getEPCISEvents", "20191101", "20191130")); got != "slaughtering" {
+		t.Errorf("events of November 2019 %s, want the slaughter", got)
+	}
+	if code := errorCode(t, c.ledger.Evaluate(c.farmer, "getEPCISEvents", "20191201", "20191101")); code != "invalid_argument" {
+		t.Errorf("reversed range failed with %q, want invalid_argument", code)
+	}
+}
@@ -0,0 +1,120 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Failed transactions answer with a JSON error body as the response message
+//
+//	{"code":"not_found","message":"Cow does not exist: 180501-1"}
+//
+// and the response status of the code, so clients can branch on the code.
+// Transactions return the errors built below, the Chaincode wrapper turns any
+// other error into an internal one.
+const (
+	codeInvalidArgument = "invalid_argument"
+	codeNotFound        = "not_found"
+	codeConflict        = "conflict"
+	codeForbidden       = "forbidden"
+	codeInvalidState    = "invalid_state"
+	codeInternal        = "internal"
+)
+
+// codeStatus is the response status of every error code
+var codeStatus = map[string]int32{
+	codeInvalidArgument: 400,
+	codeForbidden:       403,
+	codeNotFound:        404,
+	codeConflict:        409,
+	codeInvalidState:    412,
+	codeInternal:        500,
+}
+
+// contractArgumentErrors start the messages of the contract API about the arguments of a call
+var contractArgumentErrors = []string{"Incorrect number of params", "Error managing parameter", "Contract not found"}
+
+// Error is the error body of a failed transaction
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Details carries more about some errors, the failed items of a batch
+	Details interface{} `json:"details,omitempty"`
+}
+
+func (e *Error) Error() string {
+	bodyAsBytes, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Sprintf(`{"code":%q,"message":%q}`, e.Code, e.Message)
+	}
+	return string(bodyAsBytes)
+}
+
+func newError(code string, format string, args ...interface{}) error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// invalidArgument is a call with a missing, malformed or out of range argument
+func invalidArgument(format string, args ...interface{}) error {
+	return newError(codeInvalidArgument, format, args...)
+}
+
+// notFound is a reference to a record that does not exist
+func notFound(format string, args ...interface{}) error {
+	return newError(codeNotFound, format, args...)
+}
+
+// conflict is a write that clashes with what is stored, a duplicate or a reference
+func conflict(format string, args ...interface{}) error {
+	return newError(codeConflict, format, args...)
+}
+
+// forbidden is a call the submitter is not allowed to make
+func forbidden(format string, args ...interface{}) error {
+	return newError(codeForbidden, format, args...)
+}
+
+// invalidState is a call the record does not accept in its current state (a dead cow)
+func invalidState(format string, args ...interface{}) error {
+	return newError(codeInvalidState, format, args...)
+}
+
+// internalError is a failure of the ledger or of a stored document
+func internalError(format string, args ...interface{}) error {
+	return newError(codeInternal, format, args...)
+}
+
+// withContext puts context in front of the message of err and keeps its code
+func withContext(context string, err error) error {
+	var e *Error
+	if errors.As(err, &e) {
+		return &Error{Code: e.Code, Message: context + ": " + e.Message, Details: e.Details}
+	}
+	return internalError("%s: %s", context, err.Error())
+}
+
+// errorOf returns err as an Error, errors without a code are internal
+func errorOf(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return &Error{Code: codeInternal, Message: err.Error()}
+}
+
+// responseError reads the error body back from the message of a failed response.
+// The contract API reports bad arguments itself, those messages are invalid arguments.
+func responseError(message string) *Error {
+	e := Error{}
+	if err := json.Unmarshal([]byte(message), &e); err == nil && codeStatus[e.Code] != 0 {
+		return &e
+	}
+	for _, prefix := range contractArgumentErrors {
+		if strings.HasPrefix(message, prefix) {
+			return &Error{Code: codeInvalidArgument, Message: message}
+		}
+	}
+	return &Error{Code: codeInternal, Message: message}
+}
@@ -0,0 +1,47 @@
+package chaincode_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lotty02cho/fabcow-test/chaincode"
+)
+
+func TestErrorCodes(t *testing.T) {
+	c := newContract(t)
+	c.registerFarm("", "FARM0")
+	c.registerCow("", "180501-1", "M", "FARM0")
+	c.registerCow("", "180501-2", "M", "FARM0")
+	c.submit("addInfoDead", "180501-2", "FARM0", "180501-2", "20190601", "pneumonia", "incineration")
+
+	tests := []struct {
+		name     string
+		function string
+		args     []string
+		code     string
+		status   int32
+	}{
+		{"malformed argument", "addCalving", []string{"180501-1", "2019-13-01", "191210-1", "F", "", ""}, "invalid_argument", 400},
+		{"missing arguments", "addRemark", []string{"180501-1"}, "invalid_argument", 400},
+		{"argument of the wrong type", "compareSires", []string{"901027"}, "invalid_argument", 400},
+		{"administrators only", "setColdChainRule", []string{"sirloin", "-2", "4", "", ""}, "forbidden", 403},
+		{"unknown cow", "readCow", []string{"180501-9"}, "not_found", 404},
+		{"duplicate", "registerCow", []string{"", "180501-1", "180501", "M", "", "", "Iksan", "FARM0"}, "conflict", 409},
+		{"dead cow", "addRemark", []string{"180501-2", "note", "seen"}, "invalid_state", 412},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := c.ledger.Submit(c.farmer, test.function, test.args...)
+			if result.Status != test.status {
+				t.Errorf("%s answered status %d, want %d (%s)", test.function, result.Status, test.status, result.Message)
+			}
+			e := chaincode.Error{}
+			if err := json.Unmarshal([]byte(result.Message), &e); err != nil {
+				t.Fatalf("error body %q: %v", result.Message, err)
+			}
+			if e.Code != test.code || e.Message == "" {
+				t.Errorf("%s failed with %+v, want code %s and a message", test.function, e, test.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,345 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Grade results are indexed under ("grade", [YYYYMM, farm, YYYYMMDD, Id_no]) so
+// statistics read only the months asked for (and only one farm's entries of a
+// month when a farm is given) instead of scanning every cow. The farm of a cow
+// is the farm_id of its slaughter inspection, or its owner when that is a farm.
+// The farm an entry was written under is kept on the grade result
+// (addInfoGradeResult.index_farm), so the entry is found again once the cow has
+// moved on to a slaughterhouse.
+const (
+	gradeIndexObjectType = "grade"
+	// maxStatsMonths bounds the work of one statistics query
+	maxStatsMonths = 120
+)
+
+// qualityGrades and yieldGrades are listed in this order, other grades follow sorted
+var qualityGrades = []string{"1++", "1+", "1", "2", "3"}
+var yieldGrades = []string{"A", "B", "C"}
+
+// gradeIndexEntry is the value of a grade index key
+type gradeIndexEntry struct {
+	Quality string `json:"quality"`
+	Yield   string `json:"yield"`
+	Weight  string `json:"weight"`
+}
+
+type GradeCount struct {
+	Grade   string  `json:"grade"`
+	Count   int     `json:"count"`
+	Percent float64 `json:"percent"`
+}
+
+type GradeStats struct {
+	// Period is the month (YYYYMM) of a monthly breakdown, empty for the total
+	Period         string       `json:"period"`
+	Count          int          `json:"count"`
+	Quality_grades []GradeCount `json:"quality_grades"`
+	Yield_grades   []GradeCount `json:"yield_grades"`
+	// Average_weight is the average carcass weight of the Weighed results with a numeric weight
+	Average_weight float64 `json:"average_weight"`
+	Weighed        int     `json:"weighed"`
+}
+
+type GradeReport struct {
+	Farm   string       `json:"farm"`
+	From   string       `json:"from"`
+	To     string       `json:"to"`
+	Total  GradeStats   `json:"total"`
+	Months []GradeStats `json:"months"`
+}
+
+// GetGradeStats returns the grade distribution and average carcass weight of a farm over a period
+func (s *SmartContract) GetGradeStats(ctx contractapi.TransactionContextInterface, farm string, from string, to string) (*GradeReport, error) {
+	//'{"Args":["getGradeStats", "FARM0", "20190101", "20191231"]}'
+	//'{"Args":["getGradeStats", "", "2019-01-01", "2019-12-31"]}'
+	//farm	-- Biz_no of the farm, empty for all farms
+	//from	-- first grade date, YYYYMMDD
+	//to	-- last grade date, YYYYMMDD
+
+	fromDate, toDate, months, err := statsPeriod(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := GradeReport{Farm: farm, From: fromDate, To: toDate, Months: []GradeStats{}}
+	total := newGradeAggregate("")
+	for _, month := range months {
+		monthly := newGradeAggregate(month)
+		err := scanMonthIndex(ctx.GetStub(), gradeIndexObjectType, month, farm, fromDate, toDate, func(value []byte) error {
+			entry := gradeIndexEntry{}
+			if err := json.Unmarshal(value, &entry); err != nil {
+				return err
+			}
+			monthly.add(entry)
+			total.add(entry)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if monthly.count > 0 {
+			report.Months = append(report.Months, monthly.stats())
+		}
+	}
+	report.Total = total.stats()
+
+	return &report, nil
+}
+
+// statsPeriod reads the from and to dates of a statistics query and lists the months between them
+func statsPeriod(from string, to string) (string, string, []string, error) {
+	fromDate, err := normalizeDate(from)
+	if err != nil {
+		return "", "", nil, err
+	}
+	toDate, err := normalizeDate(to)
+	if err != nil {
+		return "", "", nil, err
+	}
+	if fromDate > toDate {
+		return "", "", nil, invalidArgument("from must not be after to")
+	}
+	months := monthsBetween(fromDate[:6], toDate[:6])
+	if len(months) > maxStatsMonths {
+		return "", "", nil, invalidArgument("Period too long: at most %d months are allowed", maxStatsMonths)
+	}
+	return fromDate, toDate, months, nil
+}
+
+// scanMonthIndex calls entry with the value of every entry of a [YYYYMM, farm, YYYYMMDD, Id_no]
+// index in month, of farm unless it is empty, dated from..to
+func scanMonthIndex(APIstub shim.ChaincodeStubInterface, objectType string, month string, farm string, fromDate string, toDate string, entry func(value []byte) error) error {
+	attributes := []string{month}
+	if farm != "" {
+		attributes = append(attributes, farm)
+	}
+	resultsIterator, err := APIstub.GetStateByPartialCompositeKey(objectType, attributes)
+	if err != nil {
+		return err
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return err
+		}
+		_, keyParts, err := APIstub.SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return err
+		}
+		if date := keyParts[2]; date < fromDate || date > toDate {
+			continue
+		}
+		if err := entry(queryResponse.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putGradeIndex indexes the grade result event of cow, replacing the entry of its previous grade result
+func putGradeIndex(APIstub shim.ChaincodeStubInterface, cow Cow, previous map[string]string, event map[string]string) error {
+	if previous != nil {
+		if key, err := gradeIndexKey(APIstub, cow, previous); err == nil {
+			if err := APIstub.DelState(key); err != nil {
+				return err
+			}
+		}
+	}
+
+	key, err := gradeIndexKey(APIstub, cow, event)
+	if err != nil {
+		return err
+	}
+	entryAsBytes, err := json.Marshal(gradeIndexEntry{Quality: event["meat_quality_grade"], Yield: event["meat_weight_grade"], Weight: event["weight"]})
+	if err != nil {
+		return err
+	}
+	return APIstub.PutState(key, entryAsBytes)
+}
+
+// delGradeIndex drops the index entry of the last grade result of cow
+func delGradeIndex(APIstub shim.ChaincodeStubInterface, cow Cow) error {
+	event := lastRemarkEvent(cow.Remarks, "addInfoGradeResult")
+	if event == nil {
+		return nil
+	}
+	key, err := gradeIndexKey(APIstub, cow, event)
+	if err != nil {
+		// results with an unreadable date were never indexed
+		return nil
+	}
+	return APIstub.DelState(key)
+}
+
+func gradeIndexKey(APIstub shim.ChaincodeStubInterface, cow Cow, event map[string]string) (string, error) {
+	date, err := normalizeDate(event["grade_date"])
+	if err != nil {
+		return "", withContext("grade_date", err)
+	}
+	// results graded before the farm was kept on them are under the farm of the cow
+	farm, ok := event["index_farm"]
+	if !ok {
+		farm = cowFarm(cow)
+	}
+	return APIstub.CreateCompositeKey(gradeIndexObjectType, []string{date[:6], farm, date, cow.Id_no})
+}
+
+// cowFarm is the Biz_no of the farm a cow was raised at, empty when unknown
+func cowFarm(cow Cow) string {
+	if event := lastRemarkEvent(cow.Remarks, "addInfoInspect"); event != nil && event["farm_id"] != "" {
+		return event["farm_id"]
+	}
+	if cow.Owner.Owner_type == OwnerTypeFarm {
+		return cow.Owner.Biz_no
+	}
+	return ""
+}
+
+// indexGradeResults is the migration of cows graded before grade results were indexed
+func indexGradeResults(APIstub shim.ChaincodeStubInterface, key string, value []byte, run *migrationRun) error {
+	cow := Cow{}
+	if err := decodeCow(value, &cow); err != nil {
+		txLogger(APIstub).warning("migration skipped a record", "key", key, "error", err)
+		run.status.Failed = append(run.status.Failed, key)
+		return nil
+	}
+	event := lastRemarkEvent(cow.Remarks, "addInfoGradeResult")
+	if event == nil {
+		return nil
+	}
+	if err := putGradeIndex(APIstub, cow, nil, event); err != nil {
+		// an unreadable grade date can not be indexed
+		txLogger(APIstub).warning("migration skipped a record", "key", key, "error", err)
+		run.status.Failed = append(run.status.Failed, key)
+		return nil
+	}
+	run.status.Upgraded++
+	return nil
+}
+
+// koreaZone is the time zone of the business dates in the records
+var koreaZone = time.FixedZone("KST", 9*60*60)
+
+// normalizeDate accepts YYYYMMDD, YYYY-MM-DD and YYYY.MM.DD of a calendar day
+// and returns YYYYMMDD
+func normalizeDate(date string) (string, error) {
+	digits := dateDigits(date)
+	if len(digits) != 8 || strings.Trim(digits, "0123456789") != "" {
+		return "", invalidArgument("Incorrect date %q, expecting YYYYMMDD", date)
+	}
+	// the dates are index keys, 20190231 would sort among the real days
+	if _, err := time.Parse("20060102", digits); err != nil {
+		return "", invalidArgument("Incorrect date %q, no such day", date)
+	}
+	return digits, nil
+}
+
+// dateDigits drops the separators of a date
+func dateDigits(date string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' || r == '/' {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(date))
+}
+
+// monthsBetween lists the months from..to (YYYYMM), both included
+func monthsBetween(from string, to string) []string {
+	year, _ := strconv.Atoi(from[:4])
+	month, _ := strconv.Atoi(from[4:])
+	months := []string{}
+	for {
+		current := fmt.Sprintf("%04d%02d", year, month)
+		if current > to || len(months) > maxStatsMonths {
+			return months
+		}
+		months = append(months, current)
+		if month++; month > 12 {
+			month = 1
+			year++
+		}
+	}
+}
+
+// gradeAggregate counts grade index entries
+type gradeAggregate struct {
+	period      string
+	count       int
+	quality     map[string]int
+	yield       map[string]int
+	weightTotal float64
+	weighed     int
+}
+
+func newGradeAggregate(period string) *gradeAggregate {
+	return &gradeAggregate{period: period, quality: map[string]int{}, yield: map[string]int{}}
+}
+
+func (a *gradeAggregate) add(entry gradeIndexEntry) {
+	a.count++
+	a.quality[entry.Quality]++
+	a.yield[entry.Yield]++
+	if weight, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(entry.Weight), "kg"), 64); err == nil {
+		a.weightTotal += weight
+		a.weighed++
+	}
+}
+
+func (a *gradeAggregate) stats() GradeStats {
+	stats := GradeStats{Period: a.period, Count: a.count, Weighed: a.weighed}
+	stats.Quality_grades = gradeCounts(a.quality, qualityGrades, a.count)
+	stats.Yield_grades = gradeCounts(a.yield, yieldGrades, a.count)
+	if a.weighed > 0 {
+		stats.Average_weight = math.Round(a.weightTotal/float64(a.weighed)*100) / 100
+	}
+	return stats
+}
+
+// gradeCounts lists the known grades in order, then any other grade found
+func gradeCounts(counts map[string]int, known []string, total int) []GradeCount {
+	grades := append([]string{}, known...)
+	others := []string{}
+	for grade := range counts {
+		isKnown := false
+		for _, k := range known {
+			isKnown = isKnown || grade == k
+		}
+		if !isKnown {
+			others = append(others, grade)
+		}
+	}
+	sort.Strings(others)
+	grades = append(grades, others...)
+
+	results := []GradeCount{}
+	for _, grade := range grades {
+		share := 0.0
+		if total > 0 {
+			share = percent(counts[grade], total)
+		}
+		results = append(results, GradeCount{Grade: grade, Count: counts[grade], Percent: share})
+	}
+	return results
+}
+
+// percent is count as a percentage of total, rounded to two decimals
+func percent(count int, total int) float64 {
+	return math.Round(float64(count)*10000/float64(total)) / 100
+}
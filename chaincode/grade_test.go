@@ -0,0 +1,70 @@
+package chaincode_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lotty02cho/fabcow-test/chaincode"
+)
+
+func TestGradeStats(t *testing.T) {
+	c := newContract(t)
+	c.registerFarm("", "FARM0")
+	for i, grade := range []struct{ idNo, date, quality, yield, weight string }{
+		{"180501-1", "20190115", "1++", "A", "420"},
+		{"180501-2", "2019-01-20", "1+", "B", "380"},
+		{"180501-3", "2019.02.03", "1++", "A", "unknown"},
+	} {
+		c.registerCow("", grade.idNo, "M", "FARM0")
+		result := c.submitJSON("addInfoGradeResultJSON", chaincode.GradeResultPayload{Cow: grade.idNo, Grade_date: grade.date, Id_no: grade.idNo, Weight: grade.weight, Meat_quality_grade: grade.quality, Meat_weight_grade: grade.yield})
+		if err := result.Err(); err != nil {
+			t.Fatalf("grade result %d: %v", i, err)
+		}
+	}
+
+	result := c.ledger.Evaluate(c.farmer, "getGradeStats", "FARM0", "20190101", "2019-02-28")
+	if err := result.Err(); err != nil {
+		t.Fatal(err)
+	}
+	report := chaincode.GradeReport{}
+	if err := json.Unmarshal(result.Payload, &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Total.Count != 3 || report.Total.Weighed != 2 || report.Total.Average_weight != 400 {
+		t.Errorf("total %+v, want 3 results, 2 weighed, 400 on average", report.Total)
+	}
+	if len(report.Months) != 2 || report.Months[0].Count != 2 || report.Months[1].Count != 1 {
+		t.Errorf("months %+v, want 2 results in 201901 and 1 in 201902", report.Months)
+	}
+}
+
+func TestGradeDates(t *testing.T) {
+	tests := []struct {
+		name string
+		date string
+		code string
+	}{
+		{"calendar day", "20190228", ""},
+		{"leap day", "2020-02-29", ""},
+		{"no such day", "20190231", "invalid_argument"},
+		{"leap day of a common year", "20190229", "invalid_argument"},
+		{"no such month", "20191399", "invalid_argument"},
+		{"short date", "2019021", "invalid_argument"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := newContract(t)
+			c.registerFarm("", "FARM0")
+			c.registerCow("", "180501-1", "M", "FARM0")
+
+			result := c.submitJSON("addInfoGradeResultJSON", chaincode.GradeResultPayload{Cow: "180501-1", Grade_date: test.date, Id_no: "180501-1", Weight: "420", Meat_quality_grade: "1++", Meat_weight_grade: "A"})
+			if code := errorCode(t, result); code != test.code {
+				t.Errorf("addInfoGradeResult on %s failed with %q, want %q (%s)", test.date, code, test.code, result.Message)
+			}
+			result = c.ledger.Evaluate(c.farmer, "getGradeStats", "", test.date, test.date)
+			if code := errorCode(t, result); code != test.code {
+				t.Errorf("getGradeStats of %s failed with %q, want %q (%s)", test.date, code, test.code, result.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,448 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Weighings of live cows are addWeighing remarks on the cow, like the
+// vaccinations, and are indexed under ("weighing", [YYYYMM, Biz_no, YYYYMMDD,
+// Id_no, n]) by the owner of the cow when it was weighed, so the growth report
+// of a farm reads only its own weighings. n numbers the weighings of a cow.
+//
+// Growth references are the expected weight by age of a breed, set by
+// administrators under ("growthref", [breed, sex]) with sex * for both sexes.
+// Growth curves are compared to the reference interpolated at the age of each
+// weighing.
+const (
+	weighingIndexObjectType   = "weighing"
+	growthReferenceObjectType = "growthref"
+	// anySex is the sex of a growth reference for both sexes
+	anySex = "*"
+)
+
+type GrowthReferencePoint struct {
+	Age_days int     `json:"age_days"`
+	Weight   float64 `json:"weight"`
+}
+
+type GrowthReference struct {
+	Breed string `json:"Breed"`
+	Sex   string `json:"Sex"`
+	// Points are in order of age
+	Points []GrowthReferencePoint `json:"Points"`
+	Stamp  *Stamp                 `json:"Stamp,omitempty" metadata:",optional"`
+}
+
+type GrowthPoint struct {
+	Weigh_date string  `json:"weigh_date"`
+	Age_days   int     `json:"age_days"`
+	Weight     float64 `json:"weight"`
+	// Daily_gain is the gain per day since the previous weighing, 0 for the first
+	Daily_gain float64 `json:"daily_gain"`
+	// Reference_weight is the weight of the reference at Age_days, 0 outside of the ages of the reference
+	Reference_weight float64 `json:"reference_weight,omitempty" metadata:",optional"`
+	// Deviation is the percentage Weight is above (or below) Reference_weight
+	Deviation float64 `json:"deviation,omitempty" metadata:",optional"`
+}
+
+type GrowthCurve struct {
+	Id_no      string `json:"id_no"`
+	Birth_date string `json:"birth_date"`
+	Sex        string `json:"sex"`
+	// Average_daily_gain is the gain per day from the first to the last weighing
+	Average_daily_gain float64          `json:"average_daily_gain"`
+	Reference          *GrowthReference `json:"reference,omitempty" metadata:",optional"`
+	Points             []GrowthPoint    `json:"points"`
+}
+
+// growthIndexEntry is the value of a weighing index key
+type growthIndexEntry struct {
+	Id_no    string  `json:"id_no"`
+	Sex      string  `json:"sex"`
+	Date     string  `json:"date"`
+	Age_days int     `json:"age_days"`
+	Weight   float64 `json:"weight"`
+}
+
+// CowGrowth is the growth of a cow over the period of a growth report
+type CowGrowth struct {
+	Id_no        string  `json:"id_no"`
+	Sex          string  `json:"sex"`
+	Weighings    int     `json:"weighings"`
+	First_date   string  `json:"first_date"`
+	First_weight float64 `json:"first_weight"`
+	Last_date    string  `json:"last_date"`
+	Last_weight  float64 `json:"last_weight"`
+	Last_age     int     `json:"last_age_days"`
+	// Daily_gain is 0 for a cow weighed once in the period
+	Daily_gain float64 `json:"daily_gain"`
+}
+
+type GrowthStats struct {
+	// Sex is the sex of a breakdown, empty for the total
+	Sex  string `json:"sex"`
+	Cows int    `json:"cows"`
+	// Average_daily_gain is the average of the cows weighed at least twice, Gaining of them
+	Average_daily_gain float64 `json:"average_daily_gain"`
+	Gaining            int     `json:"gaining"`
+	// Average_weight is the average of the last weighings of the cows
+	Average_weight float64 `json:"average_weight"`
+}
+
+type GrowthReport struct {
+	Farm      string        `json:"farm"`
+	From      string        `json:"from"`
+	To        string        `json:"to"`
+	Weighings int           `json:"weighings"`
+	Total     GrowthStats   `json:"total"`
+	Sexes     []GrowthStats `json:"sexes"`
+	Cows      []CowGrowth   `json:"cows"`
+}
+
+func (reference *GrowthReference) stamp(stamp Stamp) {
+	reference.Stamp = &stamp
+}
+
+// AddWeighing notes a weighing of a live cow
+func (s *SmartContract) AddWeighing(ctx contractapi.TransactionContextInterface, cowRef string, weighDate string, weight string, method string, weigherNm string) error {
+	//'{"Args":["addWeighing","180501-1", "20190301", "212.5", "scale", "Hong Gildong"]}'
+	//weighDate	-- YYYYMMDD
+	//weight	-- live weight in kg
+	//method	-- scale or tape (may be empty)
+	//weigherNm	-- who weighed the cow (may be empty)
+
+	return s.AddWeighingJSON(ctx, WeighingPayload{Cow: cowRef, Weigh_date: weighDate, Weight: weight, Method: method, Weigher_nm: weigherNm})
+}
+
+// AddWeighingJSON is addWeighing with named fields
+func (s *SmartContract) AddWeighingJSON(ctx contractapi.TransactionContextInterface, payload WeighingPayload) error {
+	variables := []string{"addWeighing.weigh_date", "addWeighing.weight", "addWeighing.method", "addWeighing.weigher_nm"}
+
+	date, err := normalizeDate(payload.Weigh_date)
+	if err != nil {
+		return withContext("weigh_date", err)
+	}
+	weight, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(payload.Weight), "kg"), 64)
+	if err != nil || weight <= 0 {
+		return invalidArgument("Incorrect weight %q, expecting kg", payload.Weight)
+	}
+
+	APIstub := ctx.GetStub()
+
+	key, cow, err := getLiveCow(APIstub, payload.Cow)
+	if err != nil {
+		return err
+	}
+
+	cow.setCowRemarks(variables, []string{date, strconv.FormatFloat(weight, 'f', -1, 64), payload.Method, payload.Weigher_nm})
+
+	// the growth report reads the index, not the cows
+	n := len(remarkEvents(cow.Remarks, "addWeighing")) - 1
+	indexKey, err := APIstub.CreateCompositeKey(weighingIndexObjectType, []string{date[:6], cow.Owner.Biz_no, date, cow.Id_no, strconv.Itoa(n)})
+	if err != nil {
+		return err
+	}
+	entryAsBytes, err := json.Marshal(growthIndexEntry{Id_no: cow.Id_no, Sex: cow.Sex, Date: date, Age_days: ageInDays(cow.Birth_date, date), Weight: weight})
+	if err != nil {
+		return err
+	}
+	if err := APIstub.PutState(indexKey, entryAsBytes); err != nil {
+		return err
+	}
+
+	return putRecord(APIstub, key, &cow)
+}
+
+// AddWeighingBatch records the weighing of a herd in one transaction
+func (s *SmartContract) AddWeighingBatch(ctx contractapi.TransactionContextInterface, payloads []WeighingPayload) error {
+	//'{"Args":["addWeighingBatch", "[{\"cow\":\"180501-1\",\"weigh_date\":\"20190301\",\"weight\":\"212.5\"},...]"]}'
+
+	return runBatch(ctx, len(payloads), func(batchCtx contractapi.TransactionContextInterface, i int) error {
+		return s.AddWeighingJSON(batchCtx, payloads[i])
+	})
+}
+
+// SetGrowthReference sets the expected weight by age of a breed (administrators only, see adminTransactions)
+func (s *SmartContract) SetGrowthReference(ctx contractapi.TransactionContextInterface, breed string, sex string, points string) error {
+	//'{"Args":["setGrowthReference", "hanwoo", "M", "0:28,180:180,365:330,730:600"]}'
+	//breed		-- breed the reference is for
+	//sex		-- M or F, * for both
+	//points	-- AGE_DAYS:WEIGHT pairs in kg, comma separated
+
+	payload := GrowthReferencePayload{Breed: breed, Sex: sex, Points: []GrowthReferencePoint{}}
+	for _, pair := range strings.Split(points, ",") {
+		fields := strings.Split(strings.TrimSpace(pair), ":")
+		if len(fields) != 2 {
+			return invalidArgument("Incorrect point %q, expecting AGE_DAYS:WEIGHT", pair)
+		}
+		age, err := strconv.Atoi(strings.TrimSpace(fields[0]))
+		if err != nil {
+			return invalidArgument("Incorrect age %q, expecting days", fields[0])
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+		if err != nil {
+			return invalidArgument("Incorrect weight %q, expecting kg", fields[1])
+		}
+		payload.Points = append(payload.Points, GrowthReferencePoint{Age_days: age, Weight: weight})
+	}
+	return s.SetGrowthReferenceJSON(ctx, payload)
+}
+
+// SetGrowthReferenceJSON is setGrowthReference with named fields (administrators only, see adminTransactions)
+func (s *SmartContract) SetGrowthReferenceJSON(ctx contractapi.TransactionContextInterface, payload GrowthReferencePayload) error {
+	breed, sex := growthReferenceKeys(payload.Breed, payload.Sex)
+	if breed == "" {
+		return invalidArgument("Incorrect value. breed must not be empty")
+	}
+	if len(payload.Points) < 2 {
+		return invalidArgument("A growth reference needs at least two points")
+	}
+	points := append([]GrowthReferencePoint{}, payload.Points...)
+	sort.Slice(points, func(i, j int) bool { return points[i].Age_days < points[j].Age_days })
+	for i, point := range points {
+		if point.Age_days < 0 || point.Weight <= 0 {
+			return invalidArgument("Incorrect point %d:%g, expecting an age of 0 days or more and a weight above 0", point.Age_days, point.Weight)
+		}
+		if i > 0 && point.Age_days == points[i-1].Age_days {
+			return invalidArgument("Age %d is given twice", point.Age_days)
+		}
+	}
+
+	APIstub := ctx.GetStub()
+	key, err := APIstub.CreateCompositeKey(growthReferenceObjectType, []string{breed, sex})
+	if err != nil {
+		return err
+	}
+	return putRecord(APIstub, key, &GrowthReference{Breed: breed, Sex: sex, Points: points})
+}
+
+// QueryGrowthReferences returns every growth reference
+func (s *SmartContract) QueryGrowthReferences(ctx contractapi.TransactionContextInterface) ([]GrowthReference, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(growthReferenceObjectType, []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	references := []GrowthReference{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		reference := GrowthReference{}
+		if err := json.Unmarshal(queryResponse.Value, &reference); err != nil {
+			return nil, err
+		}
+		references = append(references, reference)
+	}
+	return references, nil
+}
+
+// GetGrowthCurve returns the weighings of a cow with its daily gains, compared to the reference of breed
+func (s *SmartContract) GetGrowthCurve(ctx contractapi.TransactionContextInterface, cowRef string, breed string) (*GrowthCurve, error) {
+	//'{"Args":["getGrowthCurve", "180501-1", "hanwoo"]}'
+	//cowRef	-- cow (Id_no or legacy COW key)
+	//breed		-- breed to compare to, empty for none
+
+	APIstub := ctx.GetStub()
+
+	_, cow, err := getCow(APIstub, cowRef)
+	if err != nil {
+		return nil, err
+	}
+
+	curve := GrowthCurve{Id_no: cow.Id_no, Birth_date: cow.Birth_date, Sex: cow.Sex, Points: []GrowthPoint{}}
+	if strings.TrimSpace(breed) != "" {
+		if curve.Reference, err = getGrowthReference(APIstub, breed, cow.Sex); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, event := range remarkEvents(cow.Remarks, "addWeighing") {
+		weight, err := strconv.ParseFloat(event["weight"], 64)
+		if err != nil {
+			continue
+		}
+		point := GrowthPoint{Weigh_date: event["weigh_date"], Age_days: ageInDays(cow.Birth_date, event["weigh_date"]), Weight: weight}
+		if curve.Reference != nil {
+			if reference := referenceWeight(curve.Reference.Points, point.Age_days); reference > 0 {
+				point.Reference_weight = round(reference, 2)
+				point.Deviation = round((weight-reference)/reference*100, 2)
+			}
+		}
+		curve.Points = append(curve.Points, point)
+	}
+	// weighings may be recorded late, the curve is in order of date
+	sort.SliceStable(curve.Points, func(i, j int) bool { return curve.Points[i].Weigh_date < curve.Points[j].Weigh_date })
+	for i := 1; i < len(curve.Points); i++ {
+		curve.Points[i].Daily_gain = dailyGain(curve.Points[i-1].Weigh_date, curve.Points[i-1].Weight, curve.Points[i].Weigh_date, curve.Points[i].Weight)
+	}
+	if n := len(curve.Points); n > 1 {
+		curve.Average_daily_gain = dailyGain(curve.Points[0].Weigh_date, curve.Points[0].Weight, curve.Points[n-1].Weigh_date, curve.Points[n-1].Weight)
+	}
+	return &curve, nil
+}
+
+// GetGrowthReport returns the growth of the cows of a farm weighed over a period, by sex
+func (s *SmartContract) GetGrowthReport(ctx contractapi.TransactionContextInterface, farm string, from string, to string) (*GrowthReport, error) {
+	//'{"Args":["getGrowthReport", "FARM0", "20190101", "20191231"]}'
+	//farm	-- Biz_no of the farm, empty for all farms
+	//from	-- first weigh date, YYYYMMDD
+	//to	-- last weigh date, YYYYMMDD
+
+	fromDate, toDate, months, err := statsPeriod(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := GrowthReport{Farm: farm, From: fromDate, To: toDate, Sexes: []GrowthStats{}, Cows: []CowGrowth{}}
+	cows := map[string]*CowGrowth{}
+	for _, month := range months {
+		err := scanMonthIndex(ctx.GetStub(), weighingIndexObjectType, month, farm, fromDate, toDate, func(value []byte) error {
+			entry := growthIndexEntry{}
+			if err := json.Unmarshal(value, &entry); err != nil {
+				return err
+			}
+			report.Weighings++
+			growth, ok := cows[entry.Id_no]
+			if !ok {
+				growth = &CowGrowth{Id_no: entry.Id_no, Sex: entry.Sex, First_date: entry.Date, First_weight: entry.Weight, Last_date: entry.Date}
+				cows[entry.Id_no] = growth
+			}
+			growth.Weighings++
+			if entry.Date < growth.First_date {
+				growth.First_date, growth.First_weight = entry.Date, entry.Weight
+			}
+			if entry.Date >= growth.Last_date {
+				growth.Last_date, growth.Last_weight, growth.Last_age = entry.Date, entry.Weight, entry.Age_days
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	total := growthAggregate{}
+	sexes := map[string]*growthAggregate{}
+	for _, growth := range cows {
+		growth.Daily_gain = dailyGain(growth.First_date, growth.First_weight, growth.Last_date, growth.Last_weight)
+		report.Cows = append(report.Cows, *growth)
+		if sexes[growth.Sex] == nil {
+			sexes[growth.Sex] = &growthAggregate{}
+		}
+		total.add(*growth)
+		sexes[growth.Sex].add(*growth)
+	}
+	sort.Slice(report.Cows, func(i, j int) bool { return report.Cows[i].Id_no < report.Cows[j].Id_no })
+
+	report.Total = total.stats("")
+	for sex, aggregate := range sexes {
+		report.Sexes = append(report.Sexes, aggregate.stats(sex))
+	}
+	sort.Slice(report.Sexes, func(i, j int) bool { return report.Sexes[i].Sex < report.Sexes[j].Sex })
+	return &report, nil
+}
+
+// getGrowthReference reads the reference of breed for sex, or for both sexes, nil if there is none
+func getGrowthReference(APIstub shim.ChaincodeStubInterface, breed string, sex string) (*GrowthReference, error) {
+	breed, sex = growthReferenceKeys(breed, sex)
+	for _, referenceSex := range []string{sex, anySex} {
+		key, err := APIstub.CreateCompositeKey(growthReferenceObjectType, []string{breed, referenceSex})
+		if err != nil {
+			return nil, err
+		}
+		referenceAsBytes, err := APIstub.GetState(key)
+		if err != nil {
+			return nil, err
+		}
+		if referenceAsBytes != nil {
+			reference := GrowthReference{}
+			if err := json.Unmarshal(referenceAsBytes, &reference); err != nil {
+				return nil, err
+			}
+			return &reference, nil
+		}
+	}
+	return nil, nil
+}
+
+// growthReferenceKeys are breed and sex as a reference is stored under, breeds are matched without regard to case
+func growthReferenceKeys(breed string, sex string) (string, string) {
+	sex = strings.ToUpper(strings.TrimSpace(sex))
+	if sex == "" {
+		sex = anySex
+	}
+	return strings.ToLower(strings.TrimSpace(breed)), sex
+}
+
+// referenceWeight interpolates the weight at age between the points of a reference, 0 outside of them
+func referenceWeight(points []GrowthReferencePoint, age int) float64 {
+	for i := 1; i < len(points); i++ {
+		before, after := points[i-1], points[i]
+		if age < before.Age_days || age > after.Age_days {
+			continue
+		}
+		return before.Weight + (after.Weight-before.Weight)*float64(age-before.Age_days)/float64(after.Age_days-before.Age_days)
+	}
+	return 0
+}
+
+// dailyGain is the gain in kg per day between two weighings, 0 when they are on the same day
+func dailyGain(fromDate string, fromWeight float64, toDate string, toWeight float64) float64 {
+	from, err := parseDate(fromDate)
+	if err != nil {
+		return 0
+	}
+	to, err := parseDate(toDate)
+	if err != nil {
+		return 0
+	}
+	days := to.Sub(from).Hours() / 24
+	if days <= 0 {
+		return 0
+	}
+	return round((toWeight-fromWeight)/days, 3)
+}
+
+// round rounds x to the given number of decimals
+func round(x float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(x*scale) / scale
+}
+
+// growthAggregate sums the growth of cows
+type growthAggregate struct {
+	cows        int
+	weightTotal float64
+	gaining     int
+	gainTotal   float64
+}
+
+func (a *growthAggregate) add(growth CowGrowth) {
+	a.cows++
+	a.weightTotal += growth.Last_weight
+	if growth.Weighings > 1 && growth.First_date != growth.Last_date {
+		a.gaining++
+		a.gainTotal += growth.Daily_gain
+	}
+}
+
+func (a *growthAggregate) stats(sex string) GrowthStats {
+	stats := GrowthStats{Sex: sex, Cows: a.cows, Gaining: a.gaining}
+	if a.cows > 0 {
+		stats.Average_weight = round(a.weightTotal/float64(a.cows), 2)
+	}
+	if a.gaining > 0 {
+		stats.Average_daily_gain = round(a.gainTotal/float64(a.gaining), 3)
+	}
+	return stats
+}
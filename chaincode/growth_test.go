@@ -0,0 +1,83 @@
+package chaincode_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/lotty02cho/fabcow-test/chaincode"
+)
+
+func TestGrowth(t *testing.T) {
+	c := newContract(t)
+	c.registerFarm("", "FARM0")
+	// born on 20180501, 180 days old on 20181028 and 365 days old on 20190501
+	c.registerCow("", "180501-1", "M", "FARM0")
+	c.registerCow("", "180501-2", "F", "FARM0")
+	if err := c.ledger.Submit(c.admin, "setGrowthReference", "hanwoo", "*", "365:330, 0:28, 180:180").Err(); err != nil {
+		t.Fatal(err)
+	}
+	c.submit("addWeighing", "180501-1", "20190501", "297", "scale", "Hong")
+	// weighed earlier, recorded late
+	c.submit("addWeighing", "180501-1", "2018-10-28", "180kg", "tape", "")
+	c.submit("addWeighing", "180501-2", "20190501", "300", "scale", "Hong")
+
+	result := c.ledger.Evaluate(c.farmer, "getGrowthCurve", "180501-1", "hanwoo")
+	if err := result.Err(); err != nil {
+		t.Fatal(err)
+	}
+	curve := chaincode.GrowthCurve{}
+	if err := json.Unmarshal(result.Payload, &curve); err != nil {
+		t.Fatal(err)
+	}
+	if len(curve.Points) != 2 || curve.Points[0].Weigh_date != "20181028" || curve.Points[1].Weigh_date != "20190501" {
+		t.Fatalf("curve %+v, want the weighings in order of date", curve.Points)
+	}
+	if first := curve.Points[0]; first.Age_days != 180 || first.Reference_weight != 180 || first.Deviation != 0 || first.Daily_gain != 0 {
+		t.Errorf("first point %+v, want 180 days on the reference", first)
+	}
+	if second := curve.Points[1]; second.Age_days != 365 || second.Reference_weight != 330 || second.Deviation != -10 {
+		t.Errorf("second point %+v, want 365 days 10%% under the reference", second)
+	}
+	if gain := 117.0 / 185; math.Abs(curve.Average_daily_gain-gain) > 0.01 {
+		t.Errorf("average daily gain %g, want %.2f", curve.Average_daily_gain, gain)
+	}
+
+	result = c.ledger.Evaluate(c.farmer, "getGrowthReport", "FARM0", "20181001", "20190531")
+	if err := result.Err(); err != nil {
+		t.Fatal(err)
+	}
+	report := chaincode.GrowthReport{}
+	if err := json.Unmarshal(result.Payload, &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Weighings != 3 || report.Total.Cows != 2 || report.Total.Gaining != 1 || len(report.Sexes) != 2 || report.Total.Average_weight != 298.5 {
+		t.Errorf("growth report %+v, want 3 weighings of 2 cows, 1 gaining, 298.5 kg on average", report)
+	}
+
+	tests := []struct {
+		name     string
+		function string
+		args     []string
+		admin    bool
+		code     string
+	}{
+		{"no weight", "addWeighing", []string{"180501-1", "20190601", "-5", "scale", ""}, false, "invalid_argument"},
+		{"no date", "addWeighing", []string{"180501-1", "June", "300", "scale", ""}, false, "invalid_argument"},
+		{"reference set by a farmer", "setGrowthReference", []string{"hanwoo", "M", "0:28,365:330"}, false, "forbidden"},
+		{"reference of one point", "setGrowthReference", []string{"hanwoo", "M", "0:28"}, true, "invalid_argument"},
+		{"age given twice", "setGrowthReference", []string{"hanwoo", "M", "0:28,0:30"}, true, "invalid_argument"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			identity := c.farmer
+			if test.admin {
+				identity = c.admin
+			}
+			result := c.ledger.Submit(identity, test.function, test.args...)
+			if code := errorCode(t, result); code != test.code {
+				t.Errorf("%s failed with %q, want %q (%s)", test.function, code, test.code, result.Message)
+			}
+		})
+	}
+}
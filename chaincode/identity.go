@@ -0,0 +1,66 @@
+package chaincode
+
+import (
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// adminOU is the organizational unit Fabric NodeOUs put in the certificates of
+// an organization's administrators
+const adminOU = "admin"
+
+// assertAdmin fails unless the submitter of the transaction is an administrator
+func assertAdmin(ctx contractapi.TransactionContextInterface) error {
+	cert, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err != nil {
+		return err
+	}
+	if cert == nil {
+		return forbidden("no submitter certificate")
+	}
+	for _, ou := range cert.Subject.OrganizationalUnit {
+		if ou == adminOU {
+			return nil
+		}
+	}
+	return forbidden("access denied: only administrators can call this function")
+}
+
+// newStamp stamps a write with the current transaction and its submitter
+func newStamp(APIstub shim.ChaincodeStubInterface) (Stamp, error) {
+	stamp := Stamp{Tx_id: APIstub.GetTxID()}
+	var err error
+	if stamp.Tx_time, err = txTime(APIstub); err != nil {
+		return stamp, err
+	}
+
+	identity, err := cid.New(APIstub)
+	if err != nil {
+		return stamp, err
+	}
+	if stamp.Msp_id, err = identity.GetMSPID(); err != nil {
+		return stamp, err
+	}
+	cert, err := identity.GetX509Certificate()
+	if err != nil {
+		return stamp, err
+	}
+	if cert == nil {
+		return stamp, forbidden("no submitter certificate")
+	}
+	stamp.Subject = cert.Subject.String()
+	return stamp, nil
+}
+
+// txTime is the timestamp the client put on the transaction, the same on every endorsing peer
+func txTime(APIstub shim.ChaincodeStubInterface) (string, error) {
+	timestamp, err := APIstub.GetTxTimestamp()
+	if err != nil {
+		return "", err
+	}
+	return time.Unix(timestamp.Seconds, int64(timestamp.Nanos)).UTC().Format(time.RFC3339Nano), nil
+}
@@ -0,0 +1,164 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// Ledger keys are composite keys derived from the official identifiers, so the
+// same animal, business or package can only ever be stored once:
+//
+//	cow    -> Id_no (traceability number)
+//	owner  -> Biz_no (business number, the farm identification number for farms)
+//	bundle -> Barcode_id
+//	rfid   -> Rfid_no (RFID tag number)
+//
+// Keys invented by clients before this scheme ("COW0", "OWNER10", "BUNDLE0")
+// are kept in the alias index and keep resolving to the derived key.
+const (
+	cowObjectType    = "cow"
+	ownerObjectType  = "owner"
+	bundleObjectType = "bundle"
+	rfidObjectType   = "rfid"
+	aliasObjectType  = "alias"
+
+	// compositeKeyNamespace is the prefix Fabric puts in front of every composite key
+	compositeKeyNamespace = "\x00"
+)
+
+func makeCowKey(APIstub shim.ChaincodeStubInterface, idNo string) (string, error) {
+	return objectKey(APIstub, cowObjectType, idNo)
+}
+
+func makeOwnerKey(APIstub shim.ChaincodeStubInterface, bizNo string) (string, error) {
+	return objectKey(APIstub, ownerObjectType, bizNo)
+}
+
+func makeBundleKey(APIstub shim.ChaincodeStubInterface, barcodeId string) (string, error) {
+	return objectKey(APIstub, bundleObjectType, barcodeId)
+}
+
+func makeRFIDKey(APIstub shim.ChaincodeStubInterface, rfidNo string) (string, error) {
+	return objectKey(APIstub, rfidObjectType, rfidNo)
+}
+
+func objectKey(APIstub shim.ChaincodeStubInterface, objectType string, id string) (string, error) {
+	if strings.TrimSpace(id) == "" {
+		return "", invalidArgument("empty %s id", objectType)
+	}
+	return APIstub.CreateCompositeKey(objectType, []string{id})
+}
+
+// resolveKey turns whatever a client passed to refer to a record into its ledger key.
+// ref may be the derived composite key itself, a legacy client key registered in the
+// alias index, or the official id the key is derived from.
+func resolveKey(APIstub shim.ChaincodeStubInterface, objectType string, ref string) (string, error) {
+	if strings.HasPrefix(ref, compositeKeyNamespace) {
+		if !isKeyOfType(ref, objectType) {
+			return "", invalidArgument("key is not a %s key", objectType)
+		}
+		return ref, nil
+	}
+
+	if ref != "" {
+		aliasKey, err := APIstub.CreateCompositeKey(aliasObjectType, []string{ref})
+		if err != nil {
+			return "", err
+		}
+		target, err := APIstub.GetState(aliasKey)
+		if err != nil {
+			return "", err
+		}
+		if target != nil && isKeyOfType(string(target), objectType) {
+			return string(target), nil
+		}
+	}
+
+	return objectKey(APIstub, objectType, ref)
+}
+
+// resolveRFIDKey is resolveKey for RFID tags. Tags registered before they had
+// derived keys are stored under the tag number until the migration moves them.
+func resolveRFIDKey(APIstub shim.ChaincodeStubInterface, ref string) (string, error) {
+	key, err := resolveKey(APIstub, rfidObjectType, ref)
+	if err != nil || strings.HasPrefix(ref, compositeKeyNamespace) {
+		return key, err
+	}
+	rfidAsBytes, err := APIstub.GetState(key)
+	if err != nil || rfidAsBytes != nil {
+		return key, err
+	}
+	legacyAsBytes, err := APIstub.GetState(ref)
+	if err != nil {
+		return "", err
+	}
+	if legacyAsBytes != nil {
+		return ref, nil
+	}
+	return key, nil
+}
+
+// putAlias records a legacy client key as an alias of a derived key
+func putAlias(APIstub shim.ChaincodeStubInterface, legacyKey string, key string) error {
+	if legacyKey == "" || strings.HasPrefix(legacyKey, compositeKeyNamespace) {
+		return nil
+	}
+	aliasKey, err := APIstub.CreateCompositeKey(aliasObjectType, []string{legacyKey})
+	if err != nil {
+		return err
+	}
+	return APIstub.PutState(aliasKey, []byte(key))
+}
+
+func isKeyOfType(key string, objectType string) bool {
+	return strings.HasPrefix(key, compositeKeyNamespace+objectType+compositeKeyNamespace)
+}
+
+// derivedKey computes the key a legacy record has to be stored under, and the
+// record upgraded to the current schema version
+func derivedKey(APIstub shim.ChaincodeStubInterface, objectType string, value []byte) (string, []byte, error) {
+	var key string
+	var record interface{}
+	var err error
+
+	switch objectType {
+	case cowObjectType:
+		cow := Cow{}
+		if err := decodeCow(value, &cow); err != nil {
+			return "", nil, err
+		}
+		key, err = makeCowKey(APIstub, cow.Id_no)
+		record = cow
+	case ownerObjectType:
+		owner := Owner{}
+		if err := decodeOwner(value, &owner); err != nil {
+			return "", nil, err
+		}
+		key, err = makeOwnerKey(APIstub, owner.Biz_no)
+		record = owner
+	case bundleObjectType:
+		bundle := Bundle{}
+		if err := decodeBundle(value, &bundle); err != nil {
+			return "", nil, err
+		}
+		key, err = makeBundleKey(APIstub, bundle.Barcode_id)
+		record = bundle
+	case rfidObjectType:
+		rfid := RFID{}
+		if err := decodeRFID(value, &rfid); err != nil {
+			return "", nil, err
+		}
+		key, err = makeRFIDKey(APIstub, rfid.Rfid_no)
+		record = rfid
+	default:
+		return "", nil, internalError("unknown object type %s", objectType)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	recordAsBytes, err := json.Marshal(record)
+	return key, recordAsBytes, err
+}
@@ -0,0 +1,138 @@
+package chaincode_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lotty02cho/fabcow-test/chaincode"
+)
+
+func TestKeyResolution(t *testing.T) {
+	c := newContract(t)
+	c.registerFarm("OWNER0", "FARM0")
+	c.registerCow("COW0", "180501-1", "M", "OWNER0")
+	c.submit("registerRFID", "COW0", "RFID0")
+
+	tests := []struct {
+		name string
+		ref  string
+		code string
+	}{
+		{"traceability number", "180501-1", ""},
+		{"legacy key", "COW0", ""},
+		{"derived key", "\x00cow\x00180501-1\x00", ""},
+		{"key of another type", "\x00owner\x00FARM0\x00", "invalid_argument"},
+		{"legacy key of another type", "OWNER0", "not_found"},
+		{"unknown cow", "180501-9", "not_found"},
+		{"empty reference", "", "invalid_argument"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := c.ledger.Evaluate(c.farmer, "readCow", test.ref)
+			if code := errorCode(t, result); code != test.code {
+				t.Fatalf("readCow %q failed with %q, want %q (%s)", test.ref, code, test.code, result.Message)
+			}
+			if test.code == "" {
+				if cow := c.cow(test.ref); cow.Id_no != "180501-1" {
+					t.Errorf("readCow %q returned cow %s", test.ref, cow.Id_no)
+				}
+			}
+		})
+	}
+
+	t.Run("owner of the cow", func(t *testing.T) {
+		if owner := c.cow("COW0").Owner; owner.Biz_no != "FARM0" {
+			t.Errorf("cow registered with OWNER0 is owned by %q, want FARM0", owner.Biz_no)
+		}
+	})
+
+	t.Run("derived keys are stored", func(t *testing.T) {
+		for _, key := range []string{"\x00cow\x00180501-1\x00", "\x00owner\x00FARM0\x00", "\x00rfid\x00RFID0\x00"} {
+			if c.ledger.State(key) == nil {
+				t.Errorf("nothing stored under %q", key)
+			}
+		}
+		for _, key := range []string{"COW0", "OWNER0", "RFID0"} {
+			if c.ledger.State(key) != nil {
+				t.Errorf("record stored under the client key %q", key)
+			}
+		}
+	})
+
+	t.Run("same traceability number", func(t *testing.T) {
+		result := c.ledger.Submit(c.farmer, "registerCow", "COW1", "180501-1", "180501", "M", "", "", "Iksan", "FARM0")
+		if code := errorCode(t, result); code != "conflict" {
+			t.Errorf("second registration of 180501-1 failed with %q, want conflict", code)
+		}
+	})
+}
+
+func TestRegisterRFID(t *testing.T) {
+	c := newContract(t)
+	c.registerFarm("", "FARM0")
+	c.registerCow("COW0", "180501-1", "M", "FARM0")
+	c.registerCow("", "180501-2", "F", "FARM0")
+
+	tests := []struct {
+		name   string
+		cowRef string
+		rfidNo string
+		code   string
+	}{
+		{"new tag", "180501-1", "RFID0", ""},
+		{"same cow by its legacy key", "COW0", "RFID0", ""},
+		{"tag of another cow", "180501-2", "RFID0", "conflict"},
+		{"another tag", "180501-2", "RFID1", ""},
+		{"no tag number", "180501-2", " ", "invalid_argument"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := c.ledger.Submit(c.farmer, "registerRFID", test.cowRef, test.rfidNo)
+			if code := errorCode(t, result); code != test.code {
+				t.Fatalf("registerRFID %s %q failed with %q, want %q (%s)", test.cowRef, test.rfidNo, code, test.code, result.Message)
+			}
+		})
+	}
+
+	result := c.ledger.Evaluate(c.farmer, "readRFID", "RFID0")
+	rfid := chaincode.RFID{}
+	if err := result.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(result.Payload, &rfid); err != nil {
+		t.Fatal(err)
+	}
+	if rfid.Id_no != "180501-1" {
+		t.Errorf("RFID0 is on cow %s, want 180501-1", rfid.Id_no)
+	}
+}
+
+func TestRegisterHACCP(t *testing.T) {
+	c := newContract(t)
+	c.registerFarm("", "FARM0")
+	c.registerCow("COW0", "180501-1", "M", "FARM0")
+
+	tests := []struct {
+		name string
+		key  string
+		code string
+	}{
+		{"certificate", "HACCP0", ""},
+		{"same key", "HACCP0", "conflict"},
+		{"legacy cow key", "COW0", "invalid_argument"},
+		{"derived key", "\x00cow\x00180501-1\x00", "invalid_argument"},
+		{"no key", "", "invalid_argument"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := c.ledger.Submit(c.farmer, "registerHACCP", test.key, "FARM0", "FARM0", "ChukLim1", "Iksan", "Cow", "20280528")
+			if code := errorCode(t, result); code != test.code {
+				t.Fatalf("registerHACCP %q failed with %q, want %q (%s)", test.key, code, test.code, result.Message)
+			}
+		})
+	}
+
+	if cow := c.cow("COW0"); cow.Id_no != "180501-1" || cow.Sex != "M" {
+		t.Errorf("cow 180501-1 reads as %+v after the HACCP registrations", cow)
+	}
+}
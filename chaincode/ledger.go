@@ -0,0 +1,119 @@
+package chaincode
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// getCow reads the cow a client reference (Id_no, legacy key or ledger key) points to.
+// It returns the ledger key together with the cow so the caller can write it back.
+func getCow(APIstub shim.ChaincodeStubInterface, ref string) (string, Cow, error) {
+	cow := Cow{}
+	key, err := resolveKey(APIstub, cowObjectType, ref)
+	if err != nil {
+		return "", cow, err
+	}
+	cowAsBytes, err := APIstub.GetState(key)
+	if err != nil {
+		return "", cow, internalError("Failed to get state for %s: %s", ref, err.Error())
+	}
+	if cowAsBytes == nil {
+		return "", cow, notFound("Cow does not exist: %s", ref)
+	}
+	if err := decodeCow(cowAsBytes, &cow); err != nil {
+		return "", cow, withContext("Failed to decode JSON of "+ref, err)
+	}
+	return key, cow, nil
+}
+
+// getLiveCow is getCow for writes, a dead or archived cow takes no further records
+func getLiveCow(APIstub shim.ChaincodeStubInterface, ref string) (string, Cow, error) {
+	key, cow, err := getCow(APIstub, ref)
+	if err == nil && cow.Dead {
+		err = invalidState("Cow %s is dead, no further records are accepted", cow.Id_no)
+	} else if err == nil && cow.Archived != nil {
+		err = invalidState("Cow %s is archived, no further records are accepted", cow.Id_no)
+	}
+	return key, cow, err
+}
+
+// getOwner reads the owner a client reference (Biz_no, legacy key or ledger key) points to
+func getOwner(APIstub shim.ChaincodeStubInterface, ref string) (string, Owner, error) {
+	owner := Owner{}
+	key, err := resolveKey(APIstub, ownerObjectType, ref)
+	if err != nil {
+		return "", owner, err
+	}
+	ownerAsBytes, err := APIstub.GetState(key)
+	if err != nil {
+		return "", owner, internalError("Failed to get state for %s: %s", ref, err.Error())
+	}
+	if ownerAsBytes == nil {
+		return "", owner, notFound("Owner does not exist: %s", ref)
+	}
+	if err := decodeOwner(ownerAsBytes, &owner); err != nil {
+		return "", owner, withContext("Failed to decode JSON of "+ref, err)
+	}
+	return key, owner, nil
+}
+
+// getBundle reads the bundle a client reference (Barcode_id, legacy key or ledger key) points to
+func getBundle(APIstub shim.ChaincodeStubInterface, ref string) (string, Bundle, error) {
+	bundle := Bundle{}
+	key, err := resolveKey(APIstub, bundleObjectType, ref)
+	if err != nil {
+		return "", bundle, err
+	}
+	bundleAsBytes, err := APIstub.GetState(key)
+	if err != nil {
+		return "", bundle, internalError("Failed to get state for %s: %s", ref, err.Error())
+	}
+	if bundleAsBytes == nil {
+		return "", bundle, notFound("Bundle does not exist: %s", ref)
+	}
+	if err := decodeBundle(bundleAsBytes, &bundle); err != nil {
+		return "", bundle, withContext("Failed to decode JSON of "+ref, err)
+	}
+	return key, bundle, nil
+}
+
+// assertNotExists fails when something is already stored under key
+func assertNotExists(APIstub shim.ChaincodeStubInterface, key string, what string) error {
+	existingAsBytes, err := APIstub.GetState(key)
+	if err != nil {
+		return err
+	}
+	if existingAsBytes != nil {
+		return conflict("%s already registered", what)
+	}
+	return nil
+}
+
+// stampedRecord is a record that carries the Stamp of its last write
+type stampedRecord interface {
+	stamp(stamp Stamp)
+}
+
+// putRecord stamps a record with the current transaction and stores it as JSON
+func putRecord(APIstub shim.ChaincodeStubInterface, key string, record stampedRecord) error {
+	stamp, err := newStamp(APIstub)
+	if err != nil {
+		return err
+	}
+	record.stamp(stamp)
+	txLogger(APIstub).debug("record written", "key", key, "record", record)
+
+	// the EPCIS export of a time range finds the cows through the months of their events
+	if cow, ok := record.(*Cow); ok {
+		if err := putEPCISIndex(APIstub, key, *cow); err != nil {
+			return err
+		}
+	}
+
+	recordAsBytes, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return APIstub.PutState(key, recordAsBytes)
+}
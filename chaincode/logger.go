@@ -0,0 +1,206 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// The chaincode logs one JSON object per line to stderr, which ends up in the
+// chaincode container log:
+//
+//	{"time":"…","level":"info","msg":"transaction","function":"AddInfoDead","tx_id":"…"}
+//
+// The level is read from FABCOW_LOG_LEVEL, then from the CORE_CHAINCODE_LOGGING_LEVEL
+// the peer sets on chaincode containers (debug, info, warning or error, info if
+// unset). Personal data is never written: fields named like the birth dates,
+// names and addresses of owner users, farm managers and grade subscribers are replaced by
+// redactedValue, also inside logged records and their remarks.
+const redactedValue = "[REDACTED]"
+
+type logLevel int
+
+const (
+	levelDebug logLevel = iota
+	levelInfo
+	levelWarning
+	levelError
+)
+
+var levelNames = map[logLevel]string{levelDebug: "debug", levelInfo: "info", levelWarning: "warning", levelError: "error"}
+
+// personalFieldSuffixes are the endings of the field names that hold personal data
+var personalFieldSuffixes = []string{"_birth", "_birth_date", "_user_nm", "_user_addr", "subscriber_nm", "subscriber_addr"}
+
+// personalRemarkKeys are the remark keys that hold personal data without such an
+// ending: the farm manager name addAut notes on owners under its bare field name.
+// The farm_nm of HACCP records and of reports is the name of the farm and is kept.
+var personalRemarkKeys = []string{"farm_nm"}
+
+var (
+	minLogLevel           = logLevelFromEnv()
+	logOutput   io.Writer = os.Stderr
+	logMutex    sync.Mutex
+)
+
+// logger writes log lines carrying its fields
+type logger struct {
+	// fields are key, value pairs
+	fields []interface{}
+}
+
+// txLogger is the logger of the transaction running on APIstub
+func txLogger(APIstub shim.ChaincodeStubInterface) *logger {
+	function, _ := APIstub.GetFunctionAndParameters()
+	return &logger{fields: []interface{}{"function", transactionName(function), "tx_id", APIstub.GetTxID()}}
+}
+
+// with returns a logger that adds the key, value pairs to every line
+func (l *logger) with(keyValues ...interface{}) *logger {
+	return &logger{fields: append(append([]interface{}{}, l.fields...), keyValues...)}
+}
+
+func (l *logger) debug(msg string, keyValues ...interface{}) {
+	l.log(levelDebug, msg, keyValues)
+}
+
+func (l *logger) info(msg string, keyValues ...interface{}) {
+	l.log(levelInfo, msg, keyValues)
+}
+
+func (l *logger) warning(msg string, keyValues ...interface{}) {
+	l.log(levelWarning, msg, keyValues)
+}
+
+func (l *logger) error(msg string, keyValues ...interface{}) {
+	l.log(levelError, msg, keyValues)
+}
+
+func (l *logger) log(level logLevel, msg string, keyValues []interface{}) {
+	if level < minLogLevel {
+		return
+	}
+
+	// keys keep the order they were given in
+	var line strings.Builder
+	line.WriteString("{")
+	writeLogField(&line, "time", time.Now().UTC().Format(time.RFC3339Nano))
+	writeLogField(&line, "level", levelNames[level])
+	writeLogField(&line, "msg", msg)
+	fields := append(append([]interface{}{}, l.fields...), keyValues...)
+	for i := 0; i+1 < len(fields); i += 2 {
+		key := fmt.Sprint(fields[i])
+		writeLogField(&line, key, redactField(key, fields[i+1]))
+	}
+	line.WriteString("}\n")
+
+	logMutex.Lock()
+	defer logMutex.Unlock()
+	io.WriteString(logOutput, line.String())
+}
+
+func writeLogField(line *strings.Builder, key string, value interface{}) {
+	if err, ok := value.(error); ok {
+		value = err.Error()
+	}
+	valueAsBytes, err := json.Marshal(value)
+	if err != nil {
+		valueAsBytes, _ = json.Marshal(fmt.Sprint(value))
+	}
+	keyAsBytes, _ := json.Marshal(key)
+	if line.Len() > 1 {
+		line.WriteString(",")
+	}
+	line.Write(keyAsBytes)
+	line.WriteString(":")
+	line.Write(valueAsBytes)
+}
+
+// redactField returns value with the personal data in it replaced
+func redactField(key string, value interface{}) interface{} {
+	if isPersonalField(key) {
+		return redactedValue
+	}
+	switch value.(type) {
+	case nil, string, bool, int, int64, float64, error:
+		return value
+	}
+
+	// records are redacted through their JSON form
+	valueAsBytes, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var document interface{}
+	if err := json.Unmarshal(valueAsBytes, &document); err != nil {
+		return value
+	}
+	return redactDocument(document)
+}
+
+func redactDocument(document interface{}) interface{} {
+	switch document := document.(type) {
+	case map[string]interface{}:
+		// remarks are {"Key": "addInfoGradeResult.subscriber_birth", "Value": …}
+		if key, ok := document["Key"].(string); ok && (isPersonalField(key) || isPersonalRemark(key)) {
+			if _, ok := document["Value"]; ok {
+				document["Value"] = redactedValue
+			}
+		}
+		for key, value := range document {
+			if isPersonalField(key) {
+				document[key] = redactedValue
+			} else {
+				document[key] = redactDocument(value)
+			}
+		}
+	case []interface{}:
+		for i := range document {
+			document[i] = redactDocument(document[i])
+		}
+	}
+	return document
+}
+
+// isPersonalField matches field names and remark keys ("addInfoGradeResult.subscriber_birth")
+func isPersonalField(name string) bool {
+	name = strings.ToLower(name[strings.LastIndex(name, ".")+1:])
+	for _, suffix := range personalFieldSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPersonalRemark matches whole remark keys, "farm_nm" but not "addBTVaccine.farm_nm"
+func isPersonalRemark(key string) bool {
+	for _, personal := range personalRemarkKeys {
+		if key == personal {
+			return true
+		}
+	}
+	return false
+}
+
+func logLevelFromEnv() logLevel {
+	setting := os.Getenv("FABCOW_LOG_LEVEL")
+	if setting == "" {
+		setting = os.Getenv("CORE_CHAINCODE_LOGGING_LEVEL")
+	}
+	switch strings.ToLower(strings.TrimSpace(setting)) {
+	case "debug":
+		return levelDebug
+	case "warning", "warn":
+		return levelWarning
+	case "error", "critical", "panic", "fatal":
+		return levelError
+	}
+	return levelInfo
+}
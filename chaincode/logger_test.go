@@ -0,0 +1,65 @@
+package chaincode
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestLogRedaction(t *testing.T) {
+	var output bytes.Buffer
+	logOutput, minLogLevel = &output, levelDebug
+	defer func() {
+		logOutput, minLogLevel = os.Stderr, logLevelFromEnv()
+	}()
+
+	owner := Owner{Owner_nm: "ChukLim1", Owner_user_nm: "Kim", Owner_user_birth: "530118", Remarks: []Remark{{Key: "farm_nm", Value: "Lee"}, {Key: "farm_birth_date", Value: "1985.10.27"}, {Key: "aut_item", Value: "Cow"}}}
+	cow := Cow{Id_no: "180501-1", Remarks: []Remark{{Key: "addBTVaccine.farm_nm", Value: "ChukLim1"}, {Key: "addBTVaccine.farm_user_nm", Value: "Kim"}, {Key: "addInfoGradeResult.subscriber_birth", Value: "1985.10.27"}}}
+	haccp := HACCP{Farm_id: "FARM0", Farm_nm: "ChukLim1"}
+	(&logger{}).info("records", "owner", owner, "cow", cow, "haccp", haccp, "farm_user_birth", "530118", "farm_nm", "ChukLim1")
+
+	line := struct {
+		Owner           Owner  `json:"owner"`
+		Cow             Cow    `json:"cow"`
+		HACCP           HACCP  `json:"haccp"`
+		Farm_user_birth string `json:"farm_user_birth"`
+		Farm_nm         string `json:"farm_nm"`
+	}{}
+	if err := json.Unmarshal(output.Bytes(), &line); err != nil {
+		t.Fatalf("log line %q: %v", output.String(), err)
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"owner user name", line.Owner.Owner_user_nm, redactedValue},
+		{"owner user birth date", line.Owner.Owner_user_birth, redactedValue},
+		{"owner name", line.Owner.Owner_nm, "ChukLim1"},
+		{"farm manager name of addAut", remarkValue(line.Owner.Remarks, "farm_nm"), redactedValue},
+		{"farm manager birth date of addAut", remarkValue(line.Owner.Remarks, "farm_birth_date"), redactedValue},
+		{"certified item of addAut", remarkValue(line.Owner.Remarks, "aut_item"), "Cow"},
+		{"farm name of a report", remarkValue(line.Cow.Remarks, "addBTVaccine.farm_nm"), "ChukLim1"},
+		{"farm user of a report", remarkValue(line.Cow.Remarks, "addBTVaccine.farm_user_nm"), redactedValue},
+		{"grade subscriber birth date", remarkValue(line.Cow.Remarks, "addInfoGradeResult.subscriber_birth"), redactedValue},
+		{"farm name of a HACCP record", line.HACCP.Farm_nm, "ChukLim1"},
+		{"personal field", line.Farm_user_birth, redactedValue},
+		{"farm name field", line.Farm_nm, "ChukLim1"},
+	}
+	for _, test := range tests {
+		if test.value != test.want {
+			t.Errorf("%s logged as %q, want %q", test.name, test.value, test.want)
+		}
+	}
+}
+
+func remarkValue(remarks []Remark, key string) string {
+	for _, remark := range remarks {
+		if remark.Key == key {
+			return remark.Value
+		}
+	}
+	return ""
+}
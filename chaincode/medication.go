@@ -0,0 +1,276 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Veterinary treatments are addTreatment remarks on the cow. A drug with a
+// withdrawal period keeps the cow from slaughter until withdrawal_end, the
+// treatment date plus the withdrawal days: addInfoInspect is refused for a
+// slaughter date before it, and for one after the date of the transaction.
+// Treatments with a withdrawal period are indexed under ("withdrawal", [YYYYMM,
+// YYYYMMDD, Id_no, n]) by the end of the period, so the cows still held on a date are found by reading the months up to
+// maxWithdrawalDays ahead. n numbers the treatments of a cow.
+const (
+	withdrawalIndexObjectType = "withdrawal"
+	// maxWithdrawalDays bounds the withdrawal period of a treatment
+	maxWithdrawalDays = 730
+)
+
+type WithdrawalTreatment struct {
+	Drug            string `json:"drug"`
+	Dose            string `json:"dose"`
+	Treat_date      string `json:"treat_date"`
+	Veterinarian_no string `json:"veterinarian_no"`
+	Veterinarian_nm string `json:"veterinarian_nm"`
+	Withdrawal_days int    `json:"withdrawal_days"`
+	// Withdrawal_end is the first day the cow may be slaughtered again
+	Withdrawal_end string `json:"withdrawal_end"`
+}
+
+// WithdrawalHold is a cow that can not be slaughtered yet and the treatments holding it
+type WithdrawalHold struct {
+	Id_no        string `json:"id_no"`
+	Owner_biz_no string `json:"owner_biz_no"`
+	Owner_nm     string `json:"owner_nm"`
+	// Clear_date is the first day the cow may be slaughtered, the last Withdrawal_end of its treatments
+	Clear_date string                `json:"clear_date"`
+	Treatments []WithdrawalTreatment `json:"treatments"`
+}
+
+type WithdrawalReport struct {
+	Owner string           `json:"owner"`
+	Date  string           `json:"date"`
+	Cows  []WithdrawalHold `json:"cows"`
+}
+
+// withdrawalIndexEntry is the value of a withdrawal index key
+type withdrawalIndexEntry struct {
+	Id_no     string              `json:"id_no"`
+	Treatment WithdrawalTreatment `json:"treatment"`
+}
+
+// AddTreatment notes a veterinary drug treatment of a cow
+func (s *SmartContract) AddTreatment(ctx contractapi.TransactionContextInterface, cowRef string, treatDate string, drug string, dose string, veterinarianNo string, veterinarianNm string, withdrawalDays string) error {
+	//'{"Args":["addTreatment","180501-1", "20190301", "oxytetracycline", "20 mg/kg IM", "VET-1234", "Kim", "28"]}'
+	//treatDate			-- date of the (last) dose, YYYYMMDD
+	//drug				-- drug given
+	//dose				-- dose and route
+	//veterinarianNo	-- license number of the prescribing veterinarian
+	//veterinarianNm	-- name of the prescribing veterinarian
+	//withdrawalDays	-- withdrawal period of the drug in days, 0 for none
+
+	return s.AddTreatmentJSON(ctx, TreatmentPayload{Cow: cowRef, Treat_date: treatDate, Drug: drug, Dose: dose, Veterinarian_no: veterinarianNo, Veterinarian_nm: veterinarianNm, Withdrawal_days: withdrawalDays})
+}
+
+// AddTreatmentJSON is addTreatment with named fields
+func (s *SmartContract) AddTreatmentJSON(ctx contractapi.TransactionContextInterface, payload TreatmentPayload) error {
+	variables := []string{"addTreatment.treat_date", "addTreatment.drug", "addTreatment.dose", "addTreatment.veterinarian_no", "addTreatment.veterinarian_nm", "addTreatment.withdrawal_days", "addTreatment.withdrawal_end"}
+
+	treatment, err := newWithdrawalTreatment(payload)
+	if err != nil {
+		return err
+	}
+
+	APIstub := ctx.GetStub()
+
+	key, cow, err := getLiveCow(APIstub, payload.Cow)
+	if err != nil {
+		return err
+	}
+
+	cow.setCowRemarks(variables, []string{treatment.Treat_date, treatment.Drug, treatment.Dose, treatment.Veterinarian_no, treatment.Veterinarian_nm, strconv.Itoa(treatment.Withdrawal_days), treatment.Withdrawal_end})
+
+	// the held cows are found through the index, not the cows
+	if treatment.Withdrawal_days > 0 {
+		n := len(remarkEvents(cow.Remarks, "addTreatment")) - 1
+		indexKey, err := APIstub.CreateCompositeKey(withdrawalIndexObjectType, []string{treatment.Withdrawal_end[:6], treatment.Withdrawal_end, cow.Id_no, strconv.Itoa(n)})
+		if err != nil {
+			return err
+		}
+		entryAsBytes, err := json.Marshal(withdrawalIndexEntry{Id_no: cow.Id_no, Treatment: treatment})
+		if err != nil {
+			return err
+		}
+		if err := APIstub.PutState(indexKey, entryAsBytes); err != nil {
+			return err
+		}
+	}
+
+	return putRecord(APIstub, key, &cow)
+}
+
+// GetWithdrawalHolds returns the live cows that can not be slaughtered on a date and the treatments holding them
+func (s *SmartContract) GetWithdrawalHolds(ctx contractapi.TransactionContextInterface, ownerRef string, date string) (*WithdrawalReport, error) {
+	//'{"Args":["getWithdrawalHolds", "FARM0", "20190315"]}'
+	//ownerRef	-- current owner of the cows (Biz_no or legacy OWNER key), empty for all owners
+	//date		-- YYYYMMDD, empty for the date of the transaction
+
+	APIstub := ctx.GetStub()
+
+	report := WithdrawalReport{Cows: []WithdrawalHold{}}
+	if ownerRef != "" {
+		_, owner, err := getOwner(APIstub, ownerRef)
+		if err != nil {
+			return nil, err
+		}
+		report.Owner = owner.Biz_no
+	}
+	day, err := withdrawalQueryDate(APIstub, date)
+	if err != nil {
+		return nil, err
+	}
+	report.Date = day.Format("20060102")
+
+	holds := map[string]*WithdrawalHold{}
+	for _, month := range monthsBetween(report.Date[:6], day.AddDate(0, 0, maxWithdrawalDays).Format("200601")) {
+		resultsIterator, err := APIstub.GetStateByPartialCompositeKey(withdrawalIndexObjectType, []string{month})
+		if err != nil {
+			return nil, err
+		}
+		err = func() error {
+			defer resultsIterator.Close()
+			for resultsIterator.HasNext() {
+				queryResponse, err := resultsIterator.Next()
+				if err != nil {
+					return err
+				}
+				entry := withdrawalIndexEntry{}
+				if err := json.Unmarshal(queryResponse.Value, &entry); err != nil {
+					return err
+				}
+				if entry.Treatment.Withdrawal_end <= report.Date {
+					continue
+				}
+				hold, ok := holds[entry.Id_no]
+				if !ok {
+					hold = &WithdrawalHold{Id_no: entry.Id_no, Treatments: []WithdrawalTreatment{}}
+					holds[entry.Id_no] = hold
+				}
+				hold.Treatments = append(hold.Treatments, entry.Treatment)
+				if entry.Treatment.Withdrawal_end > hold.Clear_date {
+					hold.Clear_date = entry.Treatment.Withdrawal_end
+				}
+			}
+			return nil
+		}()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for id, hold := range holds {
+		_, cow, err := getCow(APIstub, id)
+		if err != nil {
+			return nil, err
+		}
+		// dead, archived and slaughtered cows are not waiting for slaughter
+		if cow.Dead || cow.Archived != nil || lastRemarkEvent(cow.Remarks, "addInfoInspect") != nil {
+			continue
+		}
+		if report.Owner != "" && cow.Owner.Biz_no != report.Owner {
+			continue
+		}
+		hold.Owner_biz_no, hold.Owner_nm = cow.Owner.Biz_no, cow.Owner.Owner_nm
+		report.Cows = append(report.Cows, *hold)
+	}
+	sort.Slice(report.Cows, func(i, j int) bool { return report.Cows[i].Id_no < report.Cows[j].Id_no })
+	return &report, nil
+}
+
+// newWithdrawalTreatment checks a treatment and works out the end of its withdrawal period
+func newWithdrawalTreatment(payload TreatmentPayload) (WithdrawalTreatment, error) {
+	treatment := WithdrawalTreatment{Drug: strings.TrimSpace(payload.Drug), Dose: payload.Dose, Veterinarian_no: strings.TrimSpace(payload.Veterinarian_no), Veterinarian_nm: payload.Veterinarian_nm}
+	if treatment.Drug == "" {
+		return treatment, invalidArgument("Incorrect value. drug must not be empty")
+	}
+	if treatment.Veterinarian_no == "" {
+		return treatment, invalidArgument("Incorrect value. veterinarian_no must not be empty")
+	}
+	treated, err := parseDate(payload.Treat_date)
+	if err != nil {
+		return treatment, withContext("treat_date", invalidArgument("Incorrect date %q, expecting YYYYMMDD", payload.Treat_date))
+	}
+	treatment.Treat_date = treated.Format("20060102")
+	days := strings.TrimSpace(payload.Withdrawal_days)
+	if days != "" {
+		if treatment.Withdrawal_days, err = strconv.Atoi(days); err != nil || treatment.Withdrawal_days < 0 || treatment.Withdrawal_days > maxWithdrawalDays {
+			return treatment, invalidArgument("Incorrect withdrawal_days %q, expecting 0 to %d days", payload.Withdrawal_days, maxWithdrawalDays)
+		}
+	}
+	treatment.Withdrawal_end = treated.AddDate(0, 0, treatment.Withdrawal_days).Format("20060102")
+	return treatment, nil
+}
+
+// checkWithdrawal refuses the slaughter of cow while a treatment holds it. The
+// slaughter is dated slaughterDate, or inspectionDate when that is empty, or the
+// transaction date when both are; a date after the transaction date is refused,
+// a slaughter can not be recorded ahead. Without treatments with a withdrawal
+// period nothing is checked, inspections were recorded long before the dates on them were.
+func checkWithdrawal(APIstub shim.ChaincodeStubInterface, cow Cow, slaughterDate string, inspectionDate string) error {
+	treatments := []map[string]string{}
+	for _, treatment := range remarkEvents(cow.Remarks, "addTreatment") {
+		if treatment["withdrawal_days"] != "0" {
+			treatments = append(treatments, treatment)
+		}
+	}
+	if len(treatments) == 0 {
+		return nil
+	}
+
+	txDay, err := withdrawalQueryDate(APIstub, "")
+	if err != nil {
+		return err
+	}
+	day := txDay.Format("20060102")
+	// the inspection date goes first, so the slaughter date is the one compared when both are given
+	for _, field := range []struct{ name, date string }{{"inspection_date", inspectionDate}, {"slaughter_date", slaughterDate}} {
+		if strings.TrimSpace(field.date) == "" {
+			continue
+		}
+		date, err := parseDate(field.date)
+		if err != nil {
+			// a treated cow can not be cleared without knowing when it was slaughtered
+			return withContext(field.name, invalidArgument("Incorrect date %q, expecting YYYYMMDD", field.date))
+		}
+		if date.After(txDay) {
+			return withContext(field.name, invalidArgument("%s is after the transaction date %s", date.Format("20060102"), txDay.Format("20060102")))
+		}
+		day = date.Format("20060102")
+	}
+
+	var hold map[string]string
+	for _, treatment := range treatments {
+		if treatment["withdrawal_end"] > day && (hold == nil || treatment["withdrawal_end"] > hold["withdrawal_end"]) {
+			hold = treatment
+		}
+	}
+	if hold != nil {
+		return invalidState("Cow %s is inside the withdrawal period of %s given on %s, it can not be slaughtered before %s", cow.Id_no, hold["drug"], hold["treat_date"], hold["withdrawal_end"])
+	}
+	return nil
+}
+
+// withdrawalQueryDate reads the date of a withdrawal query, the date of the transaction in Korea when empty
+func withdrawalQueryDate(APIstub shim.ChaincodeStubInterface, date string) (time.Time, error) {
+	if strings.TrimSpace(date) != "" {
+		day, err := parseDate(date)
+		if err != nil {
+			return day, invalidArgument("Incorrect date %q, expecting YYYYMMDD", date)
+		}
+		return day, nil
+	}
+	timestamp, err := APIstub.GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, err
+	}
+	day := time.Unix(timestamp.Seconds, int64(timestamp.Nanos)).In(koreaZone).Format("20060102")
+	return time.Parse("20060102", day)
+}
@@ -0,0 +1,50 @@
+package chaincode_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lotty02cho/fabcow-test/chaincode"
+)
+
+func TestWithdrawalRefusal(t *testing.T) {
+	// the ledger clock starts on 2020-01-01 UTC, 20200101 in Korea
+	tests := []struct {
+		name           string
+		withdrawalDays string
+		now            time.Time
+		slaughterDate  string
+		inspectionDate string
+		code           string
+	}{
+		{"inside the withdrawal period", "28", time.Time{}, "20191231", "20191231", "invalid_state"},
+		{"on the day the period ends", "28", time.Date(2020, 1, 20, 0, 0, 0, 0, time.UTC), "20200117", "20200117", ""},
+		{"after the period", "28", time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), "20200131", "20200131", ""},
+		{"inspection date inside the period", "28", time.Time{}, "", "20191231", "invalid_state"},
+		{"no date on a held cow", "28", time.Time{}, "", "", "invalid_state"},
+		{"no date after the period", "28", time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), "", "", ""},
+		{"slaughter date ahead of the transaction", "28", time.Time{}, "20200201", "20191231", "invalid_argument"},
+		{"inspection date ahead of the transaction", "28", time.Time{}, "20191231", "20200201", "invalid_argument"},
+		{"no calendar date", "28", time.Time{}, "20200231", "20191231", "invalid_argument"},
+		{"drug without a withdrawal period", "0", time.Time{}, "20191231", "20191231", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := newContract(t)
+			c.registerFarm("", "FARM0")
+			c.registerCow("", "180501-1", "M", "FARM0")
+			c.submit("addTreatment", "180501-1", "20191220", "oxytetracycline", "20 mg/kg IM", "VET-1234", "Kim", test.withdrawalDays)
+			if !test.now.IsZero() {
+				c.ledger.SetTime(test.now)
+			}
+
+			result := c.submitJSON("addInfoInspect", chaincode.InspectPayload{Cow: "180501-1", Livestock: "C", Id_no: "180501-1", Weight: "420", Slaughter_nm: "Iksan", Seal_no: "S-1", Slaughter_date: test.slaughterDate, Farm_id: "FARM0", Farm_addr: "Iksan", Haccp_yn: "Y", Fale_method: "", Inspection_date: test.inspectionDate, Inspection_part: "all", Inspection_user_nm: "Lee", Veterinarian_no: "VET-9"})
+			if code := errorCode(t, result); code != test.code {
+				t.Fatalf("addInfoInspect failed with %q, want %q (%s)", code, test.code, result.Message)
+			}
+			if inspected := remark(c.cow("180501-1"), "addInfoInspect.seal_no") != ""; inspected != (test.code == "") {
+				t.Errorf("inspection recorded: %v", inspected)
+			}
+		})
+	}
+}
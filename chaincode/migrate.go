@@ -0,0 +1,310 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"unicode/utf8"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// The migration rewrites every record to the current schema version after a chaincode
+// upgrade. It works in batches small enough for one transaction and
+// keeps its progress on the ledger, so an administrator calls it repeatedly until
+// Done is reported. A transaction never reads its own writes, so a call that
+// wrote something ends with its step: the next steps read those records in a
+// later call.
+const (
+	migrationObjectType     = "migration"
+	defaultMigrateBatchSize = 100
+)
+
+type MigrationStatus struct {
+	Target_version int      `json:"Target_version"`
+	Step           string   `json:"Step"`
+	Last_key       string   `json:"Last_key"`
+	Processed      int      `json:"Processed"`
+	Upgraded       int      `json:"Upgraded"`
+	Duplicates     []string `json:"Duplicates"`
+	Failed         []string `json:"Failed"`
+	Done           bool     `json:"Done"`
+}
+
+// migrationStep is one pass over a set of records, steps run in the order listed.
+// records iterates the records with keys after the given one, in key order.
+type migrationStep struct {
+	name    string
+	records func(APIstub shim.ChaincodeStubInterface, after string) (shim.StateQueryIteratorInterface, error)
+	migrate func(APIstub shim.ChaincodeStubInterface, key string, value []byte, run *migrationRun) error
+}
+
+// migrationRun is the state of one migrate call
+type migrationRun struct {
+	status *MigrationStatus
+	// writes are not visible to reads of the same transaction, so keys written by this call are tracked here
+	written map[string]bool
+}
+
+var migrationSteps = []migrationStep{
+	// records under client invented keys first move to their derived keys
+	{"legacy_cow", legacyRecords("COW"), migrateLegacyKey(cowObjectType)},
+	{"legacy_owner", legacyRecords("OWNER"), migrateLegacyKey(ownerObjectType)},
+	{"legacy_bundle", legacyRecords("BUNDLE"), migrateLegacyKey(bundleObjectType)},
+	{"legacy_rfid", legacyRecords("RFID"), migrateLegacyKey(rfidObjectType)},
+	{"cow", objectRecords(cowObjectType), upgradeRecord(func(value []byte) (interface{}, error) {
+		cow := Cow{}
+		err := decodeCow(value, &cow)
+		return cow, err
+	})},
+	{"owner", objectRecords(ownerObjectType), upgradeRecord(func(value []byte) (interface{}, error) {
+		owner := Owner{}
+		err := decodeOwner(value, &owner)
+		return owner, err
+	})},
+	{"bundle", objectRecords(bundleObjectType), upgradeRecord(func(value []byte) (interface{}, error) {
+		bundle := Bundle{}
+		err := decodeBundle(value, &bundle)
+		return bundle, err
+	})},
+	// HACCP records are still stored under the keys clients pass
+	{"haccp", legacyRecords("HACCP"), upgradeRecord(func(value []byte) (interface{}, error) {
+		haccp := HACCP{}
+		err := decodeHACCP(value, &haccp)
+		return haccp, err
+	})},
+	{"rfid", objectRecords(rfidObjectType), upgradeRecord(func(value []byte) (interface{}, error) {
+		rfid := RFID{}
+		err := decodeRFID(value, &rfid)
+		return rfid, err
+	})},
+	{"grade_index", objectRecords(cowObjectType), indexGradeResults},
+	{"sire_index", objectRecords(cowObjectType), indexSires},
+	{"death_index", objectRecords(cowObjectType), indexDeaths},
+	{"owner_type_index", objectRecords(ownerObjectType), indexOwnerTypes},
+	{"report_index", objectRecords(cowObjectType), indexReports},
+	{"epcis_index", objectRecords(cowObjectType), indexEPCISEvents},
+}
+
+// legacyRecords iterates the simple keys starting with prefix. Composite keys are
+// never returned by range queries, so derived keys do not show up here. The range
+// starts right after the last key done, so a call reads only its own batch.
+func legacyRecords(prefix string) func(APIstub shim.ChaincodeStubInterface, after string) (shim.StateQueryIteratorInterface, error) {
+	return func(APIstub shim.ChaincodeStubInterface, after string) (shim.StateQueryIteratorInterface, error) {
+		startKey := prefix
+		if after != "" {
+			// the smallest key after the last one done
+			startKey = after + "\x00"
+		}
+		return APIstub.GetStateByRange(startKey, prefix+string(utf8.MaxRune))
+	}
+}
+
+// objectRecords iterates the derived keys of objectType. The shim refuses range
+// queries on composite keys and paginated queries in a transaction that writes,
+// so the range can only be opened at its start and runMigrationStep skips the
+// keys up to the last one done.
+func objectRecords(objectType string) func(APIstub shim.ChaincodeStubInterface, after string) (shim.StateQueryIteratorInterface, error) {
+	return func(APIstub shim.ChaincodeStubInterface, after string) (shim.StateQueryIteratorInterface, error) {
+		return APIstub.GetStateByPartialCompositeKey(objectType, []string{})
+	}
+}
+
+// migrateLegacyKey moves a record to its derived key and leaves the old key behind as an alias
+func migrateLegacyKey(objectType string) func(APIstub shim.ChaincodeStubInterface, key string, value []byte, run *migrationRun) error {
+	return func(APIstub shim.ChaincodeStubInterface, legacyKey string, value []byte, run *migrationRun) error {
+		key, recordAsBytes, err := derivedKey(APIstub, objectType, value)
+		if err != nil {
+			txLogger(APIstub).warning("migration skipped a record", "key", legacyKey, "error", err)
+			run.status.Failed = append(run.status.Failed, legacyKey)
+			return nil
+		}
+
+		existing, err := APIstub.GetState(key)
+		if err != nil {
+			return err
+		}
+		if existing != nil || run.written[key] {
+			// the same animal was registered twice, leave it for a manual merge
+			run.status.Duplicates = append(run.status.Duplicates, legacyKey)
+			return nil
+		}
+
+		if err := APIstub.PutState(key, recordAsBytes); err != nil {
+			return err
+		}
+		if err := putAlias(APIstub, legacyKey, key); err != nil {
+			return err
+		}
+		if err := APIstub.DelState(legacyKey); err != nil {
+			return err
+		}
+		run.written[key] = true
+		run.status.Upgraded++
+		return nil
+	}
+}
+
+// upgradeRecord rewrites a record whose schemaVersion is older than the current one
+func upgradeRecord(decode func(value []byte) (interface{}, error)) func(APIstub shim.ChaincodeStubInterface, key string, value []byte, run *migrationRun) error {
+	return func(APIstub shim.ChaincodeStubInterface, key string, value []byte, run *migrationRun) error {
+		var version struct {
+			SchemaVersion int `json:"schemaVersion"`
+		}
+		if err := json.Unmarshal(value, &version); err == nil && version.SchemaVersion == currentSchemaVersion {
+			return nil
+		}
+
+		record, err := decode(value)
+		if err != nil {
+			txLogger(APIstub).warning("migration skipped a record", "key", key, "error", err)
+			run.status.Failed = append(run.status.Failed, key)
+			return nil
+		}
+		recordAsBytes, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		if err := APIstub.PutState(key, recordAsBytes); err != nil {
+			return err
+		}
+		run.status.Upgraded++
+		return nil
+	}
+}
+
+// runMigrationStep processes up to limit records of step after status.Last_key.
+// It reports whether the step has no records left.
+func runMigrationStep(APIstub shim.ChaincodeStubInterface, step migrationStep, run *migrationRun, limit int) (bool, int, error) {
+	resultsIterator, err := step.records(APIstub, run.status.Last_key)
+	if err != nil {
+		return false, 0, err
+	}
+	defer resultsIterator.Close()
+
+	processed := 0
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return false, processed, err
+		}
+		// keys come back in order, everything up to Last_key was done by earlier calls
+		// (only composite ranges return those, see objectRecords)
+		if queryResponse.Key <= run.status.Last_key {
+			continue
+		}
+		if processed >= limit {
+			return false, processed, nil
+		}
+
+		if err := step.migrate(APIstub, queryResponse.Key, queryResponse.Value, run); err != nil {
+			return false, processed, err
+		}
+		run.status.Last_key = queryResponse.Key
+		run.status.Processed++
+		processed++
+	}
+	return true, processed, nil
+}
+
+func getMigrationStatus(APIstub shim.ChaincodeStubInterface) (MigrationStatus, error) {
+	status := MigrationStatus{}
+	key, err := APIstub.CreateCompositeKey(migrationObjectType, []string{"status"})
+	if err != nil {
+		return status, err
+	}
+	statusAsBytes, err := APIstub.GetState(key)
+	if err != nil || statusAsBytes == nil {
+		return status, err
+	}
+	err = json.Unmarshal(statusAsBytes, &status)
+	return status, err
+}
+
+func putMigrationStatus(APIstub shim.ChaincodeStubInterface, status MigrationStatus) error {
+	key, err := APIstub.CreateCompositeKey(migrationObjectType, []string{"status"})
+	if err != nil {
+		return err
+	}
+	statusAsBytes, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	return APIstub.PutState(key, statusAsBytes)
+}
+
+// Migrate rewrites records to the current schema version after a chaincode upgrade
+// (administrators only, see adminTransactions)
+func (s *SmartContract) Migrate(ctx contractapi.TransactionContextInterface, batchSize int) (*MigrationStatus, error) {
+	//'{"Args":["migrate", "0"]}'
+	//'{"Args":["migrate", "500"]}'
+	//batchSize	-- number of records looked at per call (0 for the default of 100)
+
+	if batchSize < 0 {
+		return nil, invalidArgument("Incorrect value. batchSize must not be negative")
+	}
+	if batchSize == 0 {
+		batchSize = defaultMigrateBatchSize
+	}
+
+	APIstub := ctx.GetStub()
+	status, err := getMigrationStatus(APIstub)
+	if err != nil {
+		return nil, err
+	}
+	if status.Target_version != currentSchemaVersion {
+		// first call after an upgrade starts over
+		status = MigrationStatus{Target_version: currentSchemaVersion, Step: migrationSteps[0].name, Duplicates: []string{}, Failed: []string{}}
+	}
+
+	run := migrationRun{status: &status, written: map[string]bool{}}
+	processed := 0
+	upgraded := status.Upgraded
+	for i := 0; i < len(migrationSteps) && !status.Done; i++ {
+		if migrationSteps[i].name != status.Step {
+			continue
+		}
+		if processed >= batchSize {
+			break
+		}
+
+		finished, n, err := runMigrationStep(APIstub, migrationSteps[i], &run, batchSize-processed)
+		if err != nil {
+			return nil, err
+		}
+		processed += n
+		if !finished {
+			break
+		}
+
+		if i+1 < len(migrationSteps) {
+			status.Step = migrationSteps[i+1].name
+			status.Last_key = ""
+		} else {
+			status.Done = true
+		}
+		if status.Upgraded > upgraded {
+			// the legacy steps write the records the object steps read, the
+			// object steps the records the index steps read
+			break
+		}
+	}
+
+	if err := putMigrationStatus(APIstub, status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// MigrationStatus reports the progress of the schema migration
+func (s *SmartContract) MigrationStatus(ctx contractapi.TransactionContextInterface) (*MigrationStatus, error) {
+	//'{"Args":["migrationStatus"]}'
+
+	status, err := getMigrationStatus(ctx.GetStub())
+	if err != nil {
+		return nil, err
+	}
+	if status.Target_version != currentSchemaVersion {
+		status = MigrationStatus{Target_version: currentSchemaVersion, Duplicates: []string{}, Failed: []string{}}
+	}
+	return &status, nil
+}
@@ -0,0 +1,233 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package chaincode
+
+import (
+	"strings"
+)
+
+type Owner struct {
+	SchemaVersion    int      `json:"schemaVersion"`
+	Owner_id         string   `json:"Owner_id"`
+	Owner_nm         string   `json:"Owner_nm"`
+	Owner_addr       string   `json:"Owner_addr"`
+	Livestock        string   `json:"Livestock"`
+	Owner_user_nm    string   `json:"Owner_user_nm"`
+	Owner_user_birth string   `json:"Owner_user_birth"`
+	Biz_no           string   `json:"Biz_no"`
+	Stamp            *Stamp   `json:"Stamp,omitempty" metadata:",optional"`
+	Remarks          []Remark `json:"Remarks,omitempty" metadata:",optional"`
+	// Owner_type is set on registration, with the details of the type if it has any
+	Owner_type     OwnerType              `json:"Owner_type,omitempty" metadata:",optional"`
+	Slaughterhouse *SlaughterhouseDetails `json:"Slaughterhouse,omitempty" metadata:",optional"`
+	Wholesaler     *WholesalerDetails     `json:"Wholesaler,omitempty" metadata:",optional"`
+	Importer       *ImporterDetails       `json:"Importer,omitempty" metadata:",optional"`
+	Restaurant     *RestaurantDetails     `json:"Restaurant,omitempty" metadata:",optional"`
+	// storedRemarks is the number of remarks read from the ledger, the ones after it are new
+	storedRemarks int
+}
+
+// Define the cow structure.  Structure tags are used by encoding/json library
+type Cow struct {
+	SchemaVersion int      `json:"schemaVersion"`
+	Id_no         string   `json:"Id_no"`
+	Birth_date    string   `json:"Birth_date"`
+	Sex           string   `json:"Sex"`
+	Father_id     string   `json:"Father_id"`
+	Mother_id     string   `json:"Mother_id"`
+	Origin        string   `json:"Origin"`
+	Owner         Owner    `json:"Owner"`
+	Dead          bool     `json:"Dead,omitempty" metadata:",optional"`
+	Archived      *Archive `json:"Archived,omitempty" metadata:",optional"`
+	Stamp         *Stamp   `json:"Stamp,omitempty" metadata:",optional"`
+	Remarks       []Remark `json:"Remarks,omitempty" metadata:",optional"`
+	storedRemarks int
+}
+
+type HACCP struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Farm_id       string `json:"Farm_id"`
+	Farm_nm       string `json:"Farm_nm"`
+	Farm_addr     string `json:"Farm_addr"`
+	Apply_item    string `json:"Apply_item"`
+	Validity_date string `json:"Validity_date"`
+	Stamp         *Stamp `json:"Stamp,omitempty" metadata:",optional"`
+}
+
+type RFID struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Id_no         string `json:"Id_no"`
+	Rfid_no       string `json:"Rfid_no"`
+	Stamp         *Stamp `json:"Stamp,omitempty" metadata:",optional"`
+}
+
+type Bundle struct {
+	SchemaVersion   int    `json:"schemaVersion"`
+	Id_no           string `json:"Id_no"`
+	Barcode_id      string `json:"Barcode_id"`
+	Package_date    string `json:"Package_date"`
+	Part            string `json:"Part"`
+	Weight          string `json:"Weight"`
+	Purchase_nm     string `json:"Purchase_nm"`
+	Purchase_biz_no string `json:"Purchase_biz_no"`
+	// Cold_chain holds the temperature and humidity breaches of the bundle, see coldchain.go
+	Cold_chain *ColdChain `json:"Cold_chain,omitempty" metadata:",optional"`
+	Stamp      *Stamp     `json:"Stamp,omitempty" metadata:",optional"`
+}
+
+// SlaughterhouseDetails, WholesalerDetails, ImporterDetails and RestaurantDetails
+// are the fields only owners of that type have, see validateOwner
+type SlaughterhouseDetails struct {
+	Tel    string `json:"Tel"`
+	Reg_no string `json:"Reg_no"`
+}
+
+type WholesalerDetails struct {
+	Tel string `json:"Tel"`
+	// Market is the wholesale market the wholesaler trades at
+	Market string `json:"Market"`
+}
+
+type ImporterDetails struct {
+	Tel string `json:"Tel"`
+	// Import_reg_no is the registration of the meat import business
+	Import_reg_no string `json:"Import_reg_no"`
+	// Origin_countries are the countries the importer brings cattle or beef from
+	Origin_countries []string `json:"Origin_countries"`
+}
+
+type RestaurantDetails struct {
+	Tel string `json:"Tel"`
+	// License_no is the food service business license
+	License_no string `json:"License_no"`
+}
+
+// Archive records who archived a cow, when and why
+type Archive struct {
+	Reason      string `json:"Reason"`
+	Archived_at string `json:"Archived_at"`
+	Msp_id      string `json:"Msp_id"`
+	Subject     string `json:"Subject"`
+}
+
+// Stamp is the transaction that wrote a record or a remark and who submitted it
+type Stamp struct {
+	Tx_id   string `json:"Tx_id"`
+	Tx_time string `json:"Tx_time"`
+	Msp_id  string `json:"Msp_id"`
+	Subject string `json:"Subject"`
+}
+
+type Remark struct {
+	Key   string `json:"Key"`
+	Value string `json:"Value"`
+	Stamp *Stamp `json:"Stamp,omitempty" metadata:",optional"`
+}
+
+// CowQueryResult and OwnerQueryResult are the elements returned by queryAllCows and queryAllOwners
+type CowQueryResult struct {
+	Key    string `json:"Key"`
+	Record Cow    `json:"Record"`
+}
+
+type OwnerQueryResult struct {
+	Key    string `json:"Key"`
+	Record Owner  `json:"Record"`
+}
+
+// stamp marks a record and its new remarks as written by the transaction of stamp
+func (cow *Cow) stamp(stamp Stamp) {
+	cow.Stamp = &stamp
+	for i := cow.storedRemarks; i < len(cow.Remarks); i++ {
+		cow.Remarks[i].Stamp = &stamp
+	}
+}
+
+func (owner *Owner) stamp(stamp Stamp) {
+	owner.Stamp = &stamp
+	for i := owner.storedRemarks; i < len(owner.Remarks); i++ {
+		owner.Remarks[i].Stamp = &stamp
+	}
+}
+
+func (haccp *HACCP) stamp(stamp Stamp) {
+	haccp.Stamp = &stamp
+}
+
+func (rfid *RFID) stamp(stamp Stamp) {
+	rfid.Stamp = &stamp
+}
+
+func (bundle *Bundle) stamp(stamp Stamp) {
+	bundle.Stamp = &stamp
+}
+
+func (cow *Cow) setCowRemark(remark1 Remark) []Remark {
+	cow.Remarks = append(cow.Remarks, remark1)
+	return cow.Remarks
+}
+
+func (owner *Owner) setOwnerRemark(remark1 Remark) []Remark {
+	owner.Remarks = append(owner.Remarks, remark1)
+	return owner.Remarks
+}
+
+// setCowRemarks appends one remark per variable, values are matched by position
+func (cow *Cow) setCowRemarks(variables []string, values []string) {
+	for i := 0; i < len(variables); i++ {
+		cow.setCowRemark(Remark{Key: variables[i], Value: values[i]})
+	}
+}
+
+func (owner *Owner) setOwnerRemarks(variables []string, values []string) {
+	for i := 0; i < len(variables); i++ {
+		owner.setOwnerRemark(Remark{Key: variables[i], Value: values[i]})
+	}
+}
+
+// remarkEvents splits the remarks an addInfo* style transaction left on a cow into
+// one field map per call, oldest first. function is the remark prefix ("addFAMDVaccine").
+func remarkEvents(remarks []Remark, function string) []map[string]string {
+	prefix := function + "."
+	events := []map[string]string{}
+	var event map[string]string
+	for _, remark := range remarks {
+		if !strings.HasPrefix(remark.Key, prefix) {
+			continue
+		}
+		field := strings.TrimPrefix(remark.Key, prefix)
+		// every call writes all its fields, so a field seen again starts the next call
+		if _, ok := event[field]; ok || event == nil {
+			event = map[string]string{}
+			events = append(events, event)
+		}
+		event[field] = remark.Value
+	}
+	return events
+}
+
+// lastRemarkEvent is the most recent call of function, nil if there was none
+func lastRemarkEvent(remarks []Remark, function string) map[string]string {
+	events := remarkEvents(remarks, function)
+	if len(events) == 0 {
+		return nil
+	}
+	return events[len(events)-1]
+}
@@ -0,0 +1,306 @@
+package chaincode
+
+import (
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// RegisterFarm registers a farm, the farm id is its business number
+func (s *SmartContract) RegisterFarm(ctx contractapi.TransactionContextInterface, legacyKey string, farmId string, farmNm string, farmAddr string, livestock string, farmUserNm string, farmUserBirth string) error {
+	//'{"Args":["registerFarm","OWNER0", "FARM0", "ChukLim1", "Iksan", "C", "Kim Duck Bae", "530118"]}'
+	//legacyKey			-- legacy OWNER key, kept as an alias (may be empty)
+	//farmId			-- farm id
+	//farmNm			-- farm name
+	//farmAddr			-- farm address
+	//livestock			-- livestock kind
+	//farmUserNm		-- farm manager name
+	//farmUserBirth		-- farm manager birth date
+
+	return s.RegisterFarmJSON(ctx, FarmPayload{Legacy_key: legacyKey, Farm_id: farmId, Farm_nm: farmNm, Farm_addr: farmAddr, Livestock: livestock, Farm_user_nm: farmUserNm, Farm_user_birth: farmUserBirth})
+}
+
+// RegisterFarmJSON is registerFarm with named fields
+func (s *SmartContract) RegisterFarmJSON(ctx contractapi.TransactionContextInterface, payload FarmPayload) error {
+	var owner = Owner{SchemaVersion: currentSchemaVersion, Owner_type: OwnerTypeFarm, Owner_id: payload.Farm_id, Owner_nm: payload.Farm_nm, Owner_addr: payload.Farm_addr, Livestock: payload.Livestock, Owner_user_nm: payload.Farm_user_nm, Owner_user_birth: payload.Farm_user_birth, Biz_no: payload.Farm_id}
+
+	return s.putOwner(ctx, payload.Legacy_key, owner)
+}
+
+// RegisterSlaughterhouse registers a slaughterhouse under its business registration number
+func (s *SmartContract) RegisterSlaughterhouse(ctx contractapi.TransactionContextInterface, legacyKey string, slaughterId string, slaughterNm string, slaughterAddr string, handleLivestock string, slaughterUserNm string, slaughterUserBirth string, slaughterTel string, slaughterRegNo string) error {
+	//'{"Args":["registerSlaughterhouse","OWNER1", "SLAUGHTER0", "Iksan Slaughterhouse", "Iksan", "063-000-0000", "Park", "600101", "063-000-0000", "409-81-00000"]}'
+	//slaughterId			-- slaughterhouse id
+	//slaughterNm			-- slaughterhouse name
+	//slaughterAddr			-- slaughterhouse address
+	//handleLivestock		-- livestock handled
+	//slaughterUserNm		-- manager name
+	//slaughterUserBirth	-- manager birth date
+	//slaughterTel			-- phone number
+	//slaughterRegNo		-- business registration number
+
+	return s.RegisterSlaughterhouseJSON(ctx, SlaughterhousePayload{Legacy_key: legacyKey, Slaughter_id: slaughterId, Slaughter_nm: slaughterNm, Slaughter_addr: slaughterAddr, Handle_livestock: handleLivestock, Slaughter_user_nm: slaughterUserNm, Slaughter_user_birth: slaughterUserBirth, Slaughter_tel: slaughterTel, Slaughter_reg_no: slaughterRegNo})
+}
+
+// RegisterSlaughterhouseJSON is registerSlaughterhouse with named fields
+func (s *SmartContract) RegisterSlaughterhouseJSON(ctx contractapi.TransactionContextInterface, payload SlaughterhousePayload) error {
+	var owner = Owner{SchemaVersion: currentSchemaVersion, Owner_type: OwnerTypeSlaughterhouse, Owner_id: payload.Slaughter_id, Owner_nm: payload.Slaughter_nm, Owner_addr: payload.Slaughter_addr, Livestock: payload.Handle_livestock, Owner_user_nm: payload.Slaughter_user_nm, Owner_user_birth: payload.Slaughter_user_birth, Biz_no: payload.Slaughter_reg_no}
+	owner.Slaughterhouse = &SlaughterhouseDetails{Tel: payload.Slaughter_tel, Reg_no: payload.Slaughter_reg_no}
+
+	return s.putOwner(ctx, payload.Legacy_key, owner)
+}
+
+// RegisterProcessor registers a meat processor under its business registration number
+func (s *SmartContract) RegisterProcessor(ctx contractapi.TransactionContextInterface, legacyKey string, processId string, processNm string, processAddr string, livestock string, processUserNm string, processUserBirth string, processBizNo string) error {
+	//'{"Args":["registerProcessor","OWNER2", "PROCESS0", "Jeonju Meat", "Jeonju", "Empty", "Lee", "Empty", "402-81-00000"]}'
+	//processId			-- processor id
+	//processNm			-- company name
+	//processAddr		-- company address
+	//processUserNm		-- representative name
+	//processBizNo		-- business registration number
+
+	return s.RegisterProcessorJSON(ctx, ProcessorPayload{Legacy_key: legacyKey, Process_id: processId, Process_nm: processNm, Process_addr: processAddr, Livestock: livestock, Process_user_nm: processUserNm, Process_user_birth: processUserBirth, Process_biz_no: processBizNo})
+}
+
+// RegisterProcessorJSON is registerProcessor with named fields
+func (s *SmartContract) RegisterProcessorJSON(ctx contractapi.TransactionContextInterface, payload ProcessorPayload) error {
+	var owner = Owner{SchemaVersion: currentSchemaVersion, Owner_type: OwnerTypeProcessor, Owner_id: payload.Process_id, Owner_nm: payload.Process_nm, Owner_addr: payload.Process_addr, Livestock: payload.Livestock, Owner_user_nm: payload.Process_user_nm, Owner_user_birth: payload.Process_user_birth, Biz_no: payload.Process_biz_no}
+
+	return s.putOwner(ctx, payload.Legacy_key, owner)
+}
+
+// RegisterSeller registers a meat seller under its business registration number
+func (s *SmartContract) RegisterSeller(ctx contractapi.TransactionContextInterface, legacyKey string, saleId string, saleNm string, saleAddr string, livestock string, saleUserNm string, saleUserBirth string, saleBizNo string) error {
+	//'{"Args":["registerSeller","OWNER3", "SALE0", "Daejeon Butcher", "Daejeon", "Empty", "Choi", "Empty", "305-81-00000"]}'
+	//saleId			-- seller id
+	//saleNm			-- shop name
+	//saleAddr			-- shop address
+	//saleUserNm		-- representative name
+	//saleBizNo			-- business registration number
+
+	return s.RegisterSellerJSON(ctx, SellerPayload{Legacy_key: legacyKey, Sale_id: saleId, Sale_nm: saleNm, Sale_addr: saleAddr, Livestock: livestock, Sale_user_nm: saleUserNm, Sale_user_birth: saleUserBirth, Sale_biz_no: saleBizNo})
+}
+
+// RegisterSellerJSON is registerSeller with named fields
+func (s *SmartContract) RegisterSellerJSON(ctx contractapi.TransactionContextInterface, payload SellerPayload) error {
+	var owner = Owner{SchemaVersion: currentSchemaVersion, Owner_type: OwnerTypeSeller, Owner_id: payload.Sale_id, Owner_nm: payload.Sale_nm, Owner_addr: payload.Sale_addr, Livestock: payload.Livestock, Owner_user_nm: payload.Sale_user_nm, Owner_user_birth: payload.Sale_user_birth, Biz_no: payload.Sale_biz_no}
+
+	return s.putOwner(ctx, payload.Legacy_key, owner)
+}
+
+// RegisterWholesaler registers a meat wholesaler under its business registration number
+func (s *SmartContract) RegisterWholesaler(ctx contractapi.TransactionContextInterface, legacyKey string, wholesaleId string, wholesaleNm string, wholesaleAddr string, livestock string, wholesaleUserNm string, wholesaleUserBirth string, wholesaleBizNo string, wholesaleTel string, market string) error {
+	//'{"Args":["registerWholesaler","", "WHOLESALE0", "Garak Meat", "Seoul", "C", "Jung", "Empty", "211-81-00000", "02-000-0000", "Garak market"]}'
+	//wholesaleId		-- wholesaler id
+	//wholesaleNm		-- company name
+	//wholesaleAddr		-- company address
+	//wholesaleUserNm	-- representative name
+	//wholesaleBizNo	-- business registration number
+	//wholesaleTel		-- phone number
+	//market			-- wholesale market traded at
+
+	return s.RegisterWholesalerJSON(ctx, WholesalerPayload{Legacy_key: legacyKey, Wholesale_id: wholesaleId, Wholesale_nm: wholesaleNm, Wholesale_addr: wholesaleAddr, Livestock: livestock, Wholesale_user_nm: wholesaleUserNm, Wholesale_user_birth: wholesaleUserBirth, Wholesale_biz_no: wholesaleBizNo, Wholesale_tel: wholesaleTel, Market: market})
+}
+
+// RegisterWholesalerJSON is registerWholesaler with named fields
+func (s *SmartContract) RegisterWholesalerJSON(ctx contractapi.TransactionContextInterface, payload WholesalerPayload) error {
+	var owner = Owner{SchemaVersion: currentSchemaVersion, Owner_type: OwnerTypeWholesaler, Owner_id: payload.Wholesale_id, Owner_nm: payload.Wholesale_nm, Owner_addr: payload.Wholesale_addr, Livestock: payload.Livestock, Owner_user_nm: payload.Wholesale_user_nm, Owner_user_birth: payload.Wholesale_user_birth, Biz_no: payload.Wholesale_biz_no}
+	owner.Wholesaler = &WholesalerDetails{Tel: payload.Wholesale_tel, Market: payload.Market}
+
+	return s.putOwner(ctx, payload.Legacy_key, owner)
+}
+
+// RegisterImporter registers a cattle or beef importer under its business registration number
+func (s *SmartContract) RegisterImporter(ctx contractapi.TransactionContextInterface, legacyKey string, importId string, importNm string, importAddr string, livestock string, importUserNm string, importUserBirth string, importBizNo string, importTel string, importRegNo string, originCountries string) error {
+	//'{"Args":["registerImporter","", "IMPORT0", "Incheon Trading", "Incheon", "C", "Han", "Empty", "121-81-00000", "032-000-0000", "IMP-2019-001", "AU,US"]}'
+	//importId			-- importer id
+	//importNm			-- company name
+	//importAddr		-- company address
+	//importUserNm		-- representative name
+	//importBizNo		-- business registration number
+	//importTel			-- phone number
+	//importRegNo		-- meat import business registration
+	//originCountries	-- countries imported from, comma separated
+
+	countries := []string{}
+	for _, country := range strings.Split(originCountries, ",") {
+		if country = strings.TrimSpace(country); country != "" {
+			countries = append(countries, country)
+		}
+	}
+
+	return s.RegisterImporterJSON(ctx, ImporterPayload{Legacy_key: legacyKey, Import_id: importId, Import_nm: importNm, Import_addr: importAddr, Livestock: livestock, Import_user_nm: importUserNm, Import_user_birth: importUserBirth, Import_biz_no: importBizNo, Import_tel: importTel, Import_reg_no: importRegNo, Origin_countries: countries})
+}
+
+// RegisterImporterJSON is registerImporter with named fields
+func (s *SmartContract) RegisterImporterJSON(ctx contractapi.TransactionContextInterface, payload ImporterPayload) error {
+	var owner = Owner{SchemaVersion: currentSchemaVersion, Owner_type: OwnerTypeImporter, Owner_id: payload.Import_id, Owner_nm: payload.Import_nm, Owner_addr: payload.Import_addr, Livestock: payload.Livestock, Owner_user_nm: payload.Import_user_nm, Owner_user_birth: payload.Import_user_birth, Biz_no: payload.Import_biz_no}
+	owner.Importer = &ImporterDetails{Tel: payload.Import_tel, Import_reg_no: payload.Import_reg_no, Origin_countries: payload.Origin_countries}
+
+	return s.putOwner(ctx, payload.Legacy_key, owner)
+}
+
+// RegisterRestaurant registers a restaurant under its business registration number
+func (s *SmartContract) RegisterRestaurant(ctx contractapi.TransactionContextInterface, legacyKey string, restaurantId string, restaurantNm string, restaurantAddr string, livestock string, restaurantUserNm string, restaurantUserBirth string, restaurantBizNo string, restaurantTel string, licenseNo string) error {
+	//'{"Args":["registerRestaurant","", "RESTAURANT0", "Hanu House", "Jeonju", "C", "Yoon", "Empty", "418-81-00000", "063-000-0000", "2019-0001"]}'
+	//restaurantId		-- restaurant id
+	//restaurantNm		-- restaurant name
+	//restaurantAddr	-- restaurant address
+	//restaurantUserNm	-- representative name
+	//restaurantBizNo	-- business registration number
+	//restaurantTel		-- phone number
+	//licenseNo			-- food service business license
+
+	return s.RegisterRestaurantJSON(ctx, RestaurantPayload{Legacy_key: legacyKey, Restaurant_id: restaurantId, Restaurant_nm: restaurantNm, Restaurant_addr: restaurantAddr, Livestock: livestock, Restaurant_user_nm: restaurantUserNm, Restaurant_user_birth: restaurantUserBirth, Restaurant_biz_no: restaurantBizNo, Restaurant_tel: restaurantTel, License_no: licenseNo})
+}
+
+// RegisterRestaurantJSON is registerRestaurant with named fields
+func (s *SmartContract) RegisterRestaurantJSON(ctx contractapi.TransactionContextInterface, payload RestaurantPayload) error {
+	var owner = Owner{SchemaVersion: currentSchemaVersion, Owner_type: OwnerTypeRestaurant, Owner_id: payload.Restaurant_id, Owner_nm: payload.Restaurant_nm, Owner_addr: payload.Restaurant_addr, Livestock: payload.Livestock, Owner_user_nm: payload.Restaurant_user_nm, Owner_user_birth: payload.Restaurant_user_birth, Biz_no: payload.Restaurant_biz_no}
+	owner.Restaurant = &RestaurantDetails{Tel: payload.Restaurant_tel, License_no: payload.License_no}
+
+	return s.putOwner(ctx, payload.Legacy_key, owner)
+}
+
+// registerOwner is the legacy registration of the four original owner types, the
+// type is taken from the id in args[1]. The other types only have their own
+// registrations.
+func (s *SmartContract) registerOwner(ctx contractapi.TransactionContextInterface, args []string) error {
+	//'{"Args":["registerOwner","OWNER0", "FARM0", "ChukLim1", "Iksan", "C", "Kim Duck Bae", "530118"]}'
+	//args[0]		-- legacy OWNER key
+	//args[1..6]	-- id, name, address, livestock, manager name, manager birth date
+	//SLAUGHTER		-- args[7] slaughter_tel, args[8] slaughter_reg_no
+	//PROCESS		-- args[7] process_biz_no
+	//SALE			-- args[7] sale_biz_no
+
+	if len(args) < 2 {
+		return invalidArgument("Incorrect number of arguments. Expecting at least 2")
+	}
+
+	switch legacyOwnerType(args[1]) {
+	case OwnerTypeFarm:
+		if len(args) != 7 {
+			return invalidArgument("Incorrect number of arguments. Expecting 7")
+		}
+		return s.RegisterFarm(ctx, args[0], args[1], args[2], args[3], args[4], args[5], args[6])
+	case OwnerTypeSlaughterhouse:
+		if len(args) != 9 {
+			return invalidArgument("Incorrect number of arguments. Expecting 9")
+		}
+		return s.RegisterSlaughterhouse(ctx, args[0], args[1], args[2], args[3], args[4], args[5], args[6], args[7], args[8])
+	case OwnerTypeProcessor:
+		if len(args) != 8 {
+			return invalidArgument("Incorrect number of arguments. Expecting 8")
+		}
+		return s.RegisterProcessor(ctx, args[0], args[1], args[2], args[3], args[4], args[5], args[6], args[7])
+	case OwnerTypeSeller:
+		if len(args) != 8 {
+			return invalidArgument("Incorrect number of arguments. Expecting 8")
+		}
+		return s.RegisterSeller(ctx, args[0], args[1], args[2], args[3], args[4], args[5], args[6], args[7])
+	}
+
+	return invalidArgument("Unknown owner type: %s", args[1])
+}
+
+// putOwner stores a new owner under the key derived from its business number
+func (s *SmartContract) putOwner(ctx contractapi.TransactionContextInterface, legacyKey string, owner Owner) error {
+	APIstub := ctx.GetStub()
+
+	if err := validateOwner(owner); err != nil {
+		return err
+	}
+
+	key, err := makeOwnerKey(APIstub, owner.Biz_no)
+	if err != nil {
+		return err
+	}
+	if err := assertNotExists(APIstub, key, "Owner "+owner.Biz_no); err != nil {
+		return err
+	}
+
+	if err := putRecord(APIstub, key, &owner); err != nil {
+		return err
+	}
+	if err := putOwnerTypeIndex(APIstub, owner); err != nil {
+		return err
+	}
+
+	return putAlias(APIstub, legacyKey, key)
+}
+
+// RegisterHACCP stores a HACCP certificate and notes it on the owner
+func (s *SmartContract) RegisterHACCP(ctx contractapi.TransactionContextInterface, haccpKey string, ownerRef string, farmId string, farmNm string, farmAddr string, applyItem string, validityDate string) error {
+	//'{"Args":["registerHACCP","HACCP0", "OWNER10","FARM0", "ChukLim1", "Iksan", "Cow", "20280528"]}'
+	//haccpKey		-- HACCP key, starting with HACCP
+	//ownerRef		-- owner
+	//farmId		-- farm id
+	//farmNm		-- farm name
+	//farmAddr		-- farm address
+	//applyItem		-- certified item
+	//validityDate	-- valid until
+
+	return s.RegisterHACCPJSON(ctx, HACCPPayload{Haccp: haccpKey, Owner: ownerRef, Farm_id: farmId, Farm_nm: farmNm, Farm_addr: farmAddr, Apply_item: applyItem, Validity_date: validityDate})
+}
+
+// RegisterHACCPJSON is registerHACCP with named fields
+func (s *SmartContract) RegisterHACCPJSON(ctx contractapi.TransactionContextInterface, payload HACCPPayload) error {
+	APIstub := ctx.GetStub()
+
+	key, owner, err := getOwner(APIstub, payload.Owner)
+	if err != nil {
+		return err
+	}
+
+	// HACCP records are stored under the keys clients pass, the prefix keeps
+	// them off the keys of every other record and is what the migration reads
+	if !strings.HasPrefix(payload.Haccp, "HACCP") {
+		return invalidArgument("Incorrect HACCP key %q, expecting a key starting with HACCP", payload.Haccp)
+	}
+	if err := assertNotExists(APIstub, payload.Haccp, "HACCP "+payload.Haccp); err != nil {
+		return err
+	}
+
+	var haccp = HACCP{SchemaVersion: currentSchemaVersion, Farm_id: payload.Farm_id, Farm_nm: payload.Farm_nm, Farm_addr: payload.Farm_addr, Apply_item: payload.Apply_item, Validity_date: payload.Validity_date}
+	if err := putRecord(APIstub, payload.Haccp, &haccp); err != nil {
+		return err
+	}
+
+	variables := []string{"haccp.Id_no", "haccp.Rfid_no"}
+	owner.setOwnerRemarks(variables, []string{payload.Farm_id, payload.Farm_nm})
+
+	return putRecord(APIstub, key, &owner)
+}
+
+// AddAut notes an eco-friendly livestock farm certification on the owner
+func (s *SmartContract) AddAut(ctx contractapi.TransactionContextInterface, ownerRef string, autFalg string, validityDate string, farmNm string, farmBirthDate string, farmAddr string, bizAddr string, autItem string, breedHead string, autCom string, autId string, autDate string) error {
+	//'{"Args":["addAut","OWNER90", "AutCheck", "201905251610", "ChukLim1", "1985.10.27", "Iksan", "Jeon Buk", "Cow", "30", "NAQS", "10001", "20180525"]}'
+	//autFalg			-- certified
+	//validityDate		-- valid until
+	//farmNm			-- farm manager name
+	//farmBirthDate		-- farm manager birth date
+	//farmAddr			-- farm address
+	//bizAddr			-- business address
+	//autItem			-- certified item
+	//breedHead			-- number of head
+	//autCom			-- certifying body
+	//autId				-- certificate number
+	//autDate			-- certification date
+
+	return s.AddAutJSON(ctx, AutPayload{Owner: ownerRef, Aut_falg: autFalg, Validity_date: validityDate, Farm_nm: farmNm, Farm_birth_date: farmBirthDate, Farm_addr: farmAddr, Biz_addr: bizAddr, Aut_item: autItem, Breed_head: breedHead, Aut_com: autCom, Aut_id: autId, Aut_date: autDate})
+}
+
+// AddAutJSON is addAut with named fields
+func (s *SmartContract) AddAutJSON(ctx contractapi.TransactionContextInterface, payload AutPayload) error {
+	APIstub := ctx.GetStub()
+
+	key, owner, err := getOwner(APIstub, payload.Owner)
+	if err != nil {
+		return err
+	}
+
+	variables := []string{"aut_falg", "validity_date", "farm_nm", "farm_birth_date", "farm_addr", "biz_addr", "aut_item", "breed_head", "aut_com", "aut_id", "aut_date"}
+	owner.setOwnerRemarks(variables, []string{payload.Aut_falg, payload.Validity_date, payload.Farm_nm, payload.Farm_birth_date, payload.Farm_addr, payload.Biz_addr, payload.Aut_item, payload.Breed_head, payload.Aut_com, payload.Aut_id, payload.Aut_date})
+
+	return putRecord(APIstub, key, &owner)
+}
@@ -0,0 +1,212 @@
+package chaincode
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// OwnerType is the kind of business an owner is. Every registration sets it,
+// owners registered before it existed get it from their id on upgrade (see
+// upgradeOwner). Owners are indexed under ("ownertype", [Owner_type, Biz_no]).
+type OwnerType string
+
+const (
+	OwnerTypeFarm           OwnerType = "FARM"
+	OwnerTypeSlaughterhouse OwnerType = "SLAUGHTERHOUSE"
+	OwnerTypeProcessor      OwnerType = "PROCESSOR"
+	OwnerTypeSeller         OwnerType = "SELLER"
+	OwnerTypeWholesaler     OwnerType = "WHOLESALER"
+	OwnerTypeImporter       OwnerType = "IMPORTER"
+	OwnerTypeRestaurant     OwnerType = "RESTAURANT"
+
+	ownerTypeIndexObjectType = "ownertype"
+)
+
+var ownerTypes = []OwnerType{OwnerTypeFarm, OwnerTypeSlaughterhouse, OwnerTypeProcessor, OwnerTypeSeller, OwnerTypeWholesaler, OwnerTypeImporter, OwnerTypeRestaurant}
+
+// legacyOwnerIds are the markers registerOwner looked for in the owner id, in the order it checked them
+var legacyOwnerIds = []struct {
+	marker    string
+	ownerType OwnerType
+}{
+	{"FARM", OwnerTypeFarm},
+	{"SLAUGHTER", OwnerTypeSlaughterhouse},
+	{"PROCESS", OwnerTypeProcessor},
+	{"SALE", OwnerTypeSeller},
+}
+
+var (
+	// bizNoPattern is a business registration number, 123-45-67890
+	bizNoPattern = regexp.MustCompile(`^[0-9]{3}-?[0-9]{2}-?[0-9]{5}$`)
+	// legacyBizNoPattern is the business number registerOwner clients have always sent, 1-7474-8700
+	legacyBizNoPattern = regexp.MustCompile(`^[0-9]-[0-9]{4}-[0-9]{4}$`)
+	telPattern         = regexp.MustCompile(`^\+?[0-9][0-9 -]{5,18}[0-9]$`)
+)
+
+// QueryOwnersByType returns every owner of a type with its Biz_no as the key
+func (s *SmartContract) QueryOwnersByType(ctx contractapi.TransactionContextInterface, ownerType string) ([]OwnerQueryResult, error) {
+	//'{"Args":["queryOwnersByType", "SLAUGHTERHOUSE"]}'
+	//ownerType	-- FARM, SLAUGHTERHOUSE, PROCESSOR, SELLER, WHOLESALER, IMPORTER or RESTAURANT
+
+	APIstub := ctx.GetStub()
+
+	parsed, err := parseOwnerType(ownerType)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := APIstub.GetStateByPartialCompositeKey(ownerTypeIndexObjectType, []string{string(parsed)})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	results := []OwnerQueryResult{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, keyParts, err := APIstub.SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+		_, owner, err := getOwner(APIstub, keyParts[1])
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, OwnerQueryResult{Key: owner.Biz_no, Record: owner})
+	}
+
+	return results, nil
+}
+
+// parseOwnerType accepts the owner type names in any case
+func parseOwnerType(name string) (OwnerType, error) {
+	ownerType := OwnerType(strings.ToUpper(strings.TrimSpace(name)))
+	for _, known := range ownerTypes {
+		if ownerType == known {
+			return ownerType, nil
+		}
+	}
+	return "", invalidArgument("Unknown owner type: %s", name)
+}
+
+// legacyOwnerType is the type registerOwner derived from an owner id, empty if the id has no marker
+func legacyOwnerType(ownerId string) OwnerType {
+	for _, legacy := range legacyOwnerIds {
+		if strings.Contains(ownerId, legacy.marker) {
+			return legacy.ownerType
+		}
+	}
+	return ""
+}
+
+// validateOwner checks the fields of a new owner against its type
+func validateOwner(owner Owner) error {
+	if _, err := parseOwnerType(string(owner.Owner_type)); err != nil {
+		return err
+	}
+	// checked in this order, so every peer reports the same missing field
+	for _, field := range []struct{ name, value string }{{"id", owner.Owner_id}, {"name", owner.Owner_nm}, {"address", owner.Owner_addr}} {
+		if strings.TrimSpace(field.value) == "" {
+			return invalidArgument("Incorrect value. The %s of an owner must not be empty", field.name)
+		}
+	}
+
+	switch owner.Owner_type {
+	case OwnerTypeFarm:
+		// farms are keyed by their farm identification number, not a business number
+		if strings.TrimSpace(owner.Livestock) == "" {
+			return invalidArgument("Incorrect value. livestock of a farm must not be empty")
+		}
+		return nil
+	case OwnerTypeSlaughterhouse:
+		if owner.Slaughterhouse == nil {
+			return invalidArgument("Incorrect value. A slaughterhouse needs its details")
+		}
+		if strings.TrimSpace(owner.Slaughterhouse.Reg_no) == "" {
+			return invalidArgument("Incorrect value. slaughter_reg_no must not be empty")
+		}
+		if err := validateTel(owner.Slaughterhouse.Tel); err != nil {
+			return err
+		}
+	case OwnerTypeWholesaler:
+		if owner.Wholesaler == nil {
+			return invalidArgument("Incorrect value. A wholesaler needs its details")
+		}
+		if err := validateTel(owner.Wholesaler.Tel); err != nil {
+			return err
+		}
+	case OwnerTypeImporter:
+		if owner.Importer == nil {
+			return invalidArgument("Incorrect value. An importer needs its details")
+		}
+		if strings.TrimSpace(owner.Importer.Import_reg_no) == "" {
+			return invalidArgument("Incorrect value. import_reg_no must not be empty")
+		}
+		if len(owner.Importer.Origin_countries) == 0 {
+			return invalidArgument("Incorrect value. origin_countries must name at least one country")
+		}
+		if err := validateTel(owner.Importer.Tel); err != nil {
+			return err
+		}
+	case OwnerTypeRestaurant:
+		if owner.Restaurant == nil {
+			return invalidArgument("Incorrect value. A restaurant needs its details")
+		}
+		if strings.TrimSpace(owner.Restaurant.License_no) == "" {
+			return invalidArgument("Incorrect value. license_no must not be empty")
+		}
+		if err := validateTel(owner.Restaurant.Tel); err != nil {
+			return err
+		}
+	}
+
+	// every business but a farm is keyed by its business registration number
+	if !bizNoPattern.MatchString(owner.Biz_no) && !legacyBizNoPattern.MatchString(owner.Biz_no) {
+		return invalidArgument("Incorrect value. %s is not a business registration number (123-45-67890)", owner.Biz_no)
+	}
+	return nil
+}
+
+func validateTel(tel string) error {
+	if !telPattern.MatchString(tel) {
+		return invalidArgument("Incorrect value. %s is not a phone number", tel)
+	}
+	return nil
+}
+
+// putOwnerTypeIndex indexes owner under its type, owners without a type are not indexed
+func putOwnerTypeIndex(APIstub shim.ChaincodeStubInterface, owner Owner) error {
+	if owner.Owner_type == "" {
+		return nil
+	}
+	key, err := APIstub.CreateCompositeKey(ownerTypeIndexObjectType, []string{string(owner.Owner_type), owner.Biz_no})
+	if err != nil {
+		return err
+	}
+	// an empty value would delete the key
+	return APIstub.PutState(key, []byte{0x00})
+}
+
+// indexOwnerTypes is the migration of owners registered before owners were indexed by type
+func indexOwnerTypes(APIstub shim.ChaincodeStubInterface, key string, value []byte, run *migrationRun) error {
+	owner := Owner{}
+	if err := decodeOwner(value, &owner); err != nil {
+		txLogger(APIstub).warning("migration skipped a record", "key", key, "error", err)
+		run.status.Failed = append(run.status.Failed, key)
+		return nil
+	}
+	if owner.Owner_type == "" {
+		return nil
+	}
+	if err := putOwnerTypeIndex(APIstub, owner); err != nil {
+		return err
+	}
+	run.status.Upgraded++
+	return nil
+}
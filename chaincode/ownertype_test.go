@@ -0,0 +1,80 @@
+package chaincode_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lotty02cho/fabcow-test/chaincode"
+)
+
+func TestOwnerTypes(t *testing.T) {
+	c := newContract(t)
+	c.registerFarm("", "FARM0")
+	c.submit("registerOwner", "OWNER1", "SLAUGHTER0", "Iksan Meat", "Iksan", "C", "Choi", "Empty", "063-000-0000", "1-7474-8700")
+	c.submit("registerOwner", "OWNER2", "PROCESS0", "Daejeon Butcher", "Daejeon", "C", "Park", "Empty", "305-81-00000")
+	c.submit("registerWholesaler", "", "WHOLESALE0", "Garak Meat", "Seoul", "C", "Jung", "Empty", "211-81-00000", "02-000-0000", "Garak market")
+	c.submit("registerImporter", "", "IMPORT0", "Incheon Trading", "Incheon", "C", "Han", "Empty", "121-81-00000", "032-000-0000", "IMP-2019-001", "AU, US")
+
+	tests := []struct {
+		ownerType string
+		want      []string
+	}{
+		{"FARM", []string{"FARM0"}},
+		{"SLAUGHTERHOUSE", []string{"1-7474-8700"}},
+		{"processor", []string{"305-81-00000"}},
+		{"WHOLESALER", []string{"211-81-00000"}},
+		{"IMPORTER", []string{"121-81-00000"}},
+		{"RESTAURANT", []string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.ownerType, func(t *testing.T) {
+			result := c.ledger.Evaluate(c.farmer, "queryOwnersByType", test.ownerType)
+			if err := result.Err(); err != nil {
+				t.Fatal(err)
+			}
+			owners := []chaincode.OwnerQueryResult{}
+			if err := json.Unmarshal(result.Payload, &owners); err != nil {
+				t.Fatal(err)
+			}
+			keys := []string{}
+			for _, owner := range owners {
+				keys = append(keys, owner.Key)
+			}
+			if len(keys) != len(test.want) || (len(keys) > 0 && keys[0] != test.want[0]) {
+				t.Errorf("%s owners %q, want %q", test.ownerType, keys, test.want)
+			}
+		})
+	}
+
+	t.Run("importer details", func(t *testing.T) {
+		result := c.ledger.Evaluate(c.farmer, "queryOwnersByType", "IMPORTER")
+		owners := []chaincode.OwnerQueryResult{}
+		if err := json.Unmarshal(result.Payload, &owners); err != nil {
+			t.Fatal(err)
+		}
+		if importer := owners[0].Record.Importer; importer == nil || len(importer.Origin_countries) != 2 || importer.Origin_countries[1] != "US" {
+			t.Errorf("importer registered with %+v", importer)
+		}
+	})
+
+	refused := []struct {
+		name     string
+		function string
+		args     []string
+	}{
+		{"unknown type", "queryOwnersByType", []string{"BUTCHER"}},
+		{"legacy id without a type", "registerOwner", []string{"OWNER9", "SHOP0", "Shop", "Seoul", "C", "Lee", "Empty", "211-81-00001"}},
+		{"business number", "registerWholesaler", []string{"", "WHOLESALE1", "Garak Meat", "Seoul", "C", "Jung", "Empty", "Empty", "02-000-0000", "Garak market"}},
+		{"phone number", "registerRestaurant", []string{"", "RESTAURANT0", "Hanwoo House", "Seoul", "C", "Seo", "Empty", "211-81-00002", "call us", "LIC-1"}},
+		{"importer without origin", "registerImporter", []string{"", "IMPORT1", "Busan Trading", "Busan", "C", "Han", "Empty", "121-81-00001", "051-000-0000", "IMP-2019-002", " , "}},
+		{"farm without livestock", "registerFarm", []string{"", "FARM1", "ChukLim2", "Iksan", "", "Kim", "530118"}},
+	}
+	for _, test := range refused {
+		t.Run(test.name, func(t *testing.T) {
+			result := c.ledger.Submit(c.farmer, test.function, test.args...)
+			if code := errorCode(t, result); code != "invalid_argument" {
+				t.Errorf("%s failed with %q, want invalid_argument (%s)", test.function, code, result.Message)
+			}
+		})
+	}
+}
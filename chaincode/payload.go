@@ -0,0 +1,388 @@
+package chaincode
+
+// Named payloads of the *JSON transactions. Every transaction with a long
+// positional argument list has a variant taking one JSON object with these
+// fields instead, e.g.
+//
+//	'{"Args":["addFAMDVaccineJSON", "{\"cow\":\"180501-2\",\"farm_id\":\"FARM0\",...}"]}'
+//
+// The contract API checks the object against the schema published in the
+// transaction metadata: a missing field or a field not listed here is an
+// error. Only the legacy keys may be left out. Calling the positional name
+// with a single JSON object is the same as calling the JSON variant, see Chaincode.
+
+type CowPayload struct {
+	Legacy_key string `json:"legacy_key,omitempty" metadata:",optional"`
+	Id_no      string `json:"id_no"`
+	Birth_date string `json:"birth_date"`
+	Sex        string `json:"sex"`
+	Father_id  string `json:"father_id"`
+	Mother_id  string `json:"mother_id"`
+	Origin     string `json:"origin"`
+	Owner      string `json:"owner"`
+}
+
+type FarmPayload struct {
+	Legacy_key      string `json:"legacy_key,omitempty" metadata:",optional"`
+	Farm_id         string `json:"farm_id"`
+	Farm_nm         string `json:"farm_nm"`
+	Farm_addr       string `json:"farm_addr"`
+	Livestock       string `json:"livestock"`
+	Farm_user_nm    string `json:"farm_user_nm"`
+	Farm_user_birth string `json:"farm_user_birth"`
+}
+
+type SlaughterhousePayload struct {
+	Legacy_key           string `json:"legacy_key,omitempty" metadata:",optional"`
+	Slaughter_id         string `json:"slaughter_id"`
+	Slaughter_nm         string `json:"slaughter_nm"`
+	Slaughter_addr       string `json:"slaughter_addr"`
+	Handle_livestock     string `json:"handle_livestock"`
+	Slaughter_user_nm    string `json:"slaughter_user_nm"`
+	Slaughter_user_birth string `json:"slaughter_user_birth"`
+	Slaughter_tel        string `json:"slaughter_tel"`
+	Slaughter_reg_no     string `json:"slaughter_reg_no"`
+}
+
+type ProcessorPayload struct {
+	Legacy_key         string `json:"legacy_key,omitempty" metadata:",optional"`
+	Process_id         string `json:"process_id"`
+	Process_nm         string `json:"process_nm"`
+	Process_addr       string `json:"process_addr"`
+	Livestock          string `json:"livestock"`
+	Process_user_nm    string `json:"process_user_nm"`
+	Process_user_birth string `json:"process_user_birth"`
+	Process_biz_no     string `json:"process_biz_no"`
+}
+
+type SellerPayload struct {
+	Legacy_key      string `json:"legacy_key,omitempty" metadata:",optional"`
+	Sale_id         string `json:"sale_id"`
+	Sale_nm         string `json:"sale_nm"`
+	Sale_addr       string `json:"sale_addr"`
+	Livestock       string `json:"livestock"`
+	Sale_user_nm    string `json:"sale_user_nm"`
+	Sale_user_birth string `json:"sale_user_birth"`
+	Sale_biz_no     string `json:"sale_biz_no"`
+}
+
+type WholesalerPayload struct {
+	Legacy_key           string `json:"legacy_key,omitempty" metadata:",optional"`
+	Wholesale_id         string `json:"wholesale_id"`
+	Wholesale_nm         string `json:"wholesale_nm"`
+	Wholesale_addr       string `json:"wholesale_addr"`
+	Livestock            string `json:"livestock"`
+	Wholesale_user_nm    string `json:"wholesale_user_nm"`
+	Wholesale_user_birth string `json:"wholesale_user_birth"`
+	Wholesale_biz_no     string `json:"wholesale_biz_no"`
+	Wholesale_tel        string `json:"wholesale_tel"`
+	Market               string `json:"market"`
+}
+
+type ImporterPayload struct {
+	Legacy_key        string   `json:"legacy_key,omitempty" metadata:",optional"`
+	Import_id         string   `json:"import_id"`
+	Import_nm         string   `json:"import_nm"`
+	Import_addr       string   `json:"import_addr"`
+	Livestock         string   `json:"livestock"`
+	Import_user_nm    string   `json:"import_user_nm"`
+	Import_user_birth string   `json:"import_user_birth"`
+	Import_biz_no     string   `json:"import_biz_no"`
+	Import_tel        string   `json:"import_tel"`
+	Import_reg_no     string   `json:"import_reg_no"`
+	Origin_countries  []string `json:"origin_countries"`
+}
+
+type RestaurantPayload struct {
+	Legacy_key            string `json:"legacy_key,omitempty" metadata:",optional"`
+	Restaurant_id         string `json:"restaurant_id"`
+	Restaurant_nm         string `json:"restaurant_nm"`
+	Restaurant_addr       string `json:"restaurant_addr"`
+	Livestock             string `json:"livestock"`
+	Restaurant_user_nm    string `json:"restaurant_user_nm"`
+	Restaurant_user_birth string `json:"restaurant_user_birth"`
+	Restaurant_biz_no     string `json:"restaurant_biz_no"`
+	Restaurant_tel        string `json:"restaurant_tel"`
+	License_no            string `json:"license_no"`
+}
+
+type HACCPPayload struct {
+	Haccp         string `json:"haccp"`
+	Owner         string `json:"owner"`
+	Farm_id       string `json:"farm_id"`
+	Farm_nm       string `json:"farm_nm"`
+	Farm_addr     string `json:"farm_addr"`
+	Apply_item    string `json:"apply_item"`
+	Validity_date string `json:"validity_date"`
+}
+
+type RFIDPayload struct {
+	Cow     string `json:"cow"`
+	Rfid_no string `json:"rfid_no"`
+}
+
+type BundlePayload struct {
+	Legacy_key      string `json:"legacy_key,omitempty" metadata:",optional"`
+	Id_no           string `json:"id_no"`
+	Barcode_id      string `json:"barcode_id"`
+	Package_date    string `json:"package_date"`
+	Part            string `json:"part"`
+	Weight          string `json:"weight"`
+	Purchase_nm     string `json:"purchase_nm"`
+	Purchase_biz_no string `json:"purchase_biz_no"`
+}
+
+type OwnerChangePayload struct {
+	Cow        string `json:"cow"`
+	From_owner string `json:"from_owner"`
+	To_owner   string `json:"to_owner"`
+}
+
+type RemarkPayload struct {
+	Cow   string `json:"cow"`
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type AutPayload struct {
+	Owner           string `json:"owner"`
+	Aut_falg        string `json:"aut_falg"`
+	Validity_date   string `json:"validity_date"`
+	Farm_nm         string `json:"farm_nm"`
+	Farm_birth_date string `json:"farm_birth_date"`
+	Farm_addr       string `json:"farm_addr"`
+	Biz_addr        string `json:"biz_addr"`
+	Aut_item        string `json:"aut_item"`
+	Breed_head      string `json:"breed_head"`
+	Aut_com         string `json:"aut_com"`
+	Aut_id          string `json:"aut_id"`
+	Aut_date        string `json:"aut_date"`
+}
+
+type BTVaccinePayload struct {
+	Cow                string `json:"cow"`
+	Farm_id            string `json:"farm_id"`
+	Farm_nm            string `json:"farm_nm"`
+	Farm_addr          string `json:"farm_addr"`
+	Farm_user_nm       string `json:"farm_user_nm"`
+	Farm_user_birth    string `json:"farm_user_birth"`
+	Farm_user_addr     string `json:"farm_user_addr"`
+	Inspection_date    string `json:"inspection_date"`
+	Inspection_head    string `json:"inspection_head"`
+	Inspection_method  string `json:"inspection_method"`
+	Livestock          string `json:"livestock"`
+	Kind               string `json:"kind"`
+	Sex                string `json:"sex"`
+	Age                string `json:"age"`
+	Id_no              string `json:"id_no"`
+	Inspection_result  string `json:"inspection_result"`
+	Inspection_part    string `json:"inspection_part"`
+	Inspection_user_nm string `json:"inspection_user_nm"`
+}
+
+type FAMDVaccinePayload struct {
+	Cow              string `json:"cow"`
+	Farm_id          string `json:"farm_id"`
+	Farm_addr        string `json:"farm_addr"`
+	Farm_tel         string `json:"farm_tel"`
+	Breed_head       string `json:"breed_head"`
+	Item             string `json:"item"`
+	Sex              string `json:"sex"`
+	Age              string `json:"age"`
+	Id_no            string `json:"id_no"`
+	Vaccination_date string `json:"vaccination_date"`
+}
+
+type DeadPayload struct {
+	Cow        string `json:"cow"`
+	Farm_id    string `json:"farm_id"`
+	Id_no      string `json:"id_no"`
+	Det_date   string `json:"det_date"`
+	Det_reason string `json:"det_reason"`
+	// Det_method is the disposal method of the carcass
+	Det_method string `json:"det_method"`
+	// the veterinarian confirming the death, if known already
+	Veterinarian_no string `json:"veterinarian_no,omitempty" metadata:",optional"`
+	Veterinarian_nm string `json:"veterinarian_nm,omitempty" metadata:",optional"`
+}
+
+type ArchivePayload struct {
+	Cow    string `json:"cow"`
+	Reason string `json:"reason"`
+}
+
+type DeathConfirmationPayload struct {
+	Cow             string `json:"cow"`
+	Veterinarian_no string `json:"veterinarian_no"`
+	Veterinarian_nm string `json:"veterinarian_nm"`
+	Confirm_date    string `json:"confirm_date"`
+}
+
+type DeliverPayload struct {
+	Cow     string `json:"cow"`
+	Id_no   string `json:"id_no"`
+	Rfid_no string `json:"rfid_no"`
+}
+
+type InspectPayload struct {
+	Cow                string `json:"cow"`
+	Livestock          string `json:"livestock"`
+	Id_no              string `json:"id_no"`
+	Weight             string `json:"weight"`
+	Slaughter_nm       string `json:"slaughter_nm"`
+	Seal_no            string `json:"seal_no"`
+	Slaughter_date     string `json:"slaughter_date"`
+	Farm_id            string `json:"farm_id"`
+	Farm_addr          string `json:"farm_addr"`
+	Haccp_yn           string `json:"haccp_yn"`
+	Fale_method        string `json:"fale_method"`
+	Inspection_date    string `json:"inspection_date"`
+	Inspection_part    string `json:"inspection_part"`
+	Inspection_user_nm string `json:"inspection_user_nm"`
+	Veterinarian_no    string `json:"veterinarian_no"`
+}
+
+type GradeResultPayload struct {
+	Cow                string `json:"cow"`
+	Grade_date         string `json:"grade_date"`
+	Quality_part       string `json:"quality_part"`
+	Quality_nm         string `json:"quality_nm"`
+	Subscriber_nm      string `json:"subscriber_nm"`
+	Subscriber_birth   string `json:"subscriber_birth"`
+	Subscriber_company string `json:"subscriber_company"`
+	Subscriber_addr    string `json:"subscriber_addr"`
+	Slaughter_nm       string `json:"slaughter_nm"`
+	Slaughter_addr     string `json:"slaughter_addr"`
+	Id_no              string `json:"id_no"`
+	Weight             string `json:"weight"`
+	Meat_quality_grade string `json:"meat_quality_grade"`
+	Meat_weight_grade  string `json:"meat_weight_grade"`
+	Grade_head         string `json:"grade_head"`
+}
+
+// PurchaseReportPayload is used by addInfoInProcessesReportPurchase and addInfoInSalesReportPurchase
+type PurchaseReportPayload struct {
+	Cow             string `json:"cow"`
+	Barcode_id      string `json:"barcode_id"`
+	Deal_date       string `json:"deal_date"`
+	Origin          string `json:"origin"`
+	Part            string `json:"part"`
+	Weight          string `json:"weight"`
+	Purchase_nm     string `json:"purchase_nm"`
+	Purchase_biz_no string `json:"purchase_biz_no"`
+}
+
+type PackingReportPayload struct {
+	Cow             string `json:"cow"`
+	Id_no           string `json:"id_no"`
+	Barcode_id      string `json:"barcode_id"`
+	Package_date    string `json:"package_date"`
+	Part            string `json:"part"`
+	Weight          string `json:"weight"`
+	Purchase_nm     string `json:"purchase_nm"`
+	Purchase_biz_no string `json:"purchase_biz_no"`
+}
+
+type SaleReportPayload struct {
+	Cow         string `json:"cow"`
+	Id_no       string `json:"id_no"`
+	Barcode_id  string `json:"barcode_id"`
+	Sale_date   string `json:"sale_date"`
+	Part        string `json:"part"`
+	Weight      string `json:"weight"`
+	Sale_nm     string `json:"sale_nm"`
+	Sale_biz_no string `json:"sale_biz_no"`
+}
+
+// ColdChainReadingPayload is used by addColdChainReading and addColdChainReadingBatch
+type ColdChainReadingPayload struct {
+	Bundle      string  `json:"bundle"`
+	Logger_id   string  `json:"logger_id"`
+	Read_at     string  `json:"read_at"`
+	Temperature float64 `json:"temperature"`
+	// Humidity is left out when the logger does not measure it
+	Humidity float64 `json:"humidity,omitempty" metadata:",optional"`
+	// Humidity_measured tells a reading of 0% from no humidity, the contract
+	// API takes no *float64; it is set from the humidity field, see coldchain.go
+	Humidity_measured bool `json:"-" metadata:"-"`
+}
+
+type ColdChainRulePayload struct {
+	Part        string          `json:"part"`
+	Temperature *ColdChainRange `json:"temperature,omitempty" metadata:",optional"`
+	Humidity    *ColdChainRange `json:"humidity,omitempty" metadata:",optional"`
+}
+
+// WeighingPayload is used by addWeighing and addWeighingBatch
+type WeighingPayload struct {
+	Cow        string `json:"cow"`
+	Weigh_date string `json:"weigh_date"`
+	Weight     string `json:"weight"`
+	Method     string `json:"method,omitempty" metadata:",optional"`
+	Weigher_nm string `json:"weigher_nm,omitempty" metadata:",optional"`
+}
+
+type GrowthReferencePayload struct {
+	Breed string `json:"breed"`
+	// Sex is M or F, * or empty for both
+	Sex    string                 `json:"sex,omitempty" metadata:",optional"`
+	Points []GrowthReferencePoint `json:"points"`
+}
+
+// TreatmentPayload is used by addTreatment
+type TreatmentPayload struct {
+	Cow             string `json:"cow"`
+	Treat_date      string `json:"treat_date"`
+	Drug            string `json:"drug"`
+	Dose            string `json:"dose,omitempty" metadata:",optional"`
+	Veterinarian_no string `json:"veterinarian_no"`
+	Veterinarian_nm string `json:"veterinarian_nm,omitempty" metadata:",optional"`
+	// Withdrawal_days is the withdrawal period of the drug in days, empty or 0 for none
+	Withdrawal_days string `json:"withdrawal_days,omitempty" metadata:",optional"`
+}
+
+// InseminationPayload is used by addInsemination
+type InseminationPayload struct {
+	Cow               string `json:"cow"`
+	Insemination_date string `json:"insemination_date"`
+	Sire_id           string `json:"sire_id"`
+	Straw_lot         string `json:"straw_lot,omitempty" metadata:",optional"`
+	Technician_nm     string `json:"technician_nm,omitempty" metadata:",optional"`
+}
+
+// PregnancyCheckPayload is used by addPregnancyCheck
+type PregnancyCheckPayload struct {
+	Cow        string `json:"cow"`
+	Check_date string `json:"check_date"`
+	// Result is positive or negative
+	Result          string `json:"result"`
+	Method          string `json:"method,omitempty" metadata:",optional"`
+	Veterinarian_nm string `json:"veterinarian_nm,omitempty" metadata:",optional"`
+}
+
+// CalvingPayload is used by addCalving
+type CalvingPayload struct {
+	Cow          string `json:"cow"`
+	Calving_date string `json:"calving_date"`
+	Calf_id      string `json:"calf_id"`
+	Calf_sex     string `json:"calf_sex"`
+	// Father_id is the sire of the calf, empty for the sire of the insemination that got the dam in calf
+	Father_id string `json:"father_id,omitempty" metadata:",optional"`
+	// Origin is the origin of the calf, empty for the origin of the dam
+	Origin string `json:"origin,omitempty" metadata:",optional"`
+}
+
+// AuctionSalePayload is used by addAuctionSale
+type AuctionSalePayload struct {
+	Cow           string `json:"cow"`
+	Sale_date     string `json:"sale_date"`
+	Auction_house string `json:"auction_house"`
+	Region        string `json:"region"`
+	Lot_no        string `json:"lot_no"`
+	// Hammer_price is in won
+	Hammer_price string `json:"hammer_price"`
+	Seller       string `json:"seller"`
+	Buyer        string `json:"buyer"`
+	// Weight is the live weight in kg at the sale
+	Weight string `json:"weight,omitempty" metadata:",optional"`
+}
@@ -0,0 +1,56 @@
+package chaincode_test
+
+import (
+	"testing"
+
+	"github.com/lotty02cho/fabcow-test/chaincode"
+)
+
+func TestJSONPayloads(t *testing.T) {
+	c := newContract(t)
+	c.registerFarm("", "FARM0")
+
+	// the positional call and both ways of sending the payload register the same cow
+	c.submit("registerCow", "", "180501-1", "180501", "F", "901027", "910101", "Iksan", "FARM0")
+	for function, idNo := range map[string]string{"registerCowJSON": "180501-2", "registerCow": "180501-3"} {
+		result := c.submitJSON(function, chaincode.CowPayload{Id_no: idNo, Birth_date: "180501", Sex: "F", Father_id: "901027", Mother_id: "910101", Origin: "Iksan", Owner: "FARM0"})
+		if err := result.Err(); err != nil {
+			t.Fatalf("%s: %v", function, err)
+		}
+	}
+	want := c.cow("180501-1")
+	for _, idNo := range []string{"180501-2", "180501-3"} {
+		cow := c.cow(idNo)
+		if cow.Birth_date != want.Birth_date || cow.Sex != want.Sex || cow.Father_id != want.Father_id || cow.Mother_id != want.Mother_id || cow.Origin != want.Origin || cow.Owner.Biz_no != want.Owner.Biz_no {
+			t.Errorf("cow %s registered as %+v, want %+v", idNo, cow, want)
+		}
+	}
+
+	t.Run("registerOwner picks the owner type by its id field", func(t *testing.T) {
+		result := c.submitJSON("registerOwner", chaincode.FarmPayload{Farm_id: "FARM1", Farm_nm: "ChukLim1", Farm_addr: "Iksan", Livestock: "C", Farm_user_nm: "Kim", Farm_user_birth: "530118"})
+		if err := result.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if c.ledger.State("\x00owner\x00FARM1\x00") == nil {
+			t.Errorf("farm FARM1 not registered")
+		}
+	})
+
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{"unknown field", `{"id_no":"180501-4","birth_date":"180501","sex":"F","father_id":"","mother_id":"","origin":"Iksan","owner":"FARM0","color":"black"}`},
+		{"missing field", `{"id_no":"180501-4","birth_date":"180501","sex":"F"}`},
+		{"wrong type", `{"id_no":180501,"birth_date":"180501","sex":"F","father_id":"","mother_id":"","origin":"Iksan","owner":"FARM0"}`},
+		{"not an object", `{"id_no":`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := c.ledger.Submit(c.farmer, "registerCowJSON", test.payload)
+			if code := errorCode(t, result); code != "invalid_argument" {
+				t.Errorf("registerCowJSON %s failed with %q, want invalid_argument (%s)", test.payload, code, result.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,286 @@
+package chaincode
+
+import (
+	"math"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Cows are indexed under ("sire", [Father_id, Id_no]) so the offspring of a
+// sire are found without scanning every cow.
+const (
+	sireIndexObjectType = "sire"
+	// maxCompareSires bounds the work of one comparison
+	maxCompareSires = 20
+)
+
+// ProgenyRecord is the performance of one offspring
+type ProgenyRecord struct {
+	Id_no              string `json:"id_no"`
+	Birth_date         string `json:"birth_date"`
+	Sex                string `json:"sex"`
+	Grade_date         string `json:"grade_date,omitempty" metadata:",optional"`
+	Meat_quality_grade string `json:"meat_quality_grade,omitempty" metadata:",optional"`
+	Meat_weight_grade  string `json:"meat_weight_grade,omitempty" metadata:",optional"`
+	Weight             string `json:"weight,omitempty" metadata:",optional"`
+	Slaughter_date     string `json:"slaughter_date,omitempty" metadata:",optional"`
+	// Slaughter_age_days is left out when the birth or slaughter date can not be read
+	Slaughter_age_days int    `json:"slaughter_age_days,omitempty" metadata:",optional"`
+	Dead               bool   `json:"dead"`
+	Det_date           string `json:"det_date,omitempty" metadata:",optional"`
+	Det_reason         string `json:"det_reason,omitempty" metadata:",optional"`
+}
+
+type SlaughterAge struct {
+	Count          int     `json:"count"`
+	Average_days   float64 `json:"average_days"`
+	Average_months float64 `json:"average_months"`
+	Min_days       int     `json:"min_days"`
+	Max_days       int     `json:"max_days"`
+}
+
+type ReasonCount struct {
+	Reason  string  `json:"reason"`
+	Count   int     `json:"count"`
+	Percent float64 `json:"percent"`
+}
+
+// SireProgenyReport summarizes the offspring of a sire. Rates are percentages
+// of the offspring, so reports of sires with different herd sizes compare.
+type SireProgenyReport struct {
+	Father_id      string        `json:"father_id"`
+	Offspring      int           `json:"offspring"`
+	Graded         int           `json:"graded"`
+	Dead           int           `json:"dead"`
+	Mortality_rate float64       `json:"mortality_rate"`
+	Grades         GradeStats    `json:"grades"`
+	Slaughter_age  SlaughterAge  `json:"slaughter_age"`
+	Death_reasons  []ReasonCount `json:"death_reasons"`
+	// Progeny is left out of comparisons
+	Progeny []ProgenyRecord `json:"progeny,omitempty" metadata:",optional"`
+}
+
+// GetSireProgenyReport summarizes the grades, carcass weights, slaughter ages and mortality of the offspring of a sire
+func (s *SmartContract) GetSireProgenyReport(ctx contractapi.TransactionContextInterface, fatherId string) (*SireProgenyReport, error) {
+	//'{"Args":["getSireProgenyReport", "901027"]}'
+
+	return sireProgenyReport(ctx.GetStub(), fatherId, true)
+}
+
+// CompareSires returns the progeny summary of every sire, in the order asked for
+func (s *SmartContract) CompareSires(ctx contractapi.TransactionContextInterface, fatherIds []string) ([]SireProgenyReport, error) {
+	//'{"Args":["compareSires", "[\"901027\",\"901028\"]"]}'
+
+	if len(fatherIds) == 0 {
+		return nil, invalidArgument("No sire to compare")
+	}
+	if len(fatherIds) > maxCompareSires {
+		return nil, invalidArgument("Too many sires: %d, at most %d are allowed", len(fatherIds), maxCompareSires)
+	}
+
+	reports := []SireProgenyReport{}
+	for _, fatherId := range fatherIds {
+		report, err := sireProgenyReport(ctx.GetStub(), fatherId, false)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, *report)
+	}
+	return reports, nil
+}
+
+func sireProgenyReport(APIstub shim.ChaincodeStubInterface, fatherId string, withProgeny bool) (*SireProgenyReport, error) {
+	if fatherId == "" {
+		return nil, invalidArgument("Incorrect value. father_id must not be empty")
+	}
+
+	resultsIterator, err := APIstub.GetStateByPartialCompositeKey(sireIndexObjectType, []string{fatherId})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	report := SireProgenyReport{Father_id: fatherId}
+	progeny := []ProgenyRecord{}
+	grades := newGradeAggregate("")
+	reasons := map[string]int{}
+	ageTotal := 0
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, keyParts, err := APIstub.SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+		_, cow, err := getCow(APIstub, keyParts[1])
+		if err != nil {
+			// deleted cows take their index entry with them, anything else is corrupt
+			return nil, err
+		}
+
+		record := progenyRecord(cow)
+		report.Offspring++
+		if record.Grade_date != "" {
+			report.Graded++
+			grades.add(gradeIndexEntry{Quality: record.Meat_quality_grade, Yield: record.Meat_weight_grade, Weight: record.Weight})
+		}
+		if record.Slaughter_age_days > 0 {
+			age := &report.Slaughter_age
+			if age.Count == 0 || record.Slaughter_age_days < age.Min_days {
+				age.Min_days = record.Slaughter_age_days
+			}
+			if record.Slaughter_age_days > age.Max_days {
+				age.Max_days = record.Slaughter_age_days
+			}
+			age.Count++
+			ageTotal += record.Slaughter_age_days
+		}
+		if record.Dead {
+			report.Dead++
+			reasons[record.Det_reason]++
+		}
+		progeny = append(progeny, record)
+	}
+
+	report.Grades = grades.stats()
+	if report.Offspring > 0 {
+		report.Mortality_rate = percent(report.Dead, report.Offspring)
+	}
+	if age := &report.Slaughter_age; age.Count > 0 {
+		days := float64(ageTotal) / float64(age.Count)
+		age.Average_days = math.Round(days*100) / 100
+		// an average month of the Gregorian calendar
+		age.Average_months = math.Round(days/30.436875*100) / 100
+	}
+	report.Death_reasons = reasonCounts(reasons, report.Dead)
+	if withProgeny {
+		report.Progeny = progeny
+	}
+	return &report, nil
+}
+
+// progenyRecord reads the grade, slaughter and death events of an offspring
+func progenyRecord(cow Cow) ProgenyRecord {
+	record := ProgenyRecord{Id_no: cow.Id_no, Birth_date: cow.Birth_date, Sex: cow.Sex}
+
+	if grade := lastRemarkEvent(cow.Remarks, "addInfoGradeResult"); grade != nil {
+		record.Grade_date = grade["grade_date"]
+		record.Meat_quality_grade = grade["meat_quality_grade"]
+		record.Meat_weight_grade = grade["meat_weight_grade"]
+		record.Weight = grade["weight"]
+	}
+
+	// the slaughter date is on the inspection, the grade date is the next best thing
+	slaughterDate := record.Grade_date
+	if inspect := lastRemarkEvent(cow.Remarks, "addInfoInspect"); inspect != nil && inspect["slaughter_date"] != "" {
+		slaughterDate = inspect["slaughter_date"]
+	}
+	record.Slaughter_date = slaughterDate
+	if slaughterDate != "" {
+		record.Slaughter_age_days = ageInDays(cow.Birth_date, slaughterDate)
+	}
+
+	if dead := lastRemarkEvent(cow.Remarks, "addInfoDead"); cow.Dead && dead != nil {
+		record.Dead = true
+		record.Det_date = dead["det_date"]
+		record.Det_reason = dead["det_reason"]
+	}
+	return record
+}
+
+// ageInDays is the age at date of an animal born on birthDate, 0 when either can not be read.
+// Birth dates are often written YYMMDD, they are taken in the century that makes the age positive.
+func ageInDays(birthDate string, date string) int {
+	at, err := parseDate(date)
+	if err != nil {
+		return 0
+	}
+
+	var born time.Time
+	if digits := dateDigits(birthDate); len(digits) == 6 {
+		for _, century := range []string{"20", "19"} {
+			if born, err = parseDate(century + digits); err == nil && !born.After(at) {
+				break
+			}
+		}
+	} else {
+		born, err = parseDate(birthDate)
+	}
+	if err != nil || born.After(at) {
+		return 0
+	}
+	return int(at.Sub(born).Hours() / 24)
+}
+
+// birthDay reads a birth date, YYMMDD in the century that puts it on or before today.
+// It is false when the birth date can not be read.
+func birthDay(birthDate string, today time.Time) (time.Time, bool) {
+	if digits := dateDigits(birthDate); len(digits) == 6 {
+		for _, century := range []string{"20", "19"} {
+			if born, err := parseDate(century + digits); err == nil && !born.After(today) {
+				return born, true
+			}
+		}
+		return time.Time{}, false
+	}
+	born, err := parseDate(birthDate)
+	return born, err == nil
+}
+
+func parseDate(date string) (time.Time, error) {
+	normalized, err := normalizeDate(date)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse("20060102", normalized)
+}
+
+func makeSireIndexKey(APIstub shim.ChaincodeStubInterface, cow Cow) (string, error) {
+	return APIstub.CreateCompositeKey(sireIndexObjectType, []string{cow.Father_id, cow.Id_no})
+}
+
+// putSireIndex indexes a cow under its sire, cows of unknown sires are not indexed
+func putSireIndex(APIstub shim.ChaincodeStubInterface, cow Cow) error {
+	if cow.Father_id == "" {
+		return nil
+	}
+	key, err := makeSireIndexKey(APIstub, cow)
+	if err != nil {
+		return err
+	}
+	// an empty value would delete the key
+	return APIstub.PutState(key, []byte{0x00})
+}
+
+func delSireIndex(APIstub shim.ChaincodeStubInterface, cow Cow) error {
+	if cow.Father_id == "" {
+		return nil
+	}
+	key, err := makeSireIndexKey(APIstub, cow)
+	if err != nil {
+		return err
+	}
+	return APIstub.DelState(key)
+}
+
+// indexSires is the migration of cows registered before cows were indexed by sire
+func indexSires(APIstub shim.ChaincodeStubInterface, key string, value []byte, run *migrationRun) error {
+	cow := Cow{}
+	if err := decodeCow(value, &cow); err != nil {
+		txLogger(APIstub).warning("migration skipped a record", "key", key, "error", err)
+		run.status.Failed = append(run.status.Failed, key)
+		return nil
+	}
+	if cow.Father_id == "" {
+		return nil
+	}
+	if err := putSireIndex(APIstub, cow); err != nil {
+		return err
+	}
+	run.status.Upgraded++
+	return nil
+}
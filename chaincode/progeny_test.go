@@ -0,0 +1,76 @@
+package chaincode_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lotty02cho/fabcow-test/chaincode"
+)
+
+func TestSireProgenyReport(t *testing.T) {
+	c := newContract(t)
+	c.registerFarm("", "FARM0")
+	// the cows of the fixture are by 901027 and born on 180501
+	for _, idNo := range []string{"180501-1", "180501-2", "180501-3", "180501-4"} {
+		c.registerCow("", idNo, "M", "FARM0")
+	}
+	c.submit("registerCow", "", "180501-5", "180501", "F", "901028", "910101", "Iksan", "FARM0")
+
+	if err := c.submitJSON("addInfoInspect", chaincode.InspectPayload{Cow: "180501-1", Livestock: "C", Id_no: "180501-1", Weight: "420", Slaughter_nm: "Iksan", Seal_no: "S-1", Slaughter_date: "20191130", Farm_id: "FARM0", Farm_addr: "Iksan", Haccp_yn: "Y", Inspection_date: "20191130", Inspection_part: "all", Inspection_user_nm: "Lee", Veterinarian_no: "VET-9"}).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.submitJSON("addInfoGradeResultJSON", chaincode.GradeResultPayload{Cow: "180501-1", Grade_date: "20191201", Id_no: "180501-1", Weight: "420", Meat_quality_grade: "1++", Meat_weight_grade: "A"}).Err(); err != nil {
+		t.Fatal(err)
+	}
+	c.submit("addInfoDead", "180501-2", "FARM0", "180501-2", "20190601", "pneumonia", "incineration")
+
+	result := c.ledger.Evaluate(c.farmer, "getSireProgenyReport", "901027")
+	if err := result.Err(); err != nil {
+		t.Fatal(err)
+	}
+	report := chaincode.SireProgenyReport{}
+	if err := json.Unmarshal(result.Payload, &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Offspring != 4 || report.Graded != 1 || report.Dead != 1 || report.Mortality_rate != 25 {
+		t.Errorf("report %+v, want 4 offspring, 1 graded, 1 dead, 25%% mortality", report)
+	}
+	// 20180501 to 20191130
+	if age := report.Slaughter_age; age.Count != 1 || age.Min_days != 578 || age.Average_days != 578 {
+		t.Errorf("slaughter age %+v, want one at 578 days", age)
+	}
+	if len(report.Death_reasons) != 1 || report.Death_reasons[0].Reason != "pneumonia" || report.Death_reasons[0].Percent != 100 {
+		t.Errorf("death reasons %+v, want pneumonia", report.Death_reasons)
+	}
+	if len(report.Progeny) != 4 {
+		t.Errorf("report lists %d offspring, want 4", len(report.Progeny))
+	}
+
+	t.Run("comparison", func(t *testing.T) {
+		result := c.ledger.Evaluate(c.farmer, "compareSires", `["901028","901027","901029"]`)
+		if err := result.Err(); err != nil {
+			t.Fatal(err)
+		}
+		reports := []chaincode.SireProgenyReport{}
+		if err := json.Unmarshal(result.Payload, &reports); err != nil {
+			t.Fatal(err)
+		}
+		offspring := map[string]int{"901028": 1, "901027": 4, "901029": 0}
+		for i, father := range []string{"901028", "901027", "901029"} {
+			if i >= len(reports) || reports[i].Father_id != father || reports[i].Offspring != offspring[father] || reports[i].Progeny != nil {
+				t.Errorf("comparison %+v, want %s with %d offspring and no progeny list at %d", reports, father, offspring[father], i)
+			}
+		}
+	})
+
+	t.Run("too many sires", func(t *testing.T) {
+		fathers := make([]string, 21)
+		for i := range fathers {
+			fathers[i] = "9010" + string(rune('a'+i))
+		}
+		fathersAsBytes, _ := json.Marshal(fathers)
+		if code := errorCode(t, c.ledger.Evaluate(c.farmer, "compareSires", string(fathersAsBytes))); code != "invalid_argument" {
+			t.Errorf("comparison of 21 sires failed with %q, want invalid_argument", code)
+		}
+	})
+}
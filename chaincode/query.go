@@ -0,0 +1,192 @@
+package chaincode
+
+import (
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Query returns any document as JSON, older documents in the current layout
+func (s *SmartContract) Query(ctx contractapi.TransactionContextInterface, docType string, ref string) (string, error) {
+	//'{"Args":["query", "COW", "COW0"]}'
+	//'{"Args":["query", "COW", "180501-2"]}'
+	//docType	-- COW, OWNER, HACCP, RFID, BUNDLE
+	//ref		-- official id (Id_no, Biz_no, Barcode_id) or legacy key
+
+	APIstub := ctx.GetStub()
+
+	var key string
+	var err error
+	if strings.Contains(docType, "COW") {
+		docType = "COW"
+		key, err = resolveKey(APIstub, cowObjectType, ref)
+	} else if strings.Contains(docType, "OWNER") {
+		docType = "OWNER"
+		key, err = resolveKey(APIstub, ownerObjectType, ref)
+	} else if strings.Contains(docType, "HACCP") {
+		docType = "HACCP"
+		key = ref
+	} else if strings.Contains(docType, "RFID") {
+		docType = "RFID"
+		key, err = resolveRFIDKey(APIstub, ref)
+	} else if strings.Contains(docType, "BUNDLE") {
+		docType = "BUNDLE"
+		key, err = resolveKey(APIstub, bundleObjectType, ref)
+	} else {
+		return "", invalidArgument("Unknown document type: %s", docType)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	valAsBytes, err := APIstub.GetState(key)
+	if err != nil {
+		return "", internalError("Failed to get state for %s: %s", ref, err.Error())
+	}
+	if valAsBytes == nil {
+		return "", notFound("%s does not exist: %s", docType, ref)
+	}
+	valAsBytes, err = upgradedDocument(docType, valAsBytes)
+	if err != nil {
+		return "", err
+	}
+	return string(valAsBytes), nil
+}
+
+// ReadCow returns a cow by Id_no or legacy key
+func (s *SmartContract) ReadCow(ctx contractapi.TransactionContextInterface, cowRef string) (*Cow, error) {
+	_, cow, err := getCow(ctx.GetStub(), cowRef)
+	if err != nil {
+		return nil, err
+	}
+	return &cow, nil
+}
+
+// ReadOwner returns an owner by Biz_no or legacy key
+func (s *SmartContract) ReadOwner(ctx contractapi.TransactionContextInterface, ownerRef string) (*Owner, error) {
+	_, owner, err := getOwner(ctx.GetStub(), ownerRef)
+	if err != nil {
+		return nil, err
+	}
+	return &owner, nil
+}
+
+// ReadBundle returns a bundle by Barcode_id or legacy key
+func (s *SmartContract) ReadBundle(ctx contractapi.TransactionContextInterface, bundleRef string) (*Bundle, error) {
+	_, bundle, err := getBundle(ctx.GetStub(), bundleRef)
+	if err != nil {
+		return nil, err
+	}
+	return &bundle, nil
+}
+
+// ReadHACCP returns a HACCP certificate by its key
+func (s *SmartContract) ReadHACCP(ctx contractapi.TransactionContextInterface, haccpKey string) (*HACCP, error) {
+	haccpAsBytes, err := ctx.GetStub().GetState(haccpKey)
+	if err != nil {
+		return nil, internalError("Failed to get state for %s: %s", haccpKey, err.Error())
+	}
+	if haccpAsBytes == nil {
+		return nil, notFound("HACCP does not exist: %s", haccpKey)
+	}
+	haccp := HACCP{}
+	if err := decodeHACCP(haccpAsBytes, &haccp); err != nil {
+		return nil, err
+	}
+	return &haccp, nil
+}
+
+// ReadRFID returns an RFID tag by its number
+func (s *SmartContract) ReadRFID(ctx contractapi.TransactionContextInterface, rfidNo string) (*RFID, error) {
+	APIstub := ctx.GetStub()
+
+	key, err := resolveRFIDKey(APIstub, rfidNo)
+	if err != nil {
+		return nil, err
+	}
+	rfidAsBytes, err := APIstub.GetState(key)
+	if err != nil {
+		return nil, internalError("Failed to get state for %s: %s", rfidNo, err.Error())
+	}
+	if rfidAsBytes == nil {
+		return nil, notFound("RFID does not exist: %s", rfidNo)
+	}
+	rfid := RFID{}
+	if err := decodeRFID(rfidAsBytes, &rfid); err != nil {
+		return nil, err
+	}
+	return &rfid, nil
+}
+
+// QueryAllCows returns every cow but the archived ones with its Id_no as the key
+func (s *SmartContract) QueryAllCows(ctx contractapi.TransactionContextInterface) ([]CowQueryResult, error) {
+	return queryCows(ctx.GetStub(), false)
+}
+
+// queryCows lists the archived cows, or the ones that are not archived
+func queryCows(APIstub shim.ChaincodeStubInterface, archived bool) ([]CowQueryResult, error) {
+	resultsIterator, err := APIstub.GetStateByPartialCompositeKey(cowObjectType, []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	results := []CowQueryResult{}
+	for resultsIterator.HasNext() {
+		id, value, err := nextObject(APIstub, resultsIterator)
+		if err != nil {
+			return nil, err
+		}
+		cow := Cow{}
+		if err := decodeCow(value, &cow); err != nil {
+			return nil, err
+		}
+		if (cow.Archived != nil) != archived {
+			continue
+		}
+		results = append(results, CowQueryResult{Key: id, Record: cow})
+	}
+
+	return results, nil
+}
+
+// QueryAllOwners returns every owner with its Biz_no as the key
+func (s *SmartContract) QueryAllOwners(ctx contractapi.TransactionContextInterface) ([]OwnerQueryResult, error) {
+	APIstub := ctx.GetStub()
+
+	resultsIterator, err := APIstub.GetStateByPartialCompositeKey(ownerObjectType, []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	results := []OwnerQueryResult{}
+	for resultsIterator.HasNext() {
+		id, value, err := nextObject(APIstub, resultsIterator)
+		if err != nil {
+			return nil, err
+		}
+		owner := Owner{}
+		if err := decodeOwner(value, &owner); err != nil {
+			return nil, err
+		}
+		results = append(results, OwnerQueryResult{Key: id, Record: owner})
+	}
+
+	return results, nil
+}
+
+// nextObject reads the next record of a composite key range, returning the
+// official id instead of the composite key which carries NUL separators
+func nextObject(APIstub shim.ChaincodeStubInterface, resultsIterator shim.StateQueryIteratorInterface) (string, []byte, error) {
+	queryResponse, err := resultsIterator.Next()
+	if err != nil {
+		return "", nil, err
+	}
+	_, keyParts, err := APIstub.SplitCompositeKey(queryResponse.Key)
+	if err != nil {
+		return "", nil, err
+	}
+	return keyParts[0], queryResponse.Value, nil
+}
@@ -0,0 +1,187 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Slaughterhouses, processors and sellers file purchase, packing and sale
+// reports with the national livestock traceability system. The addInfo*Report*
+// records of the cows hold the same data, GetTraceabilityReport gives it back
+// per business and period so it does not have to be keyed in again.
+//
+// Reports are indexed under ("report", [YYYYMM, Biz_no, YYYYMMDD, Id_no,
+// function, n]) by the business that filed them, the owner of the cow when the
+// report was recorded. n numbers the reports of one function on a cow. A
+// report stays indexed when its cow is archived, a filing is not undone.
+const reportIndexObjectType = "report"
+
+// The kinds of report, the filings of the national system
+const (
+	ReportKindPurchase = "PURCHASE"
+	ReportKindPacking  = "PACKING"
+	ReportKindSale     = "SALE"
+)
+
+// reportFunctions are the remark prefixes of the reports
+var reportFunctions = []string{"addInfoInProcessesReportPurchase", "addInfoInSalesReportPurchase", "addInfoReportPacking", "addInfoReportSale"}
+
+// TraceabilityReportEntry is one filed report, also the value of its index entry
+type TraceabilityReportEntry struct {
+	Kind       string `json:"kind"`
+	Date       string `json:"date"`
+	Id_no      string `json:"id_no"`
+	Barcode_id string `json:"barcode_id"`
+	Origin     string `json:"origin"`
+	Part       string `json:"part"`
+	Weight     string `json:"weight"`
+	// Partner is the other business of the deal, the purchase_* or sale_* fields of the report
+	Partner_nm     string `json:"partner_nm"`
+	Partner_biz_no string `json:"partner_biz_no"`
+}
+
+type TraceabilityReport struct {
+	Biz_no     string                    `json:"biz_no"`
+	Owner_nm   string                    `json:"owner_nm"`
+	Owner_type OwnerType                 `json:"owner_type,omitempty" metadata:",optional"`
+	From       string                    `json:"from"`
+	To         string                    `json:"to"`
+	Purchases  []TraceabilityReportEntry `json:"purchases"`
+	Packings   []TraceabilityReportEntry `json:"packings"`
+	Sales      []TraceabilityReportEntry `json:"sales"`
+}
+
+// GetTraceabilityReport returns the reports a business filed over a period, by kind and date
+func (s *SmartContract) GetTraceabilityReport(ctx contractapi.TransactionContextInterface, bizNo string, from string, to string) (*TraceabilityReport, error) {
+	//'{"Args":["getTraceabilityReport", "409-81-00001", "20200101", "20200131"]}'
+	//bizNo	-- Biz_no of the business that filed the reports
+	//from	-- first report date, YYYYMMDD
+	//to	-- last report date, YYYYMMDD
+
+	APIstub := ctx.GetStub()
+
+	_, owner, err := getOwner(APIstub, bizNo)
+	if err != nil {
+		return nil, err
+	}
+	fromDate, toDate, months, err := statsPeriod(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := TraceabilityReport{Biz_no: owner.Biz_no, Owner_nm: owner.Owner_nm, Owner_type: owner.Owner_type, From: fromDate, To: toDate,
+		Purchases: []TraceabilityReportEntry{}, Packings: []TraceabilityReportEntry{}, Sales: []TraceabilityReportEntry{}}
+	for _, month := range months {
+		err := scanMonthIndex(APIstub, reportIndexObjectType, month, owner.Biz_no, fromDate, toDate, func(value []byte) error {
+			entry := TraceabilityReportEntry{}
+			if err := json.Unmarshal(value, &entry); err != nil {
+				return err
+			}
+			switch entry.Kind {
+			case ReportKindPurchase:
+				report.Purchases = append(report.Purchases, entry)
+			case ReportKindPacking:
+				report.Packings = append(report.Packings, entry)
+			case ReportKindSale:
+				report.Sales = append(report.Sales, entry)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &report, nil
+}
+
+// addCowReport notes a report on a cow like addCowRemarks and indexes it under the owner of the cow
+func (s *SmartContract) addCowReport(ctx contractapi.TransactionContextInterface, cowRef string, function string, variables []string, values []string) error {
+	APIstub := ctx.GetStub()
+
+	key, cow, err := getLiveCow(APIstub, cowRef)
+	if err != nil {
+		return err
+	}
+
+	cow.setCowRemarks(variables, values)
+
+	events := remarkEvents(cow.Remarks, function)
+	if err := putReportIndex(APIstub, cow, function, len(events)-1); err != nil {
+		return err
+	}
+
+	return putRecord(APIstub, key, &cow)
+}
+
+// putReportIndex indexes report n of function on cow under the current owner of cow
+func putReportIndex(APIstub shim.ChaincodeStubInterface, cow Cow, function string, n int) error {
+	event := remarkEvents(cow.Remarks, function)[n]
+	entry, dateField := reportEntry(cow, function, event)
+	date, err := normalizeDate(entry.Date)
+	if err != nil {
+		return withContext(dateField, err)
+	}
+	entry.Date = date
+
+	key, err := APIstub.CreateCompositeKey(reportIndexObjectType, []string{date[:6], cow.Owner.Biz_no, date, cow.Id_no, function, strconv.Itoa(n)})
+	if err != nil {
+		return err
+	}
+	entryAsBytes, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return APIstub.PutState(key, entryAsBytes)
+}
+
+// reportEntry reads a report event of function, and names the field its date is in
+func reportEntry(cow Cow, function string, event map[string]string) (TraceabilityReportEntry, string) {
+	entry := TraceabilityReportEntry{Id_no: cow.Id_no, Barcode_id: event["barcode_id"], Part: event["part"], Weight: event["weight"]}
+	switch function {
+	case "addInfoReportPacking":
+		entry.Kind, entry.Date = ReportKindPacking, event["package_date"]
+		entry.Partner_nm, entry.Partner_biz_no = event["purchase_nm"], event["purchase_biz_no"]
+		return entry, "package_date"
+	case "addInfoReportSale":
+		entry.Kind, entry.Date = ReportKindSale, event["sale_date"]
+		entry.Partner_nm, entry.Partner_biz_no = event["sale_nm"], event["sale_biz_no"]
+		return entry, "sale_date"
+	}
+	entry.Kind, entry.Date, entry.Origin = ReportKindPurchase, event["deal_date"], event["origin"]
+	entry.Partner_nm, entry.Partner_biz_no = event["purchase_nm"], event["purchase_biz_no"]
+	return entry, "deal_date"
+}
+
+// indexReports is the migration of cows with reports recorded before reports were
+// indexed. The history of the owners is not in the cow, the reports are indexed
+// under its owner at the time of the migration.
+func indexReports(APIstub shim.ChaincodeStubInterface, key string, value []byte, run *migrationRun) error {
+	cow := Cow{}
+	if err := decodeCow(value, &cow); err != nil {
+		txLogger(APIstub).warning("migration skipped a record", "key", key, "error", err)
+		run.status.Failed = append(run.status.Failed, key)
+		return nil
+	}
+	indexed, failed := false, false
+	for _, function := range reportFunctions {
+		for n := range remarkEvents(cow.Remarks, function) {
+			if err := putReportIndex(APIstub, cow, function, n); err != nil {
+				// a report with an unreadable date can not be indexed
+				txLogger(APIstub).warning("migration skipped a report", "key", key, "function", function, "error", err)
+				failed = true
+				continue
+			}
+			indexed = true
+		}
+	}
+	if failed {
+		run.status.Failed = append(run.status.Failed, key)
+	}
+	if indexed {
+		run.status.Upgraded++
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package chaincode_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lotty02cho/fabcow-test/chaincode"
+)
+
+func TestTraceabilityReport(t *testing.T) {
+	c := newContract(t)
+	c.registerFarm("", "FARM0")
+	c.submit("registerProcessor", "", "PROCESS0", "Daejeon Butcher", "Daejeon", "C", "Park", "Empty", "305-81-00000")
+	c.registerCow("", "180501-1", "M", "FARM0")
+	c.submit("changeCowOwner", "180501-1", "FARM0", "305-81-00000")
+	c.submit("addInfoInProcessesReportPurchase", "180501-1", "8801234567890", "20200105", "Korea", "carcass", "420", "Iksan Meat", "1-7474-8700")
+	c.submit("addInfoReportPacking", "180501-1", "180501-1", "8801234567890", "20200110", "sirloin", "10", "Seoul Mart", "211-81-00000")
+	c.submit("addInfoReportSale", "180501-1", "180501-1", "8801234567890", "2020-02-01", "sirloin", "10", "Seoul Mart", "211-81-00000")
+
+	report := func(bizNo string, from string, to string) chaincode.TraceabilityReport {
+		t.Helper()
+		result := c.ledger.Evaluate(c.farmer, "getTraceabilityReport", bizNo, from, to)
+		if err := result.Err(); err != nil {
+			t.Fatal(err)
+		}
+		report := chaincode.TraceabilityReport{}
+		if err := json.Unmarshal(result.Payload, &report); err != nil {
+			t.Fatal(err)
+		}
+		return report
+	}
+
+	january := report("305-81-00000", "20200101", "20200131")
+	if january.Owner_nm != "Daejeon Butcher" || january.Owner_type != chaincode.OwnerTypeProcessor {
+		t.Errorf("report of %q, a %q", january.Owner_nm, january.Owner_type)
+	}
+	if len(january.Purchases) != 1 || len(january.Packings) != 1 || len(january.Sales) != 0 {
+		t.Fatalf("January report %+v, want a purchase and a packing", january)
+	}
+	purchase := january.Purchases[0]
+	if purchase.Date != "20200105" || purchase.Id_no != "180501-1" || purchase.Origin != "Korea" || purchase.Partner_biz_no != "1-7474-8700" {
+		t.Errorf("purchase reported as %+v", purchase)
+	}
+	if sales := report("305-81-00000", "20200201", "20200229").Sales; len(sales) != 1 || sales[0].Date != "20200201" || sales[0].Partner_nm != "Seoul Mart" {
+		t.Errorf("February sales %+v, want the sale to Seoul Mart", sales)
+	}
+	// reports are filed by the owner of the cow, not by the farm it came from
+	if farm := report("FARM0", "20200101", "20200229"); len(farm.Purchases)+len(farm.Packings)+len(farm.Sales) != 0 {
+		t.Errorf("farm report %+v, want no filings", farm)
+	}
+
+	tests := []struct {
+		name     string
+		function string
+		args     []string
+		code     string
+	}{
+		{"unknown business", "getTraceabilityReport", []string{"999-81-00000", "20200101", "20200131"}, "not_found"},
+		{"reversed period", "getTraceabilityReport", []string{"305-81-00000", "20200131", "20200101"}, "invalid_argument"},
+		{"report without a date", "addInfoReportSale", []string{"180501-1", "180501-1", "8801234567890", "soon", "sirloin", "10", "Seoul Mart", "211-81-00000"}, "invalid_argument"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := c.ledger.Submit(c.farmer, test.function, test.args...)
+			if code := errorCode(t, result); code != test.code {
+				t.Errorf("%s failed with %q, want %q (%s)", test.function, code, test.code, result.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package chaincode
+
+import (
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// AddBTVaccine notes a tuberculosis/brucella inspection on a cow
+func (s *SmartContract) AddBTVaccine(ctx contractapi.TransactionContextInterface, cowRef string, farmId string, farmNm string, farmAddr string, farmUserNm string, farmUserBirth string, farmUserAddr string, inspectionDate string, inspectionHead string, inspectionMethod string, livestock string, kind string, sex string, age string, idNo string, inspectionResult string, inspectionPart string, inspectionUserNm string) error {
+	//'{"Args":["addBTVaccine","COW0", "farm_id", "farm_nm", "farm_addr", "farm_user_nm", "farm_user_birth", "farm_user_addr", "inspection_date", "inspection_head", "inspection_method", "livestock", "kind", "sex", "age", "id_no", "inspection_result", "inspection_part", "inspection_user_nm"]}'
+
+	return s.AddBTVaccineJSON(ctx, BTVaccinePayload{Cow: cowRef, Farm_id: farmId, Farm_nm: farmNm, Farm_addr: farmAddr, Farm_user_nm: farmUserNm, Farm_user_birth: farmUserBirth, Farm_user_addr: farmUserAddr, Inspection_date: inspectionDate, Inspection_head: inspectionHead, Inspection_method: inspectionMethod, Livestock: livestock, Kind: kind, Sex: sex, Age: age, Id_no: idNo, Inspection_result: inspectionResult, Inspection_part: inspectionPart, Inspection_user_nm: inspectionUserNm})
+}
+
+// AddBTVaccineJSON is addBTVaccine with named fields
+func (s *SmartContract) AddBTVaccineJSON(ctx contractapi.TransactionContextInterface, payload BTVaccinePayload) error {
+	variables := []string{"addBTVaccine.farm_id", "addBTVaccine.farm_nm", "addBTVaccine.farm_addr", "addBTVaccine.farm_user_nm", "addBTVaccine.farm_user_birth", "addBTVaccine.farm_user_addr", "addBTVaccine.inspection_date", "addBTVaccine.inspection_head", "addBTVaccine.inspection_method",
+		"addBTVaccine.livestock", "addBTVaccine.kind", "addBTVaccine.sex", "addBTVaccine.age", "addBTVaccine.id_no", "addBTVaccine.inspection_result", "addBTVaccine.inspection_part", "addBTVaccine.inspection_user_nm"}
+
+	return s.addCowRemarks(ctx, payload.Cow, variables, []string{payload.Farm_id, payload.Farm_nm, payload.Farm_addr, payload.Farm_user_nm, payload.Farm_user_birth, payload.Farm_user_addr, payload.Inspection_date, payload.Inspection_head, payload.Inspection_method, payload.Livestock, payload.Kind, payload.Sex, payload.Age, payload.Id_no, payload.Inspection_result, payload.Inspection_part, payload.Inspection_user_nm})
+}
+
+// AddFAMDVaccine notes a foot and mouth disease vaccination on a cow
+func (s *SmartContract) AddFAMDVaccine(ctx contractapi.TransactionContextInterface, cowRef string, farmId string, farmAddr string, farmTel string, breedHead string, item string, sex string, age string, idNo string, vaccinationDate string) error {
+	//'{"Args":["addFAMDVaccine","COW0", "farm_id", "farm_addr", "farm_tel", "breed_head", "item", "sex", "age", "id_no", "vaccination_date"]}'
+
+	return s.AddFAMDVaccineJSON(ctx, FAMDVaccinePayload{Cow: cowRef, Farm_id: farmId, Farm_addr: farmAddr, Farm_tel: farmTel, Breed_head: breedHead, Item: item, Sex: sex, Age: age, Id_no: idNo, Vaccination_date: vaccinationDate})
+}
+
+// AddFAMDVaccineJSON is addFAMDVaccine with named fields
+func (s *SmartContract) AddFAMDVaccineJSON(ctx contractapi.TransactionContextInterface, payload FAMDVaccinePayload) error {
+	variables := []string{"addFAMDVaccine.farm_id", "addFAMDVaccine.farm_addr", "addFAMDVaccine.farm_tel", "addFAMDVaccine.breed_head", "addFAMDVaccine.item", "addFAMDVaccine.sex", "addFAMDVaccine.age", "addFAMDVaccine.id_no", "addFAMDVaccine.vaccination_date"}
+
+	return s.addCowRemarks(ctx, payload.Cow, variables, []string{payload.Farm_id, payload.Farm_addr, payload.Farm_tel, payload.Breed_head, payload.Item, payload.Sex, payload.Age, payload.Id_no, payload.Vaccination_date})
+}
+
+// AddInfoDead notes the death of a cow
+func (s *SmartContract) AddInfoDead(ctx contractapi.TransactionContextInterface, cowRef string, farmId string, idNo string, detDate string, detReason string, detMethod string) error {
+	//'{"Args":["addInfoDead","COW0", "farm_id", "id_no", "det_date", "det_reason", "det_method"]}'
+	//det_date		-- date of death, YYYYMMDD
+	//det_reason	-- cause of death
+	//det_method	-- disposal method of the carcass (burial, incineration, rendering)
+
+	return s.AddInfoDeadJSON(ctx, DeadPayload{Cow: cowRef, Farm_id: farmId, Id_no: idNo, Det_date: detDate, Det_reason: detReason, Det_method: detMethod})
+}
+
+// AddInfoDeadJSON is addInfoDead with named fields
+func (s *SmartContract) AddInfoDeadJSON(ctx contractapi.TransactionContextInterface, payload DeadPayload) error {
+	variables := []string{"addInfoDead.farm_id", "addInfoDead.id_no", "addInfoDead.det_date", "addInfoDead.det_reason", "addInfoDead.det_method"}
+	values := []string{payload.Farm_id, payload.Id_no, payload.Det_date, payload.Det_reason, payload.Det_method}
+	if payload.Veterinarian_no != "" {
+		variables = append(variables, "addInfoDead.veterinarian_no", "addInfoDead.veterinarian_nm")
+		values = append(values, payload.Veterinarian_no, payload.Veterinarian_nm)
+	}
+
+	if err := validateDeath(payload); err != nil {
+		return err
+	}
+
+	APIstub := ctx.GetStub()
+
+	key, cow, err := getLiveCow(APIstub, payload.Cow)
+	if err != nil {
+		return err
+	}
+
+	cow.setCowRemarks(variables, values)
+	cow.Dead = true
+
+	// death statistics read the index, not the cows
+	if err := putDeathIndex(APIstub, cow); err != nil {
+		return err
+	}
+
+	return putRecord(APIstub, key, &cow)
+}
+
+// AddInfoDeliver notes the delivery of a cow to the slaughterhouse
+func (s *SmartContract) AddInfoDeliver(ctx contractapi.TransactionContextInterface, cowRef string, idNo string, rfidNo string) error {
+	//'{"Args":["addInfoDeliver","COW0", "id_no", "rfid_no"]}'
+
+	return s.AddInfoDeliverJSON(ctx, DeliverPayload{Cow: cowRef, Id_no: idNo, Rfid_no: rfidNo})
+}
+
+// AddInfoDeliverJSON is addInfoDeliver with named fields
+func (s *SmartContract) AddInfoDeliverJSON(ctx contractapi.TransactionContextInterface, payload DeliverPayload) error {
+	variables := []string{"addInfoDeliver.id_no", "addInfoDeliver.rfid_no"}
+
+	return s.addCowRemarks(ctx, payload.Cow, variables, []string{payload.Id_no, payload.Rfid_no})
+}
+
+// AddInfoInspect notes the slaughter inspection of a cow
+func (s *SmartContract) AddInfoInspect(ctx contractapi.TransactionContextInterface, cowRef string, livestock string, idNo string, weight string, slaughterNm string, sealNo string, slaughterDate string, farmId string, farmAddr string, haccpYn string, faleMethod string, inspectionDate string, inspectionPart string, inspectionUserNm string, veterinarianNo string) error {
+	//'{"Args":["addInfoInspect","COW0", "livestock", "id_no", "weight", "slaughter_nm", "seal_no", "slaughter_date", "farm_id", "farm_addr", "haccp_yn", "fale_method", "inspection_date", "inspection_part", "inspection_user_nm", "veterinarian_no"]}'
+
+	return s.AddInfoInspectJSON(ctx, InspectPayload{Cow: cowRef, Livestock: livestock, Id_no: idNo, Weight: weight, Slaughter_nm: slaughterNm, Seal_no: sealNo, Slaughter_date: slaughterDate, Farm_id: farmId, Farm_addr: farmAddr, Haccp_yn: haccpYn, Fale_method: faleMethod, Inspection_date: inspectionDate, Inspection_part: inspectionPart, Inspection_user_nm: inspectionUserNm, Veterinarian_no: veterinarianNo})
+}
+
+// AddInfoInspectJSON is addInfoInspect with named fields
+func (s *SmartContract) AddInfoInspectJSON(ctx contractapi.TransactionContextInterface, payload InspectPayload) error {
+	variables := []string{"addInfoInspect.livestock", "addInfoInspect.id_no", "addInfoInspect.weight", "addInfoInspect.slaughter_nm", "addInfoInspect.seal_no", "addInfoInspect.slaughter_date", "addInfoInspect.farm_id", "addInfoInspect.farm_addr", "addInfoInspect.haccp_yn", "addInfoInspect.fale_method", "addInfoInspect.inspection_date", "addInfoInspect.inspection_part", "addInfoInspect.inspection_user_nm", "addInfoInspect.veterinarian_no"}
+
+	APIstub := ctx.GetStub()
+
+	key, cow, err := getLiveCow(APIstub, payload.Cow)
+	if err != nil {
+		return err
+	}
+
+	// meat of a cow inside a withdrawal period may hold drug residues
+	if err := checkWithdrawal(APIstub, cow, payload.Slaughter_date, payload.Inspection_date); err != nil {
+		return err
+	}
+
+	cow.setCowRemarks(variables, []string{payload.Livestock, payload.Id_no, payload.Weight, payload.Slaughter_nm, payload.Seal_no, payload.Slaughter_date, payload.Farm_id, payload.Farm_addr, payload.Haccp_yn, payload.Fale_method, payload.Inspection_date, payload.Inspection_part, payload.Inspection_user_nm, payload.Veterinarian_no})
+
+	return putRecord(APIstub, key, &cow)
+}
+
+// AddInfoGradeResult notes the carcass grading of a cow
+func (s *SmartContract) AddInfoGradeResult(ctx contractapi.TransactionContextInterface, cowRef string, gradeDate string, qualityPart string, qualityNm string, subscriberNm string, subscriberBirth string, subscriberCompany string, subscriberAddr string, slaughterNm string, slaughterAddr string, idNo string, weight string, meatQualityGrade string, meatWeightGrade string, gradeHead string) error {
+	//'{"Args":["addInfoGradeResult","COW0", "grade_date", "quality_part", "quality_nm", "subscriber_nm", "subscriber_birth", "subscriber_company", "subscriber_addr", "slaughter_nm", "slaughter_addr", "id_no", "weight", "meat_quality_grade", "meat_weight_grade", "grade_head"]}'
+
+	return s.AddInfoGradeResultJSON(ctx, GradeResultPayload{Cow: cowRef, Grade_date: gradeDate, Quality_part: qualityPart, Quality_nm: qualityNm, Subscriber_nm: subscriberNm, Subscriber_birth: subscriberBirth, Subscriber_company: subscriberCompany, Subscriber_addr: subscriberAddr, Slaughter_nm: slaughterNm, Slaughter_addr: slaughterAddr, Id_no: idNo, Weight: weight, Meat_quality_grade: meatQualityGrade, Meat_weight_grade: meatWeightGrade, Grade_head: gradeHead})
+}
+
+// AddInfoGradeResultJSON is addInfoGradeResult with named fields
+func (s *SmartContract) AddInfoGradeResultJSON(ctx contractapi.TransactionContextInterface, payload GradeResultPayload) error {
+	variables := []string{"addInfoGradeResult.grade_date", "addInfoGradeResult.quality_part", "addInfoGradeResult.quality_nm", "addInfoGradeResult.subscriber_nm", "addInfoGradeResult.subscriber_birth", "addInfoGradeResult.subscriber_company", "addInfoGradeResult.subscriber_addr", "addInfoGradeResult.slaughter_nm", "addInfoGradeResult.slaughter_addr", "addInfoGradeResult.id_no", "addInfoGradeResult.weight", "addInfoGradeResult.meat_quality_grade", "addInfoGradeResult.meat_weight_grade", "addInfoGradeResult.grade_head", "addInfoGradeResult.index_farm"}
+
+	APIstub := ctx.GetStub()
+
+	key, cow, err := getLiveCow(APIstub, payload.Cow)
+	if err != nil {
+		return err
+	}
+
+	previous := lastRemarkEvent(cow.Remarks, "addInfoGradeResult")
+	cow.setCowRemarks(variables, []string{payload.Grade_date, payload.Quality_part, payload.Quality_nm, payload.Subscriber_nm, payload.Subscriber_birth, payload.Subscriber_company, payload.Subscriber_addr, payload.Slaughter_nm, payload.Slaughter_addr, payload.Id_no, payload.Weight, payload.Meat_quality_grade, payload.Meat_weight_grade, payload.Grade_head, cowFarm(cow)})
+
+	// grade statistics read the index, not the cows
+	if err := putGradeIndex(APIstub, cow, previous, lastRemarkEvent(cow.Remarks, "addInfoGradeResult")); err != nil {
+		return err
+	}
+
+	return putRecord(APIstub, key, &cow)
+}
+
+// AddInfoInProcessesReportPurchase notes the purchase of a carcass by a processor
+func (s *SmartContract) AddInfoInProcessesReportPurchase(ctx contractapi.TransactionContextInterface, cowRef string, barcodeId string, dealDate string, origin string, part string, weight string, purchaseNm string, purchaseBizNo string) error {
+	//'{"Args":["addInfoInProcessesReportPurchase","COW0", "barcode_id", "deal_date", "origin", "part", "weight", "purchase_nm", "purchase_biz_no"]}'
+
+	return s.AddInfoInProcessesReportPurchaseJSON(ctx, PurchaseReportPayload{Cow: cowRef, Barcode_id: barcodeId, Deal_date: dealDate, Origin: origin, Part: part, Weight: weight, Purchase_nm: purchaseNm, Purchase_biz_no: purchaseBizNo})
+}
+
+// AddInfoInProcessesReportPurchaseJSON is addInfoInProcessesReportPurchase with named fields
+func (s *SmartContract) AddInfoInProcessesReportPurchaseJSON(ctx contractapi.TransactionContextInterface, payload PurchaseReportPayload) error {
+	variables := []string{"addInfoInProcessesReportPurchase.barcode_id", "addInfoInProcessesReportPurchase.deal_date", "addInfoInProcessesReportPurchase.origin", "addInfoInProcessesReportPurchase.part", "addInfoInProcessesReportPurchase.weight", "addInfoInProcessesReportPurchase.purchase_nm", "addInfoInProcessesReportPurchase.purchase_biz_no"}
+
+	return s.addCowReport(ctx, payload.Cow, "addInfoInProcessesReportPurchase", variables, []string{payload.Barcode_id, payload.Deal_date, payload.Origin, payload.Part, payload.Weight, payload.Purchase_nm, payload.Purchase_biz_no})
+}
+
+// AddInfoReportPacking notes the packing report of a processor
+func (s *SmartContract) AddInfoReportPacking(ctx contractapi.TransactionContextInterface, cowRef string, idNo string, barcodeId string, packageDate string, part string, weight string, purchaseNm string, purchaseBizNo string) error {
+	//'{"Args":["addInfoReportPacking","COW0", "id_no", "barcode_id", "package_date", "part", "weight", "purchase_nm", "purchase_biz_no"]}'
+
+	return s.AddInfoReportPackingJSON(ctx, PackingReportPayload{Cow: cowRef, Id_no: idNo, Barcode_id: barcodeId, Package_date: packageDate, Part: part, Weight: weight, Purchase_nm: purchaseNm, Purchase_biz_no: purchaseBizNo})
+}
+
+// AddInfoReportPackingJSON is addInfoReportPacking with named fields
+func (s *SmartContract) AddInfoReportPackingJSON(ctx contractapi.TransactionContextInterface, payload PackingReportPayload) error {
+	variables := []string{"addInfoReportPacking.id_no", "addInfoReportPacking.barcode_id", "addInfoReportPacking.package_date", "addInfoReportPacking.part", "addInfoReportPacking.weight", "addInfoReportPacking.purchase_nm", "addInfoReportPacking.purchase_biz_no"}
+
+	return s.addCowReport(ctx, payload.Cow, "addInfoReportPacking", variables, []string{payload.Id_no, payload.Barcode_id, payload.Package_date, payload.Part, payload.Weight, payload.Purchase_nm, payload.Purchase_biz_no})
+}
+
+// AddInfoReportSale notes the sales report of a processor
+func (s *SmartContract) AddInfoReportSale(ctx contractapi.TransactionContextInterface, cowRef string, idNo string, barcodeId string, saleDate string, part string, weight string, saleNm string, saleBizNo string) error {
+	//'{"Args":["addInfoReportSale","COW0", "id_no", "barcode_id", "sale_date", "part", "weight", "sale_nm", "sale_biz_no"]}'
+
+	return s.AddInfoReportSaleJSON(ctx, SaleReportPayload{Cow: cowRef, Id_no: idNo, Barcode_id: barcodeId, Sale_date: saleDate, Part: part, Weight: weight, Sale_nm: saleNm, Sale_biz_no: saleBizNo})
+}
+
+// AddInfoReportSaleJSON is addInfoReportSale with named fields
+func (s *SmartContract) AddInfoReportSaleJSON(ctx contractapi.TransactionContextInterface, payload SaleReportPayload) error {
+	variables := []string{"addInfoReportSale.id_no", "addInfoReportSale.barcode_id", "addInfoReportSale.sale_date", "addInfoReportSale.part", "addInfoReportSale.weight", "addInfoReportSale.sale_nm", "addInfoReportSale.sale_biz_no"}
+
+	return s.addCowReport(ctx, payload.Cow, "addInfoReportSale", variables, []string{payload.Id_no, payload.Barcode_id, payload.Sale_date, payload.Part, payload.Weight, payload.Sale_nm, payload.Sale_biz_no})
+}
+
+// AddInfoInSalesReportPurchase notes the purchase of meat by a seller
+func (s *SmartContract) AddInfoInSalesReportPurchase(ctx contractapi.TransactionContextInterface, cowRef string, barcodeId string, dealDate string, origin string, part string, weight string, purchaseNm string, purchaseBizNo string) error {
+	//'{"Args":["addInfoInSalesReportPurchase","COW0", "barcode_id", "deal_date", "origin", "part", "weight", "purchase_nm", "purchase_biz_no"]}'
+
+	return s.AddInfoInSalesReportPurchaseJSON(ctx, PurchaseReportPayload{Cow: cowRef, Barcode_id: barcodeId, Deal_date: dealDate, Origin: origin, Part: part, Weight: weight, Purchase_nm: purchaseNm, Purchase_biz_no: purchaseBizNo})
+}
+
+// AddInfoInSalesReportPurchaseJSON is addInfoInSalesReportPurchase with named fields
+func (s *SmartContract) AddInfoInSalesReportPurchaseJSON(ctx contractapi.TransactionContextInterface, payload PurchaseReportPayload) error {
+	variables := []string{"addInfoInSalesReportPurchase.barcode_id", "addInfoInSalesReportPurchase.deal_date", "addInfoInSalesReportPurchase.origin", "addInfoInSalesReportPurchase.part", "addInfoInSalesReportPurchase.weight", "addInfoInSalesReportPurchase.purchase_nm", "addInfoInSalesReportPurchase.purchase_biz_no"}
+
+	return s.addCowReport(ctx, payload.Cow, "addInfoInSalesReportPurchase", variables, []string{payload.Barcode_id, payload.Deal_date, payload.Origin, payload.Part, payload.Weight, payload.Purchase_nm, payload.Purchase_biz_no})
+}
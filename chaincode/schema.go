@@ -0,0 +1,178 @@
+package chaincode
+
+import (
+	"encoding/json"
+)
+
+// Every stored document carries the version of the layout it was written with
+// in schemaVersion. Documents written before versioning was introduced have no
+// schemaVersion and decode as version 0.
+//
+// The decode functions below are the only way records are read: they upgrade
+// an older document step by step to currentSchemaVersion, so handlers always
+// work on the current layout. The upgraded document is written back the next
+// time the record changes, or by the migrate function for all records at once.
+//
+//	version 1: Owner.Biz_no, the business number owner keys are derived from
+//	version 2: no layout change, grade results are indexed by month and farm
+//	           (migrate indexes the cows graded before)
+//	version 3: no layout change, cows are indexed by Father_id
+//	           (migrate indexes the cows registered before)
+//	version 4: Cow.Dead, set for cows with an addInfoDead record, deaths are
+//	           indexed by month and farm (migrate indexes the earlier deaths)
+//	version 5: Cow.Archived, set while a cow is archived
+//	version 6: Stamp on every record and remark, the transaction that last wrote
+//	           the record or added the remark (none on older writes)
+//	version 7: Owner.Owner_type and the details of the type, taken from the
+//	           owner id and the registerOwner remarks of older owners, owners
+//	           are indexed by type (migrate indexes the owners registered before)
+//	version 8: no layout change, purchase, packing and sale reports are indexed
+//	           by month and the business that filed them (migrate indexes the
+//	           earlier reports under the current owner of the cow)
+//	version 9: Bundle.Cold_chain, the temperature and humidity breaches of the
+//	           bundle (bundles without readings have none)
+const currentSchemaVersion = 9
+
+func decodeCow(data []byte, cow *Cow) error {
+	if err := json.Unmarshal(data, cow); err != nil {
+		return err
+	}
+	cow.storedRemarks = len(cow.Remarks)
+	return upgradeCow(cow)
+}
+
+func decodeOwner(data []byte, owner *Owner) error {
+	if err := json.Unmarshal(data, owner); err != nil {
+		return err
+	}
+	owner.storedRemarks = len(owner.Remarks)
+	return upgradeOwner(owner)
+}
+
+func decodeBundle(data []byte, bundle *Bundle) error {
+	if err := json.Unmarshal(data, bundle); err != nil {
+		return err
+	}
+	return upgradeBundle(bundle)
+}
+
+func decodeHACCP(data []byte, haccp *HACCP) error {
+	if err := json.Unmarshal(data, haccp); err != nil {
+		return err
+	}
+	return upgradeHACCP(haccp)
+}
+
+func decodeRFID(data []byte, rfid *RFID) error {
+	if err := json.Unmarshal(data, rfid); err != nil {
+		return err
+	}
+	return upgradeRFID(rfid)
+}
+
+func upgradeCow(cow *Cow) error {
+	if cow.SchemaVersion > currentSchemaVersion {
+		return newerSchemaError("Cow", cow.SchemaVersion)
+	}
+	// the owner copy inside the cow follows the owner layout
+	if err := upgradeOwner(&cow.Owner); err != nil {
+		return err
+	}
+	if cow.SchemaVersion < 4 {
+		cow.Dead = lastRemarkEvent(cow.Remarks, "addInfoDead") != nil
+	}
+	cow.SchemaVersion = currentSchemaVersion
+	return nil
+}
+
+func upgradeOwner(owner *Owner) error {
+	if owner.SchemaVersion > currentSchemaVersion {
+		return newerSchemaError("Owner", owner.SchemaVersion)
+	}
+	if owner.SchemaVersion < 1 {
+		// version 0 owners have no Biz_no, and their type specific remarks were
+		// written from the wrong argument positions, so the owner id is the only
+		// reliable number
+		if owner.Biz_no == "" {
+			owner.Biz_no = owner.Owner_id
+		}
+	}
+	if owner.SchemaVersion < 7 && owner.Owner_type == "" {
+		owner.Owner_type = legacyOwnerType(owner.Owner_id)
+		// the slaughterhouse fields were remarks, written from the right positions since version 1
+		if event := lastRemarkEvent(owner.Remarks, "registerOwner"); owner.Owner_type == OwnerTypeSlaughterhouse && owner.SchemaVersion >= 1 && event != nil {
+			owner.Slaughterhouse = &SlaughterhouseDetails{Tel: event["slaughter_tel"], Reg_no: event["slaughter_reg_no"]}
+		}
+	}
+	owner.SchemaVersion = currentSchemaVersion
+	return nil
+}
+
+func upgradeBundle(bundle *Bundle) error {
+	if bundle.SchemaVersion > currentSchemaVersion {
+		return newerSchemaError("Bundle", bundle.SchemaVersion)
+	}
+	bundle.SchemaVersion = currentSchemaVersion
+	return nil
+}
+
+func upgradeHACCP(haccp *HACCP) error {
+	if haccp.SchemaVersion > currentSchemaVersion {
+		return newerSchemaError("HACCP", haccp.SchemaVersion)
+	}
+	haccp.SchemaVersion = currentSchemaVersion
+	return nil
+}
+
+func upgradeRFID(rfid *RFID) error {
+	if rfid.SchemaVersion > currentSchemaVersion {
+		return newerSchemaError("RFID", rfid.SchemaVersion)
+	}
+	rfid.SchemaVersion = currentSchemaVersion
+	return nil
+}
+
+// newerSchemaError refuses documents written by a newer chaincode, rewriting
+// them with this version would silently drop their new fields
+func newerSchemaError(docType string, version int) error {
+	return invalidState("%s schemaVersion %d is newer than supported version %d", docType, version, currentSchemaVersion)
+}
+
+// upgradedDocument returns a stored document in the current layout. docType is
+// one of the record types accepted by query (COW, OWNER, BUNDLE, HACCP, RFID).
+func upgradedDocument(docType string, data []byte) ([]byte, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	var record interface{}
+	var err error
+	switch docType {
+	case "COW":
+		cow := Cow{}
+		err = decodeCow(data, &cow)
+		record = cow
+	case "OWNER":
+		owner := Owner{}
+		err = decodeOwner(data, &owner)
+		record = owner
+	case "BUNDLE":
+		bundle := Bundle{}
+		err = decodeBundle(data, &bundle)
+		record = bundle
+	case "HACCP":
+		haccp := HACCP{}
+		err = decodeHACCP(data, &haccp)
+		record = haccp
+	case "RFID":
+		rfid := RFID{}
+		err = decodeRFID(data, &rfid)
+		record = rfid
+	default:
+		return nil, internalError("unknown document type %s", docType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(record)
+}
@@ -0,0 +1,43 @@
+package chaincode_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStamps(t *testing.T) {
+	c := newContract(t)
+	c.registerFarm("", "FARM0")
+	c.registerCow("", "180501-1", "M", "FARM0")
+	c.submit("addRemark", "180501-1", "note", "by the farmer")
+	registered := c.cow("180501-1")
+
+	// the next transaction is timestamped a second later
+	c.ledger.SetTime(time.Date(2020, 3, 1, 9, 29, 59, 0, time.UTC))
+	result := c.ledger.Submit(c.admin, "addRemark", "180501-1", "note", "by the administrator")
+	if err := result.Err(); err != nil {
+		t.Fatal(err)
+	}
+	cow := c.cow("180501-1")
+
+	if cow.Stamp == nil || cow.Stamp.Tx_id != result.TxID || cow.Stamp.Tx_time != "2020-03-01T09:30:00Z" || cow.Stamp.Msp_id != "Org1MSP" || !strings.Contains(cow.Stamp.Subject, "CN=admin1") {
+		t.Errorf("cow stamped %+v, want the transaction %s of admin1 at 2020-03-01T09:30:00Z", cow.Stamp, result.TxID)
+	}
+	if len(cow.Remarks) != len(registered.Remarks)+1 {
+		t.Fatalf("cow has %d remarks, want %d", len(cow.Remarks), len(registered.Remarks)+1)
+	}
+	// remarks keep the stamp of the transaction that added them
+	for i, remark := range registered.Remarks {
+		if remark.Stamp == nil || *cow.Remarks[i].Stamp != *remark.Stamp || !strings.Contains(remark.Stamp.Subject, "CN=farmer1") {
+			t.Errorf("remark %s stamped %+v, want %+v of farmer1", remark.Key, cow.Remarks[i].Stamp, remark.Stamp)
+		}
+	}
+	if last := cow.Remarks[len(cow.Remarks)-1]; last.Stamp == nil || *last.Stamp != *cow.Stamp {
+		t.Errorf("new remark stamped %+v, want %+v", last.Stamp, cow.Stamp)
+	}
+
+	if owner := c.cow("180501-1").Owner; owner.Stamp != nil {
+		t.Errorf("owner copied into the cow carries a stamp %+v", owner.Stamp)
+	}
+}
@@ -0,0 +1,369 @@
+package chaincode
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// The trace certificate is what a retailer shows a consumer for a cow or a
+// bundle. It is built only from ledger data, so the same ledger state always
+// gives the same certificate. Its hash is the SHA-256 of the canonical JSON of
+// the certificate (encoding/json of TraceCertificateBody: fields in declaration
+// order, no white space) and is what goes into the QR code. verifyTraceCertificate
+// rebuilds the certificate at every version of the cow to check a printed hash.
+//
+// The hash is an integrity hash, not a signature: anyone can compute it from a
+// certificate, so a hash alone proves nothing about where the certificate came
+// from. A certificate is genuine when verifyTraceCertificate, answered by the
+// peers from the ledger, finds its hash.
+const traceCertificateVersion = 1
+
+type TraceCertificate struct {
+	Certificate TraceCertificateBody `json:"certificate"`
+	// Hash is the integrity hash of Certificate, checked with verifyTraceCertificate
+	Hash string `json:"hash"`
+	// Qr_payload is the text to print as a QR code
+	Qr_payload string `json:"qr_payload"`
+}
+
+type TraceCertificateBody struct {
+	Version      int                `json:"version"`
+	Subject_type string             `json:"subject_type"`
+	Subject_id   string             `json:"subject_id"`
+	Cow          TraceCow           `json:"cow"`
+	Bundle       *TraceBundle       `json:"bundle,omitempty" metadata:",optional"`
+	Farms        []TraceOwner       `json:"farms"`
+	Vaccinations []TraceVaccination `json:"vaccinations"`
+	Inspection   *TraceInspection   `json:"inspection,omitempty" metadata:",optional"`
+	Grade        *TraceGrade        `json:"grade,omitempty" metadata:",optional"`
+	Processors   []TraceOwner       `json:"processors"`
+	Sellers      []TraceOwner       `json:"sellers"`
+	Summary      TraceSummary       `json:"summary"`
+}
+
+type TraceCow struct {
+	Id_no      string `json:"id_no"`
+	Birth_date string `json:"birth_date"`
+	Sex        string `json:"sex"`
+	Origin     string `json:"origin"`
+	Father_id  string `json:"father_id"`
+	Mother_id  string `json:"mother_id"`
+}
+
+type TraceBundle struct {
+	Barcode_id      string `json:"barcode_id"`
+	Package_date    string `json:"package_date"`
+	Part            string `json:"part"`
+	Weight          string `json:"weight"`
+	Purchase_nm     string `json:"purchase_nm"`
+	Purchase_biz_no string `json:"purchase_biz_no"`
+	// Cold_chain_breaches leaves out when a breach ended, so a printed certificate stays valid until the next breach
+	Cold_chain_breaches []TraceColdChainBreach `json:"cold_chain_breaches,omitempty" metadata:",optional"`
+}
+
+type TraceColdChainBreach struct {
+	Measure    string  `json:"measure"`
+	Started_at string  `json:"started_at"`
+	Value      float64 `json:"value"`
+	Min        float64 `json:"min"`
+	Max        float64 `json:"max"`
+}
+
+// TraceOwner leaves out the personal data of the owner
+type TraceOwner struct {
+	Biz_no     string `json:"biz_no"`
+	Owner_id   string `json:"owner_id"`
+	Owner_nm   string `json:"owner_nm"`
+	Owner_addr string `json:"owner_addr"`
+}
+
+type TraceVaccination struct {
+	Type   string `json:"type"`
+	Date   string `json:"date"`
+	Method string `json:"method"`
+	Result string `json:"result"`
+}
+
+type TraceInspection struct {
+	Slaughter_nm    string `json:"slaughter_nm"`
+	Slaughter_date  string `json:"slaughter_date"`
+	Inspection_date string `json:"inspection_date"`
+	Haccp_yn        string `json:"haccp_yn"`
+	Weight          string `json:"weight"`
+}
+
+type TraceGrade struct {
+	Grade_date         string `json:"grade_date"`
+	Meat_quality_grade string `json:"meat_quality_grade"`
+	Meat_weight_grade  string `json:"meat_weight_grade"`
+	Weight             string `json:"weight"`
+}
+
+type TraceSummary struct {
+	Ko string `json:"ko"`
+	En string `json:"en"`
+}
+
+type TraceVerification struct {
+	Valid bool `json:"valid"`
+	// Current is false for a certificate of an earlier state of the cow
+	Current      bool   `json:"current"`
+	Tx_id        string `json:"tx_id"`
+	Current_hash string `json:"current_hash"`
+}
+
+// GetTraceCertificate returns the certificate of a cow or a bundle
+func (s *SmartContract) GetTraceCertificate(ctx contractapi.TransactionContextInterface, ref string) (*TraceCertificate, error) {
+	//'{"Args":["getTraceCertificate", "180501-2"]}'
+	//'{"Args":["getTraceCertificate", "8801234567890"]}'
+	//ref	-- cow (Id_no or legacy key) or bundle (Barcode_id or legacy key)
+
+	versions, bundle, err := traceSubject(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTraceCertificate(versions, bundle)
+}
+
+// VerifyTraceCertificate checks a certificate hash against the ledger, which is
+// what proves a printed certificate genuine
+func (s *SmartContract) VerifyTraceCertificate(ctx contractapi.TransactionContextInterface, ref string, hash string) (*TraceVerification, error) {
+	//'{"Args":["verifyTraceCertificate", "180501-2", "9f86d0..."]}'
+
+	versions, bundle, err := traceSubject(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	// the breaches of a bundle are only ever added, its earlier states are the first breaches of its current one
+	breaches := 0
+	if bundle != nil && bundle.Cold_chain != nil {
+		breaches = len(bundle.Cold_chain.Breaches)
+	}
+
+	verification := TraceVerification{}
+	for i := len(versions); i > 0; i-- {
+		for n := breaches; n >= 0; n-- {
+			subject := bundle
+			if bundle != nil && n < breaches {
+				subject = bundleBeforeBreaches(bundle, n)
+			}
+			certificate, err := newTraceCertificate(versions[:i], subject)
+			if err != nil {
+				return nil, err
+			}
+			if i == len(versions) && n == breaches {
+				verification.Current_hash = certificate.Hash
+			}
+			if certificate.Hash == strings.ToLower(hash) {
+				verification.Valid = true
+				verification.Current = i == len(versions) && n == breaches
+				verification.Tx_id = versions[i-1].txID
+				return &verification, nil
+			}
+		}
+	}
+	return &verification, nil
+}
+
+// cowVersion is one state of a cow from the key history
+type cowVersion struct {
+	cow       Cow
+	txID      string
+	timestamp int64
+}
+
+// traceSubject reads the history of the cow ref points to, directly or through a bundle
+func traceSubject(ctx contractapi.TransactionContextInterface, ref string) ([]cowVersion, *Bundle, error) {
+	APIstub := ctx.GetStub()
+
+	var bundle *Bundle
+	key, _, err := getCow(APIstub, ref)
+	if err != nil {
+		_, b, bundleErr := getBundle(APIstub, ref)
+		if bundleErr != nil {
+			return nil, nil, notFound("Cow or bundle does not exist: %s", ref)
+		}
+		bundle = &b
+		if key, _, err = getCow(APIstub, b.Id_no); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	versions, err := cowHistory(APIstub, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(versions) == 0 {
+		return nil, nil, notFound("Cow has no history: %s", ref)
+	}
+	return versions, bundle, nil
+}
+
+// cowHistory reads every state the cow at key had, oldest first
+func cowHistory(APIstub shim.ChaincodeStubInterface, key string) ([]cowVersion, error) {
+	resultsIterator, err := APIstub.GetHistoryForKey(key)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	versions := []cowVersion{}
+	for resultsIterator.HasNext() {
+		modification, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		if modification.IsDelete {
+			continue
+		}
+		version := cowVersion{txID: modification.TxId}
+		if err := decodeCow(modification.Value, &version.cow); err != nil {
+			return nil, err
+		}
+		if modification.Timestamp != nil {
+			version.timestamp = modification.Timestamp.Seconds*1e9 + int64(modification.Timestamp.Nanos)
+		}
+		versions = append(versions, version)
+	}
+
+	// peers differ in the order they return the history in
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].timestamp < versions[j].timestamp
+	})
+	return versions, nil
+}
+
+// addedRemarks are the remarks version i of a cow added, remarks are only ever appended
+func addedRemarks(versions []cowVersion, i int) []Remark {
+	remarks := versions[i].cow.Remarks
+	if i == 0 {
+		return remarks
+	}
+	if count := len(versions[i-1].cow.Remarks); len(remarks) > count {
+		return remarks[count:]
+	}
+	return nil
+}
+
+// newTraceCertificate builds the certificate as of the last of versions
+func newTraceCertificate(versions []cowVersion, bundle *Bundle) (*TraceCertificate, error) {
+	cow := versions[len(versions)-1].cow
+
+	body := TraceCertificateBody{
+		Version:      traceCertificateVersion,
+		Subject_type: "COW",
+		Subject_id:   cow.Id_no,
+		Cow:          TraceCow{Id_no: cow.Id_no, Birth_date: cow.Birth_date, Sex: cow.Sex, Origin: cow.Origin, Father_id: cow.Father_id, Mother_id: cow.Mother_id},
+		Farms:        []TraceOwner{},
+		Vaccinations: []TraceVaccination{},
+		Processors:   []TraceOwner{},
+		Sellers:      []TraceOwner{},
+	}
+	if bundle != nil {
+		body.Subject_type = "BUNDLE"
+		body.Subject_id = bundle.Barcode_id
+		body.Bundle = &TraceBundle{Barcode_id: bundle.Barcode_id, Package_date: bundle.Package_date, Part: bundle.Part, Weight: bundle.Weight, Purchase_nm: bundle.Purchase_nm, Purchase_biz_no: bundle.Purchase_biz_no}
+		if bundle.Cold_chain != nil {
+			for _, breach := range bundle.Cold_chain.Breaches {
+				body.Bundle.Cold_chain_breaches = append(body.Bundle.Cold_chain_breaches, TraceColdChainBreach{Measure: breach.Measure, Started_at: breach.Started_at, Value: breach.Value, Min: breach.Range.Min, Max: breach.Range.Max})
+			}
+		}
+	}
+
+	// every owner the cow had, in order
+	slaughterhouse := ""
+	for i, version := range versions {
+		owner := version.cow.Owner
+		if i > 0 && owner.Biz_no == versions[i-1].cow.Owner.Biz_no {
+			continue
+		}
+		traceOwner := TraceOwner{Biz_no: owner.Biz_no, Owner_id: owner.Owner_id, Owner_nm: owner.Owner_nm, Owner_addr: owner.Owner_addr}
+		switch owner.Owner_type {
+		case OwnerTypeFarm:
+			body.Farms = append(body.Farms, traceOwner)
+		case OwnerTypeSlaughterhouse:
+			slaughterhouse = owner.Owner_nm
+		case OwnerTypeProcessor:
+			body.Processors = append(body.Processors, traceOwner)
+		case OwnerTypeSeller, OwnerTypeWholesaler, OwnerTypeImporter, OwnerTypeRestaurant:
+			body.Sellers = append(body.Sellers, traceOwner)
+		}
+	}
+	if bundle != nil && bundle.Purchase_biz_no != "" {
+		body.Sellers = append(body.Sellers, TraceOwner{Biz_no: bundle.Purchase_biz_no, Owner_nm: bundle.Purchase_nm})
+	}
+
+	for _, event := range remarkEvents(cow.Remarks, "addFAMDVaccine") {
+		body.Vaccinations = append(body.Vaccinations, TraceVaccination{Type: "FMD", Date: event["vaccination_date"]})
+	}
+	for _, event := range remarkEvents(cow.Remarks, "addBTVaccine") {
+		body.Vaccinations = append(body.Vaccinations, TraceVaccination{Type: "TB/BR", Date: event["inspection_date"], Method: event["inspection_method"], Result: event["inspection_result"]})
+	}
+
+	if event := lastRemarkEvent(cow.Remarks, "addInfoInspect"); event != nil {
+		body.Inspection = &TraceInspection{Slaughter_nm: event["slaughter_nm"], Slaughter_date: event["slaughter_date"], Inspection_date: event["inspection_date"], Haccp_yn: event["haccp_yn"], Weight: event["weight"]}
+		slaughterhouse = event["slaughter_nm"]
+	}
+	if event := lastRemarkEvent(cow.Remarks, "addInfoGradeResult"); event != nil {
+		body.Grade = &TraceGrade{Grade_date: event["grade_date"], Meat_quality_grade: event["meat_quality_grade"], Meat_weight_grade: event["meat_weight_grade"], Weight: event["weight"]}
+	}
+
+	body.Summary = traceSummary(body, slaughterhouse)
+
+	bodyAsBytes, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(bodyAsBytes)
+	hash := hex.EncodeToString(digest[:])
+
+	return &TraceCertificate{Certificate: body, Hash: hash, Qr_payload: "fabcow:" + body.Subject_id + ":" + hash}, nil
+}
+
+// traceSummary is the consumer facing text of a certificate
+func traceSummary(body TraceCertificateBody, slaughterhouse string) TraceSummary {
+	farmsKo, farmsEn := "미등록 농장", "an unregistered farm"
+	if len(body.Farms) > 0 {
+		names := []string{}
+		for _, farm := range body.Farms {
+			names = append(names, farm.Owner_nm)
+		}
+		farmsKo = strings.Join(names, ", ")
+		farmsEn = farmsKo
+	}
+
+	ko := fmt.Sprintf("이력번호 %s, %s 출생, 원산지 %s. 사육농장: %s. 예방접종 및 검사 %d건.", body.Cow.Id_no, body.Cow.Birth_date, body.Cow.Origin, farmsKo, len(body.Vaccinations))
+	en := fmt.Sprintf("Traceability number %s, born %s, origin %s. Raised at %s. %d vaccinations and inspections.", body.Cow.Id_no, body.Cow.Birth_date, body.Cow.Origin, farmsEn, len(body.Vaccinations))
+
+	if body.Inspection != nil || slaughterhouse != "" {
+		where := slaughterhouse
+		if body.Inspection != nil && body.Inspection.Slaughter_date != "" {
+			where = strings.TrimSpace(where + " " + body.Inspection.Slaughter_date)
+		}
+		ko += fmt.Sprintf(" 도축: %s.", where)
+		en += fmt.Sprintf(" Slaughtered at %s.", where)
+	}
+	if body.Grade != nil {
+		ko += fmt.Sprintf(" 육질등급 %s, 육량등급 %s.", body.Grade.Meat_quality_grade, body.Grade.Meat_weight_grade)
+		en += fmt.Sprintf(" Quality grade %s, yield grade %s.", body.Grade.Meat_quality_grade, body.Grade.Meat_weight_grade)
+	}
+	if body.Bundle != nil {
+		ko += fmt.Sprintf(" 포장: %s %s %skg (%s).", body.Bundle.Package_date, body.Bundle.Part, body.Bundle.Weight, body.Bundle.Barcode_id)
+		en += fmt.Sprintf(" Packed %s, %s %skg (%s).", body.Bundle.Package_date, body.Bundle.Part, body.Bundle.Weight, body.Bundle.Barcode_id)
+		if n := len(body.Bundle.Cold_chain_breaches); n > 0 {
+			ko += fmt.Sprintf(" 보관 온도·습도 이탈 %d건.", n)
+			en += fmt.Sprintf(" %d cold chain breaches.", n)
+		}
+	}
+
+	return TraceSummary{Ko: ko, En: en}
+}
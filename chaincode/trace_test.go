@@ -0,0 +1,91 @@
+package chaincode_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/lotty02cho/fabcow-test/chaincode"
+)
+
+func TestTraceCertificate(t *testing.T) {
+	c := newContract(t)
+	c.registerFarm("", "FARM0")
+	c.registerFarm("", "FARM1")
+	c.registerCow("", "180501-1", "M", "FARM0")
+
+	certificate := func() chaincode.TraceCertificate {
+		t.Helper()
+		result := c.ledger.Evaluate(c.farmer, "getTraceCertificate", "180501-1")
+		if err := result.Err(); err != nil {
+			t.Fatal(err)
+		}
+		certificate := chaincode.TraceCertificate{}
+		if err := json.Unmarshal(result.Payload, &certificate); err != nil {
+			t.Fatal(err)
+		}
+		return certificate
+	}
+	verify := func(hash string) chaincode.TraceVerification {
+		t.Helper()
+		result := c.ledger.Evaluate(c.farmer, "verifyTraceCertificate", "180501-1", hash)
+		if err := result.Err(); err != nil {
+			t.Fatal(err)
+		}
+		verification := chaincode.TraceVerification{}
+		if err := json.Unmarshal(result.Payload, &verification); err != nil {
+			t.Fatal(err)
+		}
+		return verification
+	}
+
+	first := certificate()
+	bodyAsBytes, err := json.Marshal(first.Certificate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest := sha256.Sum256(bodyAsBytes); first.Hash != hex.EncodeToString(digest[:]) {
+		t.Errorf("hash %s is not the SHA-256 of the canonical certificate", first.Hash)
+	}
+	if first.Qr_payload != "fabcow:180501-1:"+first.Hash {
+		t.Errorf("QR payload %q", first.Qr_payload)
+	}
+	if again := certificate(); again.Hash != first.Hash {
+		t.Errorf("certificate of the same ledger state hashed %s, then %s", first.Hash, again.Hash)
+	}
+	// the farm user is personal data
+	if strings.Contains(string(bodyAsBytes), "Kim") || strings.Contains(string(bodyAsBytes), "530118") {
+		t.Errorf("certificate carries the farm user: %s", bodyAsBytes)
+	}
+
+	c.submit("changeCowOwner", "180501-1", "FARM0", "FARM1")
+	second := certificate()
+	if second.Hash == first.Hash {
+		t.Fatalf("certificate hash unchanged after the cow moved to FARM1")
+	}
+
+	tests := []struct {
+		name    string
+		hash    string
+		valid   bool
+		current bool
+	}{
+		{"current certificate", second.Hash, true, true},
+		{"hash in capitals", strings.ToUpper(second.Hash), true, true},
+		{"certificate of an earlier state", first.Hash, true, false},
+		{"unknown hash", strings.Repeat("0", 64), false, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verification := verify(test.hash)
+			if verification.Valid != test.valid || verification.Current != test.current {
+				t.Errorf("verification %+v, want valid %v and current %v", verification, test.valid, test.current)
+			}
+			if verification.Current_hash != second.Hash {
+				t.Errorf("current hash %s, want %s", verification.Current_hash, second.Hash)
+			}
+		})
+	}
+}
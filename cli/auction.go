@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/lotty02cho/fabcow-test/chaincode"
+)
+
+func (a *App) auction(ctx context.Context, args []string) error {
+	return subcommand("auction", args, map[string]func() error{
+		"sell":    func() error { return a.auctionSell(ctx, args[1:]) },
+		"history": func() error { return a.auctionReport(ctx, "history", args[1:]) },
+		"prices":  func() error { return a.auctionReport(ctx, "prices", args[1:]) },
+	})
+}
+
+// auctionSaleFlags names the fields of auction sell
+var auctionSaleFlags = flagLabel(map[string]string{"sale_date": "date", "auction_house": "house", "lot_no": "lot", "hammer_price": "price"})
+
+// auctionSell records the sale of a cow at an auction, which transfers it to the buyer
+func (a *App) auctionSell(ctx context.Context, args []string) error {
+	flags := a.newFlags("auction sell")
+	payload := chaincode.AuctionSalePayload{}
+	flags.StringVar(&payload.Sale_date, "date", "", "sale date, YYYYMMDD")
+	flags.StringVar(&payload.Auction_house, "house", "", "auction market the cow was sold at")
+	flags.StringVar(&payload.Region, "region", "", "region of the auction market")
+	flags.StringVar(&payload.Lot_no, "lot", "", "lot number of the cow")
+	flags.StringVar(&payload.Hammer_price, "price", "", "hammer price in won")
+	flags.StringVar(&payload.Seller, "seller", "", "current owner of the cow")
+	flags.StringVar(&payload.Buyer, "buyer", "", "new owner of the cow")
+	flags.StringVar(&payload.Weight, "weight", "", "live weight in kg at the sale")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usagef("usage: fabcow auction sell COW -date YYYYMMDD -house HOUSE -region REGION -lot LOT -price WON -seller OWNER -buyer OWNER [-weight KG]")
+	}
+	payload.Cow = positional[0]
+	if err := checkAuctionSale(payload, auctionSaleFlags); err != nil {
+		return err
+	}
+
+	if err := a.submit(ctx, "AddAuctionSaleJSON", payload); err != nil {
+		return err
+	}
+	return a.showCow(ctx, payload.Cow)
+}
+
+// auctionReport prints the auction sales (history) or the average prices (prices) of a period
+func (a *App) auctionReport(ctx context.Context, report string, args []string) error {
+	flags := a.newFlags("auction " + report)
+	region := flags.String("region", "", "only the auction markets of this region")
+	from := flags.String("from", "", "first sale date, YYYYMMDD")
+	to := flags.String("to", "", "last sale date, YYYYMMDD")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return usagef("usage: fabcow auction %s [-region REGION] -from YYYYMMDD -to YYYYMMDD", report)
+	}
+	if err := requireFields(flagLabel(nil), map[string]string{"from": *from, "to": *to}); err != nil {
+		return err
+	}
+	if err := checkDate("-from", *from); err != nil {
+		return err
+	}
+	if err := checkDate("-to", *to); err != nil {
+		return err
+	}
+
+	if report == "history" {
+		result, err := a.evaluate(ctx, "GetAuctionPriceHistory", *region, *from, *to)
+		if err != nil {
+			return err
+		}
+		history := chaincode.AuctionPriceHistory{}
+		return a.show(result, &history, func(w io.Writer) { printAuctionPriceHistory(w, history) })
+	}
+	result, err := a.evaluate(ctx, "GetAuctionPriceStats", *region, *from, *to)
+	if err != nil {
+		return err
+	}
+	prices := chaincode.AuctionPriceReport{}
+	return a.show(result, &prices, func(w io.Writer) { printAuctionPriceReport(w, prices) })
+}
+
+func printAuctionPriceHistory(w io.Writer, history chaincode.AuctionPriceHistory) {
+	table := newTable(w)
+	fmt.Fprintln(table, "DATE\tAUCTION HOUSE\tREGION\tLOT\tCOW\tSEX\tAGE\tPRICE\tWEIGHT\tPER KG\tSELLER\tBUYER")
+	for _, sale := range history.Sales {
+		weight, perKg := "", ""
+		if sale.Weight > 0 {
+			weight, perKg = fmt.Sprintf("%g", sale.Weight), fmt.Sprintf("%.0f", sale.Price_per_kg)
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t%s\t%s\t%s\t%s\n", sale.Sale_date, sale.Auction_house, sale.Region, sale.Lot_no, sale.Id_no, sale.Sex, sale.Age_months, sale.Hammer_price, weight, perKg, sale.Seller_nm, sale.Buyer_nm)
+	}
+	table.Flush()
+}
+
+func printAuctionPriceReport(w io.Writer, report chaincode.AuctionPriceReport) {
+	table := newTable(w)
+	region := report.Region
+	if region == "" {
+		region = "all regions"
+	}
+	fmt.Fprintf(table, "Region\t%s\n", region)
+	fmt.Fprintf(table, "Period\t%s - %s\n", report.From, report.To)
+	table.Flush()
+
+	for _, breakdown := range []struct {
+		title string
+		stats []chaincode.AuctionPriceStats
+	}{
+		{"SEX", report.Sexes},
+		{"AGE (MONTHS)", report.Ages},
+		{"REGION", report.Regions},
+		{"MONTH", report.Months},
+	} {
+		fmt.Fprintln(w)
+		table = newTable(w)
+		fmt.Fprintf(table, "%s\tSALES\tAVERAGE\tMIN\tMAX\tPER KG\n", breakdown.title)
+		for _, stats := range append(breakdown.stats, report.Total) {
+			group := stats.Group
+			if group == "" {
+				group = "Total"
+			}
+			fmt.Fprintf(table, "%s\t%d\t%.0f\t%d\t%d\t%.0f\n", group, stats.Sales, stats.Average_price, stats.Min_price, stats.Max_price, stats.Average_price_per_kg)
+		}
+		table.Flush()
+	}
+}
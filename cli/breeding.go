@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/lotty02cho/fabcow-test/chaincode"
+)
+
+func (a *App) breeding(ctx context.Context, args []string) error {
+	return subcommand("breeding", args, map[string]func() error{
+		"inseminate": func() error { return a.inseminate(ctx, args[1:]) },
+		"check":      func() error { return a.pregnancyCheck(ctx, args[1:]) },
+		"calve":      func() error { return a.calve(ctx, args[1:]) },
+		"stats":      func() error { return a.calvingStats(ctx, args[1:]) },
+	})
+}
+
+// inseminationFlags, pregnancyCheckFlags and calvingFlags name the fields of breeding inseminate, check and calve
+var (
+	inseminationFlags   = flagLabel(map[string]string{"insemination_date": "date", "sire_id": "sire", "straw_lot": "straw", "technician_nm": "by"})
+	pregnancyCheckFlags = flagLabel(map[string]string{"check_date": "date", "veterinarian_nm": "vet"})
+	calvingFlags        = flagLabel(map[string]string{"calving_date": "date", "calf_id": "calf", "calf_sex": "sex", "father_id": "sire"})
+)
+
+// inseminate records an artificial insemination of a dam
+func (a *App) inseminate(ctx context.Context, args []string) error {
+	flags := a.newFlags("breeding inseminate")
+	payload := chaincode.InseminationPayload{}
+	flags.StringVar(&payload.Insemination_date, "date", "", "insemination date, YYYYMMDD")
+	flags.StringVar(&payload.Sire_id, "sire", "", "traceability (or KPN) number of the sire of the semen")
+	flags.StringVar(&payload.Straw_lot, "straw", "", "lot number of the semen straw")
+	flags.StringVar(&payload.Technician_nm, "by", "", "who inseminated the dam")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usagef("usage: fabcow breeding inseminate DAM -date YYYYMMDD -sire SIRE [-straw LOT] [-by NAME]")
+	}
+	payload.Cow = positional[0]
+	if err := requireFields(inseminationFlags, map[string]string{"insemination_date": payload.Insemination_date, "sire_id": payload.Sire_id}); err != nil {
+		return err
+	}
+	if err := checkDate("-date", payload.Insemination_date); err != nil {
+		return err
+	}
+
+	if err := a.submit(ctx, "AddInseminationJSON", payload); err != nil {
+		return err
+	}
+	return a.showCow(ctx, payload.Cow)
+}
+
+// pregnancyCheck records a pregnancy check of a dam
+func (a *App) pregnancyCheck(ctx context.Context, args []string) error {
+	flags := a.newFlags("breeding check")
+	payload := chaincode.PregnancyCheckPayload{}
+	flags.StringVar(&payload.Check_date, "date", "", "check date, YYYYMMDD")
+	flags.StringVar(&payload.Result, "result", "", "positive or negative")
+	flags.StringVar(&payload.Method, "method", "", "rectal palpation, ultrasound, blood test, ...")
+	flags.StringVar(&payload.Veterinarian_nm, "vet", "", "who checked the dam")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usagef("usage: fabcow breeding check DAM -date YYYYMMDD -result positive|negative [-method METHOD] [-vet NAME]")
+	}
+	payload.Cow = positional[0]
+	if err := requireFields(pregnancyCheckFlags, map[string]string{"check_date": payload.Check_date, "result": payload.Result}); err != nil {
+		return err
+	}
+	if err := checkDate("-date", payload.Check_date); err != nil {
+		return err
+	}
+	if payload.Result != chaincode.PregnancyPositive && payload.Result != chaincode.PregnancyNegative {
+		return usagef("-result %q is not a result, expecting %s or %s", payload.Result, chaincode.PregnancyPositive, chaincode.PregnancyNegative)
+	}
+
+	if err := a.submit(ctx, "AddPregnancyCheckJSON", payload); err != nil {
+		return err
+	}
+	return a.showCow(ctx, payload.Cow)
+}
+
+// calve records a calving of a dam and prints the calf it registered
+func (a *App) calve(ctx context.Context, args []string) error {
+	flags := a.newFlags("breeding calve")
+	payload := chaincode.CalvingPayload{}
+	flags.StringVar(&payload.Calving_date, "date", "", "calving date, YYYYMMDD")
+	flags.StringVar(&payload.Calf_id, "calf", "", "traceability number of the calf")
+	flags.StringVar(&payload.Calf_sex, "sex", "", "sex of the calf, M or F")
+	flags.StringVar(&payload.Father_id, "sire", "", "sire of the calf (default the sire of the insemination that got the dam in calf)")
+	flags.StringVar(&payload.Origin, "origin", "", "origin of the calf (default the origin of the dam)")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usagef("usage: fabcow breeding calve DAM -date YYYYMMDD -calf ID -sex M|F [-sire SIRE] [-origin ORIGIN]")
+	}
+	payload.Cow = positional[0]
+	if err := requireFields(calvingFlags, map[string]string{"calving_date": payload.Calving_date, "calf_id": payload.Calf_id, "calf_sex": payload.Calf_sex}); err != nil {
+		return err
+	}
+	if err := checkDate("-date", payload.Calving_date); err != nil {
+		return err
+	}
+	if err := checkSex("-sex", payload.Calf_sex); err != nil {
+		return err
+	}
+
+	if err := a.submit(ctx, "AddCalvingJSON", payload); err != nil {
+		return err
+	}
+	return a.showCow(ctx, payload.Calf_id)
+}
+
+// calvingStats prints the calvings and calving intervals of a farm
+func (a *App) calvingStats(ctx context.Context, args []string) error {
+	flags := a.newFlags("breeding stats")
+	farm := flags.String("farm", "", "calvings of the dams of this farm (Biz_no), all farms when empty")
+	from := flags.String("from", "", "first calving date, YYYYMMDD")
+	to := flags.String("to", "", "last calving date, YYYYMMDD")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return usagef("usage: fabcow breeding stats [-farm FARM] -from YYYYMMDD -to YYYYMMDD")
+	}
+	if err := requireFields(flagLabel(nil), map[string]string{"from": *from, "to": *to}); err != nil {
+		return err
+	}
+	if err := checkDate("-from", *from); err != nil {
+		return err
+	}
+	if err := checkDate("-to", *to); err != nil {
+		return err
+	}
+
+	result, err := a.evaluate(ctx, "GetCalvingStats", *farm, *from, *to)
+	if err != nil {
+		return err
+	}
+	report := chaincode.CalvingReport{}
+	return a.show(result, &report, func(w io.Writer) { printCalvingReport(w, report) })
+}
+
+func printCalvingReport(w io.Writer, report chaincode.CalvingReport) {
+	table := newTable(w)
+	farm := report.Farm
+	if farm == "" {
+		farm = "all farms"
+	}
+	fmt.Fprintf(table, "Farm\t%s\n", farm)
+	fmt.Fprintf(table, "Period\t%s - %s\n", report.From, report.To)
+	table.Flush()
+	fmt.Fprintln(w)
+
+	table = newTable(w)
+	fmt.Fprintln(table, "MONTH\tCALVINGS\tMALES\tFEMALES\tINTERVALS\tAVERAGE\tMIN\tMAX")
+	for _, stats := range append(report.Months, report.Total) {
+		period := stats.Period
+		if period == "" {
+			period = "Total"
+		}
+		fmt.Fprintf(table, "%s\t%d\t%d\t%d\t%d\t%.1f\t%d\t%d\n", period, stats.Calvings, stats.Males, stats.Females, stats.Intervals, stats.Average_interval_days, stats.Min_interval_days, stats.Max_interval_days)
+	}
+	table.Flush()
+}
@@ -0,0 +1,250 @@
+// Package cli is the fabcow command, the operator tool of the traceability
+// ledger. It replaces pasting '{"Args":[...]}' into the peer CLI:
+//
+//	fabcow cow register -id 180501-2 -birth 20180501 -sex M -owner FARM0 ...
+//	fabcow cow show 180501-2
+//	fabcow owner register -type slaughterhouse -id SLAUGHTER0 -biz-no 409-81-00000 ...
+//	fabcow owner show 409-81-00000
+//	fabcow transfer 180501-2 -from FARM0 -to 409-81-00000
+//	fabcow vaccinate fmd 180501-2 -farm-id FARM0 -date 20190301 ...
+//	fabcow vaccinate bt 180501-2 -farm-id FARM0 -date 20190301 ...
+//	fabcow weigh 180501-2 -date 20190301 -weight 212.5
+//	fabcow growth 180501-2 -breed hanwoo
+//	fabcow treat 180501-2 -date 20190301 -drug oxytetracycline -vet-no VET-1234 -withdrawal 28
+//	fabcow holds -owner FARM0
+//	fabcow breeding inseminate 180501-2 -date 20200301 -sire KPN1234 -straw LOT-20-017
+//	fabcow breeding calve 180501-2 -date 20201210 -calf 201210-1 -sex F
+//	fabcow breeding stats -farm FARM0 -from 20200101 -to 20201231
+//	fabcow auction sell 180501-2 -date 20190301 -house Iksan -region Jeonbuk -lot 117 -price 3250000 -seller FARM0 -buyer FARM1
+//	fabcow auction prices -region Jeonbuk -from 20190101 -to 20191231
+//	fabcow trace 180501-2
+//	fabcow trace 180501-2 -verify 9f86d0...
+//	fabcow export cows -format csv -out cows.csv
+//	fabcow export epcis 180501-2 -out 180501-2.jsonld
+//	fabcow import cows herd.csv -dry-run
+//	fabcow import readings logger7.csv
+//	fabcow report 409-81-00001 -from 20200101 -to 20200131
+//
+// Input is checked before anything is sent, so a typo does not cost an
+// endorsement. The network comes from a connection profile, see Profiles.
+// Results are printed for people, or as JSON with -o json.
+//
+// App runs against any gateway.Backend, tests give it an in-memory one.
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/lotty02cho/fabcow-test/gateway"
+)
+
+// Exit codes of Run
+const (
+	ExitOK = 0
+	// ExitFailed is a transaction the ledger rejected or a network failure
+	ExitFailed = 1
+	// ExitUsage is a bad command line or input that failed validation, nothing was sent
+	ExitUsage = 2
+)
+
+// App is one run of the fabcow command
+type App struct {
+	Stdout io.Writer
+	Stderr io.Writer
+	// Connect opens the backend of a connection profile
+	Connect func(profile Profile) (gateway.Backend, error)
+	// Getenv reads FABCOW_PROFILE and FABCOW_PROFILES, os.Getenv if nil
+	Getenv func(key string) string
+
+	output       string
+	profileName  string
+	profilesPath string
+	backend      gateway.Backend
+}
+
+// usageError is a command line Run rejects before connecting
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+func usagef(format string, args ...interface{}) error {
+	return &usageError{message: fmt.Sprintf(format, args...)}
+}
+
+// errFlags is a bad subcommand flag, the flag set already printed why
+var errFlags = &usageError{}
+
+// command is a subcommand, run gets the arguments after its name
+type command struct {
+	usage string
+	run   func(a *App, ctx context.Context, args []string) error
+}
+
+var commands = map[string]command{
+	"cow":       {usage: "cow register|show", run: (*App).cow},
+	"owner":     {usage: "owner register|show", run: (*App).owner},
+	"transfer":  {usage: "transfer COW -from OWNER -to OWNER", run: (*App).transfer},
+	"vaccinate": {usage: "vaccinate fmd|bt COW ...", run: (*App).vaccinate},
+	"weigh":     {usage: "weigh COW -date DATE -weight KG [-method scale|tape] [-by NAME]", run: (*App).weigh},
+	"treat":     {usage: "treat COW -date DATE -drug DRUG -vet-no LICENSE -withdrawal DAYS ...", run: (*App).treat},
+	"holds":     {usage: "holds [-owner OWNER] [-date DATE]", run: (*App).holds},
+	"auction":   {usage: "auction sell|history|prices ...", run: (*App).auction},
+	"breeding":  {usage: "breeding inseminate|check|calve|stats ...", run: (*App).breeding},
+	"growth":    {usage: "growth COW [-breed BREED] | growth [-farm FARM] -from DATE -to DATE", run: (*App).growth},
+	"trace":     {usage: "trace COW|BARCODE [-verify HASH]", run: (*App).trace},
+	"export":    {usage: "export cows|owners|epcis [-archived] [-type TYPE] [-format csv|json] [-from DATE -to DATE] [-out FILE]", run: (*App).export},
+	"report":    {usage: "report BIZNO -from DATE -to DATE [-kind purchase|packing|sale] [-dir DIR]", run: (*App).report},
+	"import":    {usage: "import cows|owners|fmd|bt|weighings|readings|auction-sales FILE [-dry-run] [-chunk N] [-map COLUMN=FIELD,...] [-report FILE]", run: (*App).importCSV},
+}
+
+// Run runs the command line args (without the program name) and returns the exit code
+func (a *App) Run(ctx context.Context, args []string) int {
+	flags := flag.NewFlagSet("fabcow", flag.ContinueOnError)
+	flags.SetOutput(a.Stderr)
+	flags.StringVar(&a.profileName, "profile", "", "connection profile to use (default $FABCOW_PROFILE or the default of the profiles file)")
+	flags.StringVar(&a.profilesPath, "profiles", "", "connection profiles file (default $FABCOW_PROFILES or "+defaultProfilesPath()+")")
+	flags.StringVar(&a.output, "o", "text", "output format, text or json")
+	flags.Usage = func() {
+		fmt.Fprintln(a.Stderr, "usage: fabcow [-profile NAME] [-profiles FILE] [-o text|json] COMMAND ...")
+		names := []string{}
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintln(a.Stderr, "  fabcow "+commands[name].usage)
+		}
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+	if a.output != "text" && a.output != "json" {
+		fmt.Fprintf(a.Stderr, "fabcow: unknown output format %s, expecting text or json\n", a.output)
+		return ExitUsage
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return ExitUsage
+	}
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(a.Stderr, "fabcow: unknown command %s\n", flags.Arg(0))
+		flags.Usage()
+		return ExitUsage
+	}
+
+	// the backend is opened on first use, so bad input never needs a network
+	defer func() {
+		if closer, ok := a.backend.(io.Closer); ok {
+			closer.Close()
+		}
+	}()
+	err := cmd.run(a, ctx, flags.Args()[1:])
+	if err == nil {
+		return ExitOK
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		return ExitOK
+	}
+
+	var usage *usageError
+	if errors.As(err, &usage) {
+		if usage.message != "" {
+			fmt.Fprintln(a.Stderr, "fabcow: "+usage.message)
+		}
+		return ExitUsage
+	}
+	var ledgerErr *gateway.Error
+	if errors.As(err, &ledgerErr) && a.output == "json" {
+		a.printJSON(a.Stderr, ledgerErr)
+		return ExitFailed
+	}
+	fmt.Fprintln(a.Stderr, "fabcow: "+err.Error())
+	return ExitFailed
+}
+
+// connect opens the backend of the selected profile once
+func (a *App) connect() (gateway.Backend, error) {
+	if a.backend != nil {
+		return a.backend, nil
+	}
+	getenv := a.Getenv
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	profileName, profilesPath := a.profileName, a.profilesPath
+	if profileName == "" {
+		profileName = getenv("FABCOW_PROFILE")
+	}
+	if profilesPath == "" {
+		profilesPath = getenv("FABCOW_PROFILES")
+	}
+	if profilesPath == "" {
+		profilesPath = defaultProfilesPath()
+	}
+
+	profiles, err := LoadProfiles(profilesPath)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := profiles.Select(profileName)
+	if err != nil {
+		return nil, err
+	}
+	backend, err := a.Connect(profile)
+	if err != nil {
+		return nil, fmt.Errorf("connecting with profile %s: %s", profile.Name, err)
+	}
+	a.backend = backend
+	return backend, nil
+}
+
+// printJSON writes value as indented JSON
+func (a *App) printJSON(w io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// newFlags is the flag set of a subcommand, it prints its errors itself
+func (a *App) newFlags(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet("fabcow "+name, flag.ContinueOnError)
+	flags.SetOutput(a.Stderr)
+	return flags
+}
+
+// parseFlags parses args into flags, allowing positional arguments before the
+// flags ("transfer COW -to OWNER" as well as "transfer -to OWNER COW"), and
+// returns the positional ones
+func parseFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+	for {
+		if err := flags.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, errFlags
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
@@ -0,0 +1,188 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lotty02cho/fabcow-test/chaincode"
+	"github.com/lotty02cho/fabcow-test/cli"
+	"github.com/lotty02cho/fabcow-test/gateway"
+)
+
+// session runs fabcow commands one after the other against the same in-memory ledger
+type session struct {
+	t        *testing.T
+	backend  *gateway.LedgerBackend
+	profiles string
+	// connects counts the connections the commands opened
+	connects int
+}
+
+func newSession(t *testing.T) *session {
+	t.Helper()
+
+	backend, err := gateway.NewMemoryBackend("Org1MSP", "client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	profiles := filepath.Join(t.TempDir(), "profiles.json")
+	if err := os.WriteFile(profiles, []byte(`{"default": "local", "profiles": {"local": {"backend": "memory"}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return &session{t: t, backend: backend, profiles: profiles}
+}
+
+// run runs the command line args and returns its exit code, standard output and standard error
+func (s *session) run(args ...string) (int, string, string) {
+	stdout, stderr := bytes.Buffer{}, bytes.Buffer{}
+	app := cli.App{
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Connect: func(profile cli.Profile) (gateway.Backend, error) {
+			s.connects++
+			if profile.Name != "local" {
+				return nil, errors.New("unexpected profile " + profile.Name)
+			}
+			return s.backend, nil
+		},
+		Getenv: func(key string) string {
+			if key == "FABCOW_PROFILES" {
+				return s.profiles
+			}
+			return ""
+		},
+	}
+	code := app.Run(context.Background(), args)
+	return code, stdout.String(), stderr.String()
+}
+
+// mustRun runs a command that has to succeed and returns its output
+func (s *session) mustRun(args ...string) string {
+	s.t.Helper()
+	code, stdout, stderr := s.run(args...)
+	if code != cli.ExitOK {
+		s.t.Fatalf("fabcow %s exited with %d: %s", strings.Join(args, " "), code, stderr)
+	}
+	return stdout
+}
+
+func TestCommands(t *testing.T) {
+	s := newSession(t)
+	s.mustRun("owner", "register", "-type", "farm", "-id", "FARM0", "-name", "ChukLim1", "-addr", "Iksan", "-livestock", "C", "-user-name", "Kim", "-user-birth", "530118")
+	s.mustRun("owner", "register", "-type", "farm", "-id", "FARM1", "-name", "ChukLim2", "-addr", "Jeonju", "-livestock", "C", "-user-name", "Lee", "-user-birth", "520202")
+
+	tests := []struct {
+		name string
+		args []string
+		// want is in the standard output
+		want string
+	}{
+		{"register", []string{"cow", "register", "-id", "180501-2", "-birth", "20180501", "-sex", "F", "-father", "901027", "-mother", "910101", "-origin", "Iksan", "-owner", "FARM0"}, "180501-2"},
+		{"show", []string{"cow", "show", "180501-2"}, "FARM0"},
+		{"transfer", []string{"transfer", "180501-2", "-from", "FARM0", "-to", "FARM1"}, "FARM1"},
+		{"show as JSON", []string{"-o", "json", "cow", "show", "180501-2"}, `"Biz_no": "FARM1"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if stdout := s.mustRun(test.args...); !strings.Contains(stdout, test.want) {
+				t.Errorf("fabcow %s printed %q, want %q in it", strings.Join(test.args, " "), stdout, test.want)
+			}
+		})
+	}
+
+	t.Run("export", func(t *testing.T) {
+		records, err := csv.NewReader(strings.NewReader(s.mustRun("export", "cows"))).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 2 || records[0][0] != "id_no" {
+			t.Fatalf("export cows wrote %v, want a header and one cow", records)
+		}
+		cow := map[string]string{}
+		for i, column := range records[0] {
+			cow[column] = records[1][i]
+		}
+		if cow["id_no"] != "180501-2" || cow["owner_biz_no"] != "FARM1" || cow["archived"] != "false" {
+			t.Errorf("export cows wrote %v", cow)
+		}
+
+		cows := []chaincode.CowQueryResult{}
+		if err := json.Unmarshal([]byte(s.mustRun("export", "cows", "-format", "json")), &cows); err != nil {
+			t.Fatal(err)
+		}
+		if len(cows) != 1 || cows[0].Record.Owner.Biz_no != "FARM1" {
+			t.Errorf("export cows -format json wrote %+v", cows)
+		}
+
+		out := filepath.Join(t.TempDir(), "owners.csv")
+		s.mustRun("export", "owners", "-out", out)
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), "FARM0") || !strings.Contains(string(data), "FARM1") {
+			t.Errorf("export owners -out wrote %q", data)
+		}
+	})
+}
+
+func TestExitCodes(t *testing.T) {
+	s := newSession(t)
+	s.mustRun("owner", "register", "-type", "farm", "-id", "FARM0", "-name", "ChukLim1", "-addr", "Iksan", "-livestock", "C", "-user-name", "Kim", "-user-birth", "530118")
+	s.mustRun("cow", "register", "-id", "180501-2", "-birth", "20180501", "-sex", "F", "-owner", "FARM0")
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+		// connects tells whether the command reached the backend
+		connects bool
+		// stderr is in the standard error
+		stderr string
+	}{
+		{"help", []string{"-h"}, cli.ExitOK, false, "usage: fabcow"},
+		{"read", []string{"cow", "show", "180501-2"}, cli.ExitOK, true, ""},
+		{"unknown cow", []string{"cow", "show", "180501-9"}, cli.ExitFailed, true, "not_found"},
+		{"transfer from another owner", []string{"transfer", "180501-2", "-from", "FARM9", "-to", "FARM0"}, cli.ExitFailed, true, "not_found"},
+		{"registered twice", []string{"cow", "register", "-id", "180501-2", "-birth", "20180501", "-sex", "F", "-owner", "FARM0"}, cli.ExitFailed, true, "conflict"},
+		{"no command", []string{}, cli.ExitUsage, false, "usage: fabcow"},
+		{"unknown command", []string{"milk", "180501-2"}, cli.ExitUsage, false, "unknown command milk"},
+		{"unknown flag", []string{"cow", "show", "-color", "180501-2"}, cli.ExitUsage, false, "-color"},
+		{"unknown output format", []string{"-o", "xml", "cow", "show", "180501-2"}, cli.ExitUsage, false, "xml"},
+		{"missing field", []string{"cow", "register", "-id", "180501-3", "-sex", "F", "-owner", "FARM0"}, cli.ExitUsage, false, "-birth"},
+		{"bad sex", []string{"cow", "register", "-id", "180501-3", "-birth", "20180501", "-sex", "X", "-owner", "FARM0"}, cli.ExitUsage, false, "-sex"},
+		{"same owners", []string{"transfer", "180501-2", "-from", "FARM0", "-to", "FARM0"}, cli.ExitUsage, false, "same owner"},
+		{"unknown export", []string{"export", "bundles"}, cli.ExitUsage, false, "usage: fabcow export"},
+		{"unknown import", []string{"import", "milkings", "milkings.csv"}, cli.ExitUsage, false, "auction-sales, bt, cows, fmd, owners, readings, weighings"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			connects := s.connects
+			code, _, stderr := s.run(test.args...)
+			if code != test.code {
+				t.Fatalf("fabcow %s exited with %d, want %d: %s", strings.Join(test.args, " "), code, test.code, stderr)
+			}
+			if connected := s.connects > connects; connected != test.connects {
+				t.Errorf("fabcow %s connected: %v, want %v", strings.Join(test.args, " "), connected, test.connects)
+			}
+			if !strings.Contains(stderr, test.stderr) {
+				t.Errorf("fabcow %s wrote %q to standard error, want %q in it", strings.Join(test.args, " "), stderr, test.stderr)
+			}
+		})
+	}
+
+	t.Run("failure as JSON", func(t *testing.T) {
+		code, _, stderr := s.run("-o", "json", "cow", "show", "180501-9")
+		e := gateway.Error{}
+		if err := json.Unmarshal([]byte(stderr), &e); err != nil || code != cli.ExitFailed || e.Code != "not_found" {
+			t.Errorf("failed read exited with %d and wrote %q, want %d and a not_found error body", code, stderr, cli.ExitFailed)
+		}
+	})
+}
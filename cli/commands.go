@@ -0,0 +1,527 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/lotty02cho/fabcow-test/chaincode"
+)
+
+// errInvalidCertificate fails trace -verify, after the verification is printed
+var errInvalidCertificate = errors.New("the certificate hash does not match the ledger")
+
+// submit runs transaction with payload as its JSON argument
+func (a *App) submit(ctx context.Context, transaction string, payload interface{}) error {
+	backend, err := a.connect()
+	if err != nil {
+		return err
+	}
+	arg, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = backend.Submit(ctx, transaction, string(arg))
+	return err
+}
+
+func (a *App) evaluate(ctx context.Context, transaction string, args ...string) ([]byte, error) {
+	backend, err := a.connect()
+	if err != nil {
+		return nil, err
+	}
+	return backend.Evaluate(ctx, transaction, args...)
+}
+
+// show prints the result of a transaction: as it is with -o json, else decoded
+// into value and printed by render
+func (a *App) show(result []byte, value interface{}, render func(w io.Writer)) error {
+	if a.output == "json" {
+		indented := bytes.Buffer{}
+		if err := json.Indent(&indented, result, "", "  "); err != nil {
+			return err
+		}
+		indented.WriteByte('\n')
+		_, err := indented.WriteTo(a.Stdout)
+		return err
+	}
+	if err := json.Unmarshal(result, value); err != nil {
+		return fmt.Errorf("unexpected result: %s", err)
+	}
+	render(a.Stdout)
+	return nil
+}
+
+func (a *App) showCow(ctx context.Context, cowRef string) error {
+	result, err := a.evaluate(ctx, "ReadCow", cowRef)
+	if err != nil {
+		return err
+	}
+	cow := chaincode.Cow{}
+	return a.show(result, &cow, func(w io.Writer) { printCow(w, cow) })
+}
+
+func (a *App) showOwner(ctx context.Context, ownerRef string) error {
+	result, err := a.evaluate(ctx, "ReadOwner", ownerRef)
+	if err != nil {
+		return err
+	}
+	owner := chaincode.Owner{}
+	return a.show(result, &owner, func(w io.Writer) { printOwner(w, owner) })
+}
+
+// subcommand picks the subcommand of cow and owner
+func subcommand(name string, args []string, subcommands map[string]func() error) error {
+	if len(args) == 0 {
+		return usagef("%s needs a subcommand", name)
+	}
+	run, ok := subcommands[args[0]]
+	if !ok {
+		return usagef("unknown command %s %s", name, args[0])
+	}
+	return run()
+}
+
+func (a *App) cow(ctx context.Context, args []string) error {
+	return subcommand("cow", args, map[string]func() error{
+		"register": func() error { return a.cowRegister(ctx, args[1:]) },
+		"show": func() error {
+			positional, err := parseFlags(a.newFlags("cow show"), args[1:])
+			if err != nil {
+				return err
+			}
+			if len(positional) != 1 {
+				return usagef("usage: fabcow cow show COW")
+			}
+			return a.showCow(ctx, positional[0])
+		},
+	})
+}
+
+// cowFlags names the fields of cow register
+var cowFlags = flagLabel(map[string]string{"id_no": "id", "birth_date": "birth", "father_id": "father", "mother_id": "mother"})
+
+func (a *App) cowRegister(ctx context.Context, args []string) error {
+	flags := a.newFlags("cow register")
+	payload := chaincode.CowPayload{}
+	flags.StringVar(&payload.Id_no, "id", "", "traceability number of the cow")
+	flags.StringVar(&payload.Birth_date, "birth", "", "birth date, YYYYMMDD")
+	flags.StringVar(&payload.Sex, "sex", "", "M or F")
+	flags.StringVar(&payload.Father_id, "father", "", "traceability number of the sire")
+	flags.StringVar(&payload.Mother_id, "mother", "", "traceability number of the dam")
+	flags.StringVar(&payload.Origin, "origin", "", "place of birth")
+	flags.StringVar(&payload.Owner, "owner", "", "owner, its business number")
+	flags.StringVar(&payload.Legacy_key, "legacy-key", "", "legacy COW key to keep as an alias")
+	if err := parseNoArgs(flags, args); err != nil {
+		return err
+	}
+
+	if err := checkCow(payload, cowFlags); err != nil {
+		return err
+	}
+
+	if err := a.submit(ctx, "RegisterCowJSON", payload); err != nil {
+		return err
+	}
+	return a.showCow(ctx, payload.Id_no)
+}
+
+func (a *App) owner(ctx context.Context, args []string) error {
+	return subcommand("owner", args, map[string]func() error{
+		"register": func() error { return a.ownerRegister(ctx, args[1:]) },
+		"show": func() error {
+			positional, err := parseFlags(a.newFlags("owner show"), args[1:])
+			if err != nil {
+				return err
+			}
+			if len(positional) != 1 {
+				return usagef("usage: fabcow owner show BIZ_NO")
+			}
+			return a.showOwner(ctx, positional[0])
+		},
+	})
+}
+
+// ownerFlags names the fields of owner register
+var ownerFlags = flagLabel(map[string]string{"owner_type": "type"})
+
+func (a *App) ownerRegister(ctx context.Context, args []string) error {
+	flags := a.newFlags("owner register")
+	o := ownerFields{}
+	flags.StringVar(&o.Owner_type, "type", "", "farm, slaughterhouse, processor, seller, wholesaler, importer or restaurant")
+	flags.StringVar(&o.Legacy_key, "legacy-key", "", "legacy OWNER key to keep as an alias")
+	flags.StringVar(&o.Id, "id", "", "owner id, the business number of a farm")
+	flags.StringVar(&o.Name, "name", "", "name")
+	flags.StringVar(&o.Addr, "addr", "", "address")
+	flags.StringVar(&o.Livestock, "livestock", "", "livestock kind handled")
+	flags.StringVar(&o.User_name, "user-name", "", "name of the manager or representative")
+	flags.StringVar(&o.User_birth, "user-birth", "", "birth date of the manager or representative")
+	flags.StringVar(&o.Biz_no, "biz-no", "", "business registration number (123-45-67890)")
+	flags.StringVar(&o.Tel, "tel", "", "phone number")
+	flags.StringVar(&o.Market, "market", "", "wholesale market traded at")
+	flags.StringVar(&o.Import_reg_no, "import-reg-no", "", "meat import business registration")
+	flags.StringVar(&o.Origin_countries, "origin-countries", "", "countries imported from, comma separated")
+	flags.StringVar(&o.License_no, "license-no", "", "food service business license")
+	if err := parseNoArgs(flags, args); err != nil {
+		return err
+	}
+
+	registration, err := registerOwner(o, ownerFlags)
+	if err != nil {
+		return err
+	}
+	if err := a.submit(ctx, registration.transaction, registration.payload); err != nil {
+		return err
+	}
+	return a.showOwner(ctx, registration.ref)
+}
+
+func (a *App) transfer(ctx context.Context, args []string) error {
+	flags := a.newFlags("transfer")
+	payload := chaincode.OwnerChangePayload{}
+	flags.StringVar(&payload.From_owner, "from", "", "current owner, its business number")
+	flags.StringVar(&payload.To_owner, "to", "", "new owner, its business number")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usagef("usage: fabcow transfer COW -from OWNER -to OWNER")
+	}
+	payload.Cow = positional[0]
+	if err := requireFields(flagLabel(map[string]string{"from_owner": "from", "to_owner": "to"}), map[string]string{"from_owner": payload.From_owner, "to_owner": payload.To_owner}); err != nil {
+		return err
+	}
+	if payload.From_owner == payload.To_owner {
+		return usagef("-from and -to are the same owner")
+	}
+
+	if err := a.submit(ctx, "ChangeCowOwnerJSON", payload); err != nil {
+		return err
+	}
+	return a.showCow(ctx, payload.Cow)
+}
+
+func (a *App) vaccinate(ctx context.Context, args []string) error {
+	return subcommand("vaccinate", args, map[string]func() error{
+		"fmd": func() error { return a.vaccinateFMD(ctx, args[1:]) },
+		"bt":  func() error { return a.vaccinateBT(ctx, args[1:]) },
+	})
+}
+
+// fmdFlags and btFlags name the fields of vaccinate fmd and vaccinate bt
+var (
+	fmdFlags = flagLabel(map[string]string{"vaccination_date": "date"})
+	btFlags  = flagLabel(map[string]string{"inspection_date": "date", "inspection_head": "head", "inspection_method": "method", "inspection_result": "result", "inspection_part": "part", "inspection_user_nm": "inspector"})
+)
+
+// vaccinateFMD records a foot and mouth disease vaccination
+func (a *App) vaccinateFMD(ctx context.Context, args []string) error {
+	flags := a.newFlags("vaccinate fmd")
+	payload := chaincode.FAMDVaccinePayload{}
+	flags.StringVar(&payload.Farm_id, "farm-id", "", "farm the cow was vaccinated at")
+	flags.StringVar(&payload.Farm_addr, "farm-addr", "", "address of the farm")
+	flags.StringVar(&payload.Farm_tel, "farm-tel", "", "phone number of the farm")
+	flags.StringVar(&payload.Breed_head, "breed-head", "", "head of cattle bred at the farm")
+	flags.StringVar(&payload.Item, "item", "", "vaccine")
+	flags.StringVar(&payload.Sex, "sex", "", "sex of the cow, M or F")
+	flags.StringVar(&payload.Age, "age", "", "age of the cow in months")
+	flags.StringVar(&payload.Id_no, "id-no", "", "traceability number (default the cow)")
+	flags.StringVar(&payload.Vaccination_date, "date", "", "vaccination date, YYYYMMDD")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usagef("usage: fabcow vaccinate fmd COW -farm-id FARM -item VACCINE -date YYYYMMDD ...")
+	}
+	payload.Cow = positional[0]
+	if err := checkFMD(&payload, fmdFlags); err != nil {
+		return err
+	}
+
+	if err := a.submit(ctx, "AddFAMDVaccineJSON", payload); err != nil {
+		return err
+	}
+	return a.showCow(ctx, payload.Cow)
+}
+
+// vaccinateBT records a tuberculosis and brucellosis inspection
+func (a *App) vaccinateBT(ctx context.Context, args []string) error {
+	flags := a.newFlags("vaccinate bt")
+	payload := chaincode.BTVaccinePayload{}
+	flags.StringVar(&payload.Farm_id, "farm-id", "", "farm the cow was inspected at")
+	flags.StringVar(&payload.Farm_nm, "farm-nm", "", "name of the farm")
+	flags.StringVar(&payload.Farm_addr, "farm-addr", "", "address of the farm")
+	flags.StringVar(&payload.Farm_user_nm, "farm-user-nm", "", "name of the farm manager")
+	flags.StringVar(&payload.Farm_user_birth, "farm-user-birth", "", "birth date of the farm manager")
+	flags.StringVar(&payload.Farm_user_addr, "farm-user-addr", "", "address of the farm manager")
+	flags.StringVar(&payload.Inspection_date, "date", "", "inspection date, YYYYMMDD")
+	flags.StringVar(&payload.Inspection_head, "head", "", "head of cattle inspected")
+	flags.StringVar(&payload.Inspection_method, "method", "", "inspection method")
+	flags.StringVar(&payload.Livestock, "livestock", "", "livestock kind")
+	flags.StringVar(&payload.Kind, "kind", "", "tuberculosis or brucellosis")
+	flags.StringVar(&payload.Sex, "sex", "", "sex of the cow, M or F")
+	flags.StringVar(&payload.Age, "age", "", "age of the cow in months")
+	flags.StringVar(&payload.Id_no, "id-no", "", "traceability number (default the cow)")
+	flags.StringVar(&payload.Inspection_result, "result", "", "inspection result")
+	flags.StringVar(&payload.Inspection_part, "part", "", "inspecting office")
+	flags.StringVar(&payload.Inspection_user_nm, "inspector", "", "name of the inspector")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usagef("usage: fabcow vaccinate bt COW -farm-id FARM -date YYYYMMDD -result RESULT ...")
+	}
+	payload.Cow = positional[0]
+	if err := checkBT(&payload, btFlags); err != nil {
+		return err
+	}
+
+	if err := a.submit(ctx, "AddBTVaccineJSON", payload); err != nil {
+		return err
+	}
+	return a.showCow(ctx, payload.Cow)
+}
+
+func (a *App) trace(ctx context.Context, args []string) error {
+	flags := a.newFlags("trace")
+	hash := flags.String("verify", "", "check this certificate hash instead of printing the certificate")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usagef("usage: fabcow trace COW|BARCODE [-verify HASH]")
+	}
+	ref := positional[0]
+
+	if *hash == "" {
+		result, err := a.evaluate(ctx, "GetTraceCertificate", ref)
+		if err != nil {
+			return err
+		}
+		certificate := chaincode.TraceCertificate{}
+		return a.show(result, &certificate, func(w io.Writer) { printTraceCertificate(w, certificate) })
+	}
+
+	result, err := a.evaluate(ctx, "VerifyTraceCertificate", ref, *hash)
+	if err != nil {
+		return err
+	}
+	verification := chaincode.TraceVerification{}
+	if err := a.show(result, &verification, func(w io.Writer) { printTraceVerification(w, ref, verification) }); err != nil {
+		return err
+	}
+	if a.output == "json" {
+		// the output already says so, but scripts check the exit code
+		if err := json.Unmarshal(result, &verification); err != nil {
+			return err
+		}
+	}
+	if !verification.Valid {
+		return errInvalidCertificate
+	}
+	return nil
+}
+
+// export writes every cow or owner as CSV or JSON. The CSV leaves out the
+// personal data of owners and the remarks, use JSON for the full records.
+func (a *App) export(ctx context.Context, args []string) error {
+	if len(args) > 0 && args[0] == "epcis" {
+		return a.exportEPCIS(ctx, args[1:])
+	}
+	flags := a.newFlags("export")
+	archived := flags.Bool("archived", false, "export the archived cows instead")
+	typeName := flags.String("type", "", "export the owners of this type only")
+	format := flags.String("format", "csv", "csv or json")
+	out := flags.String("out", "", "file to write (default standard output)")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 || (positional[0] != "cows" && positional[0] != "owners") {
+		return usagef("usage: fabcow export cows|owners [-archived] [-type TYPE] [-format csv|json] [-out FILE], or fabcow export epcis COW|BARCODE")
+	}
+	if *format != "csv" && *format != "json" {
+		return usagef("unknown export format %s, expecting csv or json", *format)
+	}
+	what := positional[0]
+	if what == "cows" && *typeName != "" {
+		return usagef("-type applies to owners")
+	}
+	if what == "owners" && *archived {
+		return usagef("-archived applies to cows")
+	}
+
+	transaction, args := "QueryAllOwners", []string{}
+	switch {
+	case what == "cows" && *archived:
+		transaction = "QueryArchivedCows"
+	case what == "cows":
+		transaction = "QueryAllCows"
+	case *typeName != "":
+		ownerType, err := parseOwnerType("-type", *typeName)
+		if err != nil {
+			return err
+		}
+		transaction, args = "QueryOwnersByType", []string{string(ownerType)}
+	}
+	result, err := a.evaluate(ctx, transaction, args...)
+	if err != nil {
+		return err
+	}
+
+	var columns []string
+	var rows [][]string
+	if what == "cows" {
+		cows := []chaincode.CowQueryResult{}
+		if err := json.Unmarshal(result, &cows); err != nil {
+			return fmt.Errorf("unexpected result: %s", err)
+		}
+		columns = cowColumns
+		for _, cow := range cows {
+			rows = append(rows, cowRow(cow.Record))
+		}
+	} else {
+		owners := []chaincode.OwnerQueryResult{}
+		if err := json.Unmarshal(result, &owners); err != nil {
+			return fmt.Errorf("unexpected result: %s", err)
+		}
+		columns = ownerColumns
+		for _, owner := range owners {
+			rows = append(rows, ownerRow(owner.Record))
+		}
+	}
+
+	w := a.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	if *format == "csv" {
+		err = writeCSV(w, columns, rows)
+	} else {
+		indented := bytes.Buffer{}
+		if err := json.Indent(&indented, result, "", "  "); err != nil {
+			return err
+		}
+		indented.WriteByte('\n')
+		_, err = indented.WriteTo(w)
+	}
+	if err != nil {
+		return err
+	}
+	if *out != "" {
+		fmt.Fprintf(a.Stderr, "exported %d %s to %s\n", len(rows), what, *out)
+	}
+	return nil
+}
+
+// exportEPCIS writes the GS1 EPCIS 2.0 JSON-LD document of a cow, a bundle or a period
+func (a *App) exportEPCIS(ctx context.Context, args []string) error {
+	flags := a.newFlags("export epcis")
+	from := flags.String("from", "", "first date of the period, YYYYMMDD")
+	to := flags.String("to", "", "last date of the period, YYYYMMDD")
+	out := flags.String("out", "", "file to write (default standard output)")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	period := *from != "" || *to != ""
+	if len(positional) > 1 || (len(positional) == 1) == period {
+		return usagef("usage: fabcow export epcis COW|BARCODE | -from YYYYMMDD -to YYYYMMDD [-out FILE]")
+	}
+
+	transaction, args := "GetEPCISEvents", []string{*from, *to}
+	if period {
+		if err := requireFields(flagLabel(nil), map[string]string{"from": *from, "to": *to}); err != nil {
+			return err
+		}
+		if err := checkDate("-from", *from); err != nil {
+			return err
+		}
+		if err := checkDate("-to", *to); err != nil {
+			return err
+		}
+	} else {
+		transaction, args = "GetEPCISDocument", positional
+	}
+	result, err := a.evaluate(ctx, transaction, args...)
+	if err != nil {
+		return err
+	}
+	document := chaincode.EPCISDocument{}
+	if err := json.Unmarshal(result, &document); err != nil {
+		return fmt.Errorf("unexpected result: %s", err)
+	}
+
+	w := a.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	indented := bytes.Buffer{}
+	if err := json.Indent(&indented, result, "", "  "); err != nil {
+		return err
+	}
+	indented.WriteByte('\n')
+	if _, err := indented.WriteTo(w); err != nil {
+		return err
+	}
+	if *out != "" {
+		fmt.Fprintf(a.Stderr, "exported %d events to %s\n", len(document.EpcisBody.EventList), *out)
+	}
+	return nil
+}
+
+var cowColumns = []string{"id_no", "birth_date", "sex", "father_id", "mother_id", "origin", "owner_biz_no", "owner_type", "owner_nm", "dead", "archived", "remarks"}
+
+func cowRow(cow chaincode.Cow) []string {
+	return []string{cow.Id_no, cow.Birth_date, cow.Sex, cow.Father_id, cow.Mother_id, cow.Origin, cow.Owner.Biz_no, string(cow.Owner.Owner_type), cow.Owner.Owner_nm, strconv.FormatBool(cow.Dead), strconv.FormatBool(cow.Archived != nil), strconv.Itoa(len(cow.Remarks))}
+}
+
+var ownerColumns = []string{"biz_no", "owner_type", "owner_id", "owner_nm", "owner_addr", "livestock", "tel"}
+
+func ownerRow(owner chaincode.Owner) []string {
+	return []string{owner.Biz_no, string(owner.Owner_type), owner.Owner_id, owner.Owner_nm, owner.Owner_addr, owner.Livestock, ownerTel(owner)}
+}
+
+func writeCSV(w io.Writer, columns []string, rows [][]string) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// parseNoArgs parses the flags of a command that takes no positional arguments
+func parseNoArgs(flags *flag.FlagSet, args []string) error {
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usagef("unexpected argument %s", positional[0])
+	}
+	return nil
+}
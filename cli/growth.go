@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/lotty02cho/fabcow-test/chaincode"
+)
+
+// weighFlags names the fields of weigh
+var weighFlags = flagLabel(map[string]string{"weigh_date": "date", "weigher_nm": "by"})
+
+// weigh records a weighing of a cow and prints its growth curve
+func (a *App) weigh(ctx context.Context, args []string) error {
+	flags := a.newFlags("weigh")
+	payload := chaincode.WeighingPayload{}
+	flags.StringVar(&payload.Weigh_date, "date", "", "weigh date, YYYYMMDD")
+	flags.StringVar(&payload.Weight, "weight", "", "live weight in kg")
+	flags.StringVar(&payload.Method, "method", "", "scale or tape")
+	flags.StringVar(&payload.Weigher_nm, "by", "", "who weighed the cow")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usagef("usage: fabcow weigh COW -date YYYYMMDD -weight KG [-method scale|tape] [-by NAME]")
+	}
+	payload.Cow = positional[0]
+	if err := checkWeighing(payload, weighFlags); err != nil {
+		return err
+	}
+
+	if err := a.submit(ctx, "AddWeighingJSON", payload); err != nil {
+		return err
+	}
+	return a.showGrowthCurve(ctx, payload.Cow, "")
+}
+
+// growth prints the growth curve of a cow, or the growth report of a farm
+func (a *App) growth(ctx context.Context, args []string) error {
+	flags := a.newFlags("growth")
+	breed := flags.String("breed", "", "compare the cow to the growth reference of this breed")
+	farm := flags.String("farm", "", "report on the cows of this farm (Biz_no), all farms when empty")
+	from := flags.String("from", "", "first weigh date of a report, YYYYMMDD")
+	to := flags.String("to", "", "last weigh date of a report, YYYYMMDD")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(positional) == 1 && *farm == "" && *from == "" && *to == "":
+		return a.showGrowthCurve(ctx, positional[0], *breed)
+	case len(positional) != 0 || *breed != "":
+		return usagef("usage: fabcow growth COW [-breed BREED] | fabcow growth [-farm FARM] -from YYYYMMDD -to YYYYMMDD")
+	}
+	if err := requireFields(flagLabel(nil), map[string]string{"from": *from, "to": *to}); err != nil {
+		return err
+	}
+	if err := checkDate("-from", *from); err != nil {
+		return err
+	}
+	if err := checkDate("-to", *to); err != nil {
+		return err
+	}
+
+	result, err := a.evaluate(ctx, "GetGrowthReport", *farm, *from, *to)
+	if err != nil {
+		return err
+	}
+	report := chaincode.GrowthReport{}
+	return a.show(result, &report, func(w io.Writer) { printGrowthReport(w, report) })
+}
+
+func (a *App) showGrowthCurve(ctx context.Context, cowRef string, breed string) error {
+	result, err := a.evaluate(ctx, "GetGrowthCurve", cowRef, breed)
+	if err != nil {
+		return err
+	}
+	curve := chaincode.GrowthCurve{}
+	return a.show(result, &curve, func(w io.Writer) { printGrowthCurve(w, curve) })
+}
+
+func printGrowthCurve(w io.Writer, curve chaincode.GrowthCurve) {
+	table := newTable(w)
+	fmt.Fprintf(table, "Cow\t%s (%s, born %s)\n", curve.Id_no, curve.Sex, curve.Birth_date)
+	fmt.Fprintf(table, "Average daily gain\t%.3f kg\n", curve.Average_daily_gain)
+	if curve.Reference != nil {
+		fmt.Fprintf(table, "Reference\t%s %s\n", curve.Reference.Breed, curve.Reference.Sex)
+	}
+	table.Flush()
+	fmt.Fprintln(w)
+
+	table = newTable(w)
+	fmt.Fprintln(table, "DATE\tAGE\tWEIGHT\tDAILY GAIN\tREFERENCE\tDEVIATION")
+	for _, point := range curve.Points {
+		reference, deviation := "", ""
+		if point.Reference_weight > 0 {
+			reference, deviation = fmt.Sprintf("%g", point.Reference_weight), fmt.Sprintf("%+.1f%%", point.Deviation)
+		}
+		fmt.Fprintf(table, "%s\t%d\t%g\t%.3f\t%s\t%s\n", point.Weigh_date, point.Age_days, point.Weight, point.Daily_gain, reference, deviation)
+	}
+	table.Flush()
+}
+
+func printGrowthReport(w io.Writer, report chaincode.GrowthReport) {
+	table := newTable(w)
+	farm := report.Farm
+	if farm == "" {
+		farm = "all farms"
+	}
+	fmt.Fprintf(table, "Farm\t%s\n", farm)
+	fmt.Fprintf(table, "Period\t%s - %s\n", report.From, report.To)
+	fmt.Fprintf(table, "Weighings\t%d\n", report.Weighings)
+	for _, stats := range append([]chaincode.GrowthStats{report.Total}, report.Sexes...) {
+		label := "Total"
+		if stats.Sex != "" {
+			label = "Sex " + stats.Sex
+		}
+		fmt.Fprintf(table, "%s\t%d cows, average %.2f kg, daily gain %.3f kg (%d cows)\n", label, stats.Cows, stats.Average_weight, stats.Average_daily_gain, stats.Gaining)
+	}
+	table.Flush()
+	fmt.Fprintln(w)
+
+	table = newTable(w)
+	fmt.Fprintln(table, "COW\tSEX\tWEIGHINGS\tFIRST\tLAST\tAGE\tDAILY GAIN")
+	for _, cow := range report.Cows {
+		fmt.Fprintf(table, "%s\t%s\t%d\t%s %gkg\t%s %gkg\t%d\t%.3f\n", cow.Id_no, cow.Sex, cow.Weighings, cow.First_date, cow.First_weight, cow.Last_date, cow.Last_weight, cow.Last_age, cow.Daily_gain)
+	}
+	table.Flush()
+}
@@ -0,0 +1,572 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/lotty02cho/fabcow-test/chaincode"
+	"github.com/lotty02cho/fabcow-test/gateway"
+)
+
+// Import reads records from a CSV file with a header row. The columns are the
+// fields of the JSON payload of the transaction (id_no, birth_date, ... for
+// cows) or, for owners, the fields of owner register with owner_type. The
+// readings of data loggers have the columns bundle, logger_id, read_at,
+// temperature and humidity. -map renames the columns of a spreadsheet
+// ("Ear tag=id_no,DOB=birth_date") or leaves one out ("Notes=").
+//
+// Every row is checked like the single record commands check their flags. A
+// dry run stops there. Otherwise the good rows are sent in chunks, one batch
+// transaction per chunk for cows, vaccinations, weighings, readings and
+// auction sales, so a chunk is written completely or not at all; owners go one
+// transaction per row. A batch the chaincode rejects for some of its rows is
+// sent again without them.
+//
+// Progress is kept in a file next to the CSV file after every transaction.
+// When an import stops, on a network failure or an interrupt, running the same
+// command again carries on after the last row written. Rows that failed are
+// reported, not retried: fix them in a new file.
+
+// importKind is what import reads, by its first argument
+type importKind struct {
+	// fields is the payload of a row, its JSON fields are the columns
+	fields interface{}
+	// record checks a row and returns its transaction and payload
+	record func(row []byte, name label) (string, interface{}, error)
+	// batch is the transaction taking a chunk of payloads, none for one transaction per row
+	batch string
+}
+
+var importKinds = map[string]importKind{
+	"cows": {
+		fields: chaincode.CowPayload{},
+		batch:  "RegisterCowBatch",
+		record: func(row []byte, name label) (string, interface{}, error) {
+			payload := chaincode.CowPayload{}
+			if err := json.Unmarshal(row, &payload); err != nil {
+				return "", nil, err
+			}
+			return "RegisterCowJSON", payload, checkCow(payload, name)
+		},
+	},
+	"owners": {
+		fields: ownerFields{},
+		record: func(row []byte, name label) (string, interface{}, error) {
+			o := ownerFields{}
+			if err := json.Unmarshal(row, &o); err != nil {
+				return "", nil, err
+			}
+			registration, err := registerOwner(o, name)
+			return registration.transaction, registration.payload, err
+		},
+	},
+	"fmd": {
+		fields: chaincode.FAMDVaccinePayload{},
+		batch:  "AddFAMDVaccineBatch",
+		record: func(row []byte, name label) (string, interface{}, error) {
+			payload := chaincode.FAMDVaccinePayload{}
+			if err := json.Unmarshal(row, &payload); err != nil {
+				return "", nil, err
+			}
+			return "AddFAMDVaccineJSON", payload, checkFMD(&payload, name)
+		},
+	},
+	"bt": {
+		fields: chaincode.BTVaccinePayload{},
+		batch:  "AddBTVaccineBatch",
+		record: func(row []byte, name label) (string, interface{}, error) {
+			payload := chaincode.BTVaccinePayload{}
+			if err := json.Unmarshal(row, &payload); err != nil {
+				return "", nil, err
+			}
+			return "AddBTVaccineJSON", payload, checkBT(&payload, name)
+		},
+	},
+	"weighings": {
+		fields: chaincode.WeighingPayload{},
+		batch:  "AddWeighingBatch",
+		record: func(row []byte, name label) (string, interface{}, error) {
+			payload := chaincode.WeighingPayload{}
+			if err := json.Unmarshal(row, &payload); err != nil {
+				return "", nil, err
+			}
+			return "AddWeighingJSON", payload, checkWeighing(payload, name)
+		},
+	},
+	"auction-sales": {
+		fields: chaincode.AuctionSalePayload{},
+		batch:  "AddAuctionSaleBatch",
+		record: func(row []byte, name label) (string, interface{}, error) {
+			payload := chaincode.AuctionSalePayload{}
+			if err := json.Unmarshal(row, &payload); err != nil {
+				return "", nil, err
+			}
+			return "AddAuctionSaleJSON", payload, checkAuctionSale(payload, name)
+		},
+	},
+	"readings": {
+		fields: readingFields{},
+		batch:  "AddColdChainReadingBatch",
+		record: func(row []byte, name label) (string, interface{}, error) {
+			r := readingFields{}
+			if err := json.Unmarshal(row, &r); err != nil {
+				return "", nil, err
+			}
+			payload, err := checkReading(r, name)
+			return "AddColdChainReadingJSON", payload, err
+		},
+	},
+}
+
+// maxImportChunk is the largest batch the chaincode takes
+const maxImportChunk = 200
+
+// errRowsFailed fails an import with rows that were not imported, after they are reported
+var errRowsFailed = errors.New("some rows were not imported")
+
+// importRow is a row of the file, a payload to send or the error that keeps it out
+type importRow struct {
+	// line is the line the row starts at in the file, the header is line 1
+	line        int
+	transaction string
+	payload     interface{}
+	err         *rowError
+}
+
+// rowError is a row that was not imported
+type rowError struct {
+	Row     int    `json:"row"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// importProgress is the progress file of an import
+type importProgress struct {
+	Kind string `json:"kind"`
+	// Sha256 is the hash of the file, a changed file can not be resumed
+	Sha256 string `json:"sha256"`
+	// Next is the line of the first row not handled yet
+	Next     int        `json:"next"`
+	Imported int        `json:"imported"`
+	Failed   []rowError `json:"failed"`
+}
+
+// importSummary is the outcome of an import, printed at the end
+type importSummary struct {
+	Kind   string `json:"kind"`
+	File   string `json:"file"`
+	DryRun bool   `json:"dry_run"`
+	Rows   int    `json:"rows"`
+	// Valid is the number of rows a dry run would send
+	Valid    int        `json:"valid,omitempty"`
+	Imported int        `json:"imported"`
+	Failed   []rowError `json:"failed"`
+}
+
+// importKindNames lists the kinds import reads
+func importKindNames() string {
+	names := []string{}
+	for name := range importKinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func (a *App) importCSV(ctx context.Context, args []string) error {
+	flags := a.newFlags("import")
+	dryRun := flags.Bool("dry-run", false, "check every row without sending anything")
+	chunk := flags.Int("chunk", 100, fmt.Sprintf("rows per batch transaction, at most %d", maxImportChunk))
+	mapping := flags.String("map", "", "column renames, \"Column=field,...\", an empty field leaves the column out")
+	reportPath := flags.String("report", "", "write the rows that failed to this CSV file")
+	progressPath := flags.String("progress", "", "progress file (default FILE.progress)")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return usagef("usage: fabcow import cows|owners|fmd|bt|weighings|readings|auction-sales FILE [-dry-run] [-chunk N] [-map COLUMN=FIELD,...] [-report FILE] [-progress FILE]")
+	}
+	kindName, path := positional[0], positional[1]
+	kind, ok := importKinds[kindName]
+	if !ok {
+		return usagef("unknown import %s, expecting one of %s", kindName, importKindNames())
+	}
+	if *chunk < 1 || *chunk > maxImportChunk {
+		return usagef("-chunk must be between 1 and %d", maxImportChunk)
+	}
+	if *progressPath == "" {
+		*progressPath = path + ".progress"
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	rows, err := readImportRows(data, kind, *mapping)
+	if err != nil {
+		return err
+	}
+	summary := importSummary{Kind: kindName, File: path, DryRun: *dryRun, Rows: len(rows), Failed: []rowError{}}
+
+	if *dryRun {
+		for _, row := range rows {
+			if row.err != nil {
+				summary.Failed = append(summary.Failed, *row.err)
+			} else {
+				summary.Valid++
+			}
+		}
+		return a.finishImport(summary, *reportPath)
+	}
+
+	hash := sha256.Sum256(data)
+	progress, err := loadProgress(*progressPath, kindName, hex.EncodeToString(hash[:]))
+	if err != nil {
+		return err
+	}
+
+	pending := []importRow{}
+	for _, row := range rows {
+		if row.line < progress.Next {
+			continue
+		}
+		pending = append(pending, row)
+	}
+	if progress.Next > 0 && len(pending) > 0 {
+		fmt.Fprintf(a.Stderr, "resuming %s at line %d\n", path, pending[0].line)
+	} else if progress.Next > 0 {
+		fmt.Fprintf(a.Stderr, "%s was imported already, see %s\n", path, *progressPath)
+	}
+	for len(pending) > 0 {
+		if err := ctx.Err(); err != nil {
+			fmt.Fprintf(a.Stderr, "import stopped at line %d, run the same command again to resume\n", pending[0].line)
+			return err
+		}
+		size := *chunk
+		if kind.batch == "" {
+			size = 1
+		}
+		if size > len(pending) {
+			size = len(pending)
+		}
+		if err := a.importChunk(ctx, kind, pending[:size], progress); err != nil {
+			fmt.Fprintf(a.Stderr, "import stopped at line %d, run the same command again to resume\n", pending[0].line)
+			return err
+		}
+		progress.Next = pending[size-1].line + 1
+		if err := progress.save(*progressPath); err != nil {
+			return err
+		}
+		pending = pending[size:]
+	}
+	if progress.Next == 0 {
+		// an empty file, remember it was done
+		progress.Next = 2
+		if err := progress.save(*progressPath); err != nil {
+			return err
+		}
+	}
+
+	summary.Imported = progress.Imported
+	summary.Failed = progress.Failed
+	return a.finishImport(summary, *reportPath)
+}
+
+// importChunk sends the good rows of chunk and records the outcome of every row
+// in progress. An error stops the import with nothing of chunk written.
+func (a *App) importChunk(ctx context.Context, kind importKind, chunk []importRow, progress *importProgress) error {
+	failed := []rowError{}
+	send := []importRow{}
+	for _, row := range chunk {
+		if row.err != nil {
+			failed = append(failed, *row.err)
+		} else {
+			send = append(send, row)
+		}
+	}
+
+	if kind.batch == "" {
+		for _, row := range send {
+			err := a.submit(ctx, row.transaction, row.payload)
+			if err != nil {
+				e, ok := rowFailure(err)
+				if !ok {
+					return err
+				}
+				failed = append(failed, rowError{Row: row.line, Code: e.Code, Message: e.Message})
+				continue
+			}
+			progress.Imported++
+		}
+	}
+
+	// a rejected batch writes nothing, it is sent again without the rows it names
+	for kind.batch != "" && len(send) > 0 {
+		payloads := []interface{}{}
+		for _, row := range send {
+			payloads = append(payloads, row.payload)
+		}
+		err := a.submit(ctx, kind.batch, payloads)
+		if err == nil {
+			progress.Imported += len(send)
+			break
+		}
+		items, ok := batchItemErrors(err, len(send))
+		if !ok {
+			return err
+		}
+		rejected := map[int]bool{}
+		for _, item := range items {
+			rejected[item.Index] = true
+			failed = append(failed, rowError{Row: send[item.Index].line, Code: item.Code, Message: item.Message})
+		}
+		remaining := []importRow{}
+		for i, row := range send {
+			if !rejected[i] {
+				remaining = append(remaining, row)
+			}
+		}
+		send = remaining
+	}
+
+	sort.Slice(failed, func(i, j int) bool { return failed[i].Row < failed[j].Row })
+	progress.Failed = append(progress.Failed, failed...)
+	return nil
+}
+
+// rowFailure is the error of the chaincode that rejected a row, other errors
+// (network, internal) stop the import
+func rowFailure(err error) (*gateway.Error, bool) {
+	var e *gateway.Error
+	if !errors.As(err, &e) || e.Code == "unavailable" || e.Code == "internal" {
+		return nil, false
+	}
+	return e, true
+}
+
+// batchItemErrors are the failed items of a batch of size rejected by the chaincode
+func batchItemErrors(err error, size int) ([]chaincode.BatchItemError, bool) {
+	e, ok := rowFailure(err)
+	if !ok || len(e.Details) == 0 {
+		return nil, false
+	}
+	items := []chaincode.BatchItemError{}
+	if json.Unmarshal(e.Details, &items) != nil || len(items) == 0 {
+		return nil, false
+	}
+	for _, item := range items {
+		if item.Index < 0 || item.Index >= size {
+			return nil, false
+		}
+	}
+	return items, true
+}
+
+// readImportRows reads and checks every row of a CSV file
+func readImportRows(data []byte, kind importKind, mapping string) ([]importRow, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, usagef("the file is empty, expecting a header row")
+	}
+	if err != nil {
+		return nil, usagef("%s", err)
+	}
+	fields, err := mapColumns(header, jsonFields(kind.fields), mapping)
+	if err != nil {
+		return nil, err
+	}
+	// messages name the columns as the file does
+	columns := map[string]string{}
+	for i, field := range fields {
+		if field != "" {
+			columns[field] = strings.TrimSpace(header[i])
+		}
+	}
+	name := func(field string) string {
+		if column, ok := columns[field]; ok {
+			return column
+		}
+		return field
+	}
+
+	rows := []importRow{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, usagef("%s", err)
+		}
+		line, _ := reader.FieldPos(0)
+		if len(record) != len(header) {
+			rows = append(rows, importRow{line: line, err: &rowError{Row: line, Code: "invalid_argument", Message: fmt.Sprintf("the row has %d columns, the header %d", len(record), len(header))}})
+			continue
+		}
+		values := map[string]string{}
+		for i, field := range fields {
+			if field != "" {
+				values[field] = strings.TrimSpace(record[i])
+			}
+		}
+		rowJSON, err := json.Marshal(values)
+		if err != nil {
+			return nil, err
+		}
+		row := importRow{line: line}
+		row.transaction, row.payload, err = kind.record(rowJSON, name)
+		if err != nil {
+			row.err = &rowError{Row: line, Code: "invalid_argument", Message: err.Error()}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// mapColumns returns the payload field of every column of header, empty for a
+// column left out
+func mapColumns(header []string, known []string, mapping string) ([]string, error) {
+	renames := map[string]string{}
+	for _, entry := range splitList(mapping) {
+		column, field, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, usagef("-map %q is not COLUMN=FIELD", entry)
+		}
+		renames[strings.TrimSpace(column)] = strings.TrimSpace(field)
+	}
+	isKnown := map[string]bool{}
+	for _, field := range known {
+		isKnown[field] = true
+	}
+
+	fields := make([]string, len(header))
+	seen := map[string]string{}
+	for i, column := range header {
+		column = strings.TrimSpace(column)
+		field, renamed := renames[column]
+		if !renamed {
+			field = column
+		}
+		if field == "" {
+			continue
+		}
+		if !isKnown[field] {
+			return nil, usagef("column %q is not one of %s; rename it with -map %q or leave it out with -map %q", column, strings.Join(known, ", "), column+"=FIELD", column+"=")
+		}
+		if other, ok := seen[field]; ok {
+			return nil, usagef("columns %q and %q are both %s", other, column, field)
+		}
+		seen[field] = column
+		fields[i] = field
+	}
+	for column := range renames {
+		found := false
+		for _, h := range header {
+			found = found || strings.TrimSpace(h) == column
+		}
+		if !found {
+			return nil, usagef("-map names column %q, the file has no such column", column)
+		}
+	}
+	return fields, nil
+}
+
+// jsonFields lists the JSON names of the fields of a payload struct
+func jsonFields(payload interface{}) []string {
+	fields := []string{}
+	t := reflect.TypeOf(payload)
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// loadProgress reads the progress file of an import of the file with hash, a new one if there is none
+func loadProgress(path string, kind string, hash string) (*importProgress, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &importProgress{Kind: kind, Sha256: hash, Failed: []rowError{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	progress := importProgress{}
+	if err := json.Unmarshal(data, &progress); err != nil {
+		return nil, fmt.Errorf("reading progress file %s: %s", path, err)
+	}
+	if progress.Kind != kind {
+		return nil, usagef("progress file %s is of an import of %s, not %s", path, progress.Kind, kind)
+	}
+	if progress.Sha256 != hash {
+		return nil, usagef("the file changed since the import recorded in %s; import the rows not yet imported from a new file, or delete the progress file to start over", path)
+	}
+	return &progress, nil
+}
+
+// save replaces the progress file, an interrupted save leaves the previous one
+func (p *importProgress) save(path string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// finishImport prints the summary and writes the report of failed rows
+func (a *App) finishImport(summary importSummary, reportPath string) error {
+	if reportPath != "" {
+		rows := [][]string{}
+		for _, failed := range summary.Failed {
+			rows = append(rows, []string{fmt.Sprint(failed.Row), failed.Code, failed.Message})
+		}
+		file, err := os.Create(reportPath)
+		if err != nil {
+			return err
+		}
+		err = writeCSV(file, []string{"row", "code", "message"}, rows)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if a.output == "json" {
+		if err := a.printJSON(a.Stdout, summary); err != nil {
+			return err
+		}
+	} else {
+		for _, failed := range summary.Failed {
+			fmt.Fprintf(a.Stdout, "line %d: %s: %s\n", failed.Row, failed.Code, failed.Message)
+		}
+		if summary.DryRun {
+			fmt.Fprintf(a.Stdout, "dry run of %s: %d rows, %d valid, %d invalid\n", summary.File, summary.Rows, summary.Valid, len(summary.Failed))
+		} else {
+			fmt.Fprintf(a.Stdout, "import of %s: %d rows, %d imported, %d failed\n", summary.File, summary.Rows, summary.Imported, len(summary.Failed))
+		}
+	}
+	if len(summary.Failed) > 0 {
+		return errRowsFailed
+	}
+	return nil
+}
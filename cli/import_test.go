@@ -0,0 +1,91 @@
+package cli_test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lotty02cho/fabcow-test/cli"
+)
+
+func TestImport(t *testing.T) {
+	s := newSession(t)
+	s.mustRun("owner", "register", "-type", "farm", "-id", "FARM0", "-name", "ChukLim1", "-addr", "Iksan", "-livestock", "C", "-user-name", "Kim", "-user-birth", "530118")
+	s.mustRun("cow", "register", "-id", "180501-9", "-birth", "20180501", "-sex", "F", "-owner", "FARM0")
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "herd.csv")
+	// line 4 fails the check of the sex, line 5 the chaincode as a cow already registered
+	herd := "Ear tag,DOB,sex,father_id,mother_id,origin,owner,Notes\n" +
+		"180501-1,20180501,F,901027,910101,Iksan,FARM0,calm\n" +
+		"180501-2,20180501,M,901027,910101,Iksan,FARM0,\n" +
+		"180501-3,20180501,X,901027,910101,Iksan,FARM0,\n" +
+		"180501-9,20180501,F,901027,910101,Iksan,FARM0,\n" +
+		"180501-4,20180501,F,901027,910101,Iksan,FARM0,\n"
+	if err := os.WriteFile(path, []byte(herd), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mapping := "Ear tag=id_no,DOB=birth_date,Notes="
+
+	summary := func(stdout string) map[string]interface{} {
+		t.Helper()
+		summary := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(stdout), &summary); err != nil {
+			t.Fatalf("import printed %q: %v", stdout, err)
+		}
+		return summary
+	}
+
+	code, stdout, stderr := s.run("-o", "json", "import", "cows", path, "-map", mapping, "-dry-run")
+	if code != cli.ExitFailed {
+		t.Fatalf("dry run exited with %d: %s", code, stderr)
+	}
+	if dry := summary(stdout); dry["rows"] != 5.0 || dry["valid"] != 4.0 || len(dry["failed"].([]interface{})) != 1 {
+		t.Errorf("dry run summary %v, want 5 rows with 4 valid", dry)
+	}
+	if code, _, _ := s.run("cow", "show", "180501-1"); code != cli.ExitFailed {
+		t.Errorf("dry run registered 180501-1")
+	}
+
+	report := filepath.Join(dir, "failed.csv")
+	code, stdout, stderr = s.run("-o", "json", "import", "cows", path, "-map", mapping, "-chunk", "2", "-report", report)
+	if code != cli.ExitFailed {
+		t.Fatalf("import exited with %d: %s", code, stderr)
+	}
+	if imported := summary(stdout); imported["imported"] != 3.0 || len(imported["failed"].([]interface{})) != 2 {
+		t.Errorf("import summary %v, want 3 imported and 2 failed", imported)
+	}
+	for _, idNo := range []string{"180501-1", "180501-2", "180501-4"} {
+		s.mustRun("cow", "show", idNo)
+	}
+
+	file, err := os.Open(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	failed, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 3 || failed[1][0] != "4" || failed[2][0] != "5" || failed[2][1] != "conflict" {
+		t.Errorf("report %v, want lines 4 and 5, 5 a conflict", failed)
+	}
+
+	t.Run("run again", func(t *testing.T) {
+		code, _, stderr := s.run("import", "cows", path, "-map", mapping)
+		if code != cli.ExitFailed || !strings.Contains(stderr, "imported already") {
+			t.Errorf("second import exited with %d: %s", code, stderr)
+		}
+	})
+
+	t.Run("unknown column", func(t *testing.T) {
+		code, _, stderr := s.run("import", "cows", path, "-dry-run")
+		if code != cli.ExitUsage || !strings.Contains(stderr, "Ear tag") {
+			t.Errorf("import without -map exited with %d: %s", code, stderr)
+		}
+	})
+}
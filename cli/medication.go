@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/lotty02cho/fabcow-test/chaincode"
+)
+
+// treatFlags names the fields of treat
+var treatFlags = flagLabel(map[string]string{"treat_date": "date", "veterinarian_no": "vet-no", "veterinarian_nm": "vet", "withdrawal_days": "withdrawal"})
+
+// treat records a veterinary drug treatment of a cow
+func (a *App) treat(ctx context.Context, args []string) error {
+	flags := a.newFlags("treat")
+	payload := chaincode.TreatmentPayload{}
+	flags.StringVar(&payload.Treat_date, "date", "", "date of the (last) dose, YYYYMMDD")
+	flags.StringVar(&payload.Drug, "drug", "", "drug given")
+	flags.StringVar(&payload.Dose, "dose", "", "dose and route")
+	flags.StringVar(&payload.Veterinarian_no, "vet-no", "", "license number of the prescribing veterinarian")
+	flags.StringVar(&payload.Veterinarian_nm, "vet", "", "name of the prescribing veterinarian")
+	flags.StringVar(&payload.Withdrawal_days, "withdrawal", "", "withdrawal period of the drug in days")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usagef("usage: fabcow treat COW -date YYYYMMDD -drug DRUG -vet-no LICENSE -withdrawal DAYS [-dose DOSE] [-vet NAME]")
+	}
+	payload.Cow = positional[0]
+	if err := requireFields(treatFlags, map[string]string{"treat_date": payload.Treat_date, "drug": payload.Drug, "veterinarian_no": payload.Veterinarian_no}); err != nil {
+		return err
+	}
+	if err := checkDate("-date", payload.Treat_date); err != nil {
+		return err
+	}
+	if payload.Withdrawal_days != "" {
+		if days, err := strconv.Atoi(strings.TrimSpace(payload.Withdrawal_days)); err != nil || days < 0 {
+			return usagef("-withdrawal %q is not a number of days", payload.Withdrawal_days)
+		}
+	}
+
+	if err := a.submit(ctx, "AddTreatmentJSON", payload); err != nil {
+		return err
+	}
+	return a.showCow(ctx, payload.Cow)
+}
+
+// holds lists the cows that can not be slaughtered yet
+func (a *App) holds(ctx context.Context, args []string) error {
+	flags := a.newFlags("holds")
+	owner := flags.String("owner", "", "only the cows of this owner")
+	date := flags.String("date", "", "planned slaughter date, YYYYMMDD (default today)")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return usagef("usage: fabcow holds [-owner OWNER] [-date YYYYMMDD]")
+	}
+	if *date != "" {
+		if err := checkDate("-date", *date); err != nil {
+			return err
+		}
+	}
+
+	result, err := a.evaluate(ctx, "GetWithdrawalHolds", *owner, *date)
+	if err != nil {
+		return err
+	}
+	report := chaincode.WithdrawalReport{}
+	return a.show(result, &report, func(w io.Writer) { printWithdrawalReport(w, report) })
+}
+
+func printWithdrawalReport(w io.Writer, report chaincode.WithdrawalReport) {
+	table := newTable(w)
+	fmt.Fprintln(table, "COW\tOWNER\tCLEAR ON\tDRUG\tTREATED\tWITHDRAWAL\tVETERINARIAN")
+	for _, hold := range report.Cows {
+		for i, treatment := range hold.Treatments {
+			cow, owner, clear := "", "", ""
+			if i == 0 {
+				cow, owner, clear = hold.Id_no, fmt.Sprintf("%s (%s)", hold.Owner_nm, hold.Owner_biz_no), hold.Clear_date
+			}
+			fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%d days\t%s %s\n", cow, owner, clear, treatment.Drug, treatment.Treat_date, treatment.Withdrawal_days, treatment.Veterinarian_nm, treatment.Veterinarian_no)
+		}
+	}
+	table.Flush()
+	fmt.Fprintf(w, "%d cows can not be slaughtered on %s\n", len(report.Cows), report.Date)
+}
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Profiles is the connection profiles file, a JSON object naming the networks
+// an operator works with:
+//
+//	{
+//	  "default": "org1",
+//	  "profiles": {
+//	    "org1": {
+//	      "backend": "fabric",
+//	      "peer": "localhost:7051",
+//	      "peer_host": "peer0.org1.example.com",
+//	      "tls_cert": "org1/tls/ca.crt",
+//	      "msp_id": "Org1MSP",
+//	      "cert": "org1/users/operator/cert.pem",
+//	      "key": "org1/users/operator/key.pem",
+//	      "channel": "mychannel",
+//	      "chaincode": "fabcow"
+//	    },
+//	    "local": {"backend": "memory"}
+//	  }
+//	}
+//
+// Relative file names are relative to the profiles file. A memory profile runs
+// the chaincode in process on an empty ledger that is gone when fabcow exits,
+// it is for trying commands out.
+type Profiles struct {
+	Default  string             `json:"default"`
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+// Profile is one network of the profiles file
+type Profile struct {
+	// Name is the key of the profile in the file
+	Name    string `json:"-"`
+	Backend string `json:"backend"`
+
+	Peer      string `json:"peer"`
+	PeerHost  string `json:"peer_host"`
+	TLSCert   string `json:"tls_cert"`
+	MSPID     string `json:"msp_id"`
+	Cert      string `json:"cert"`
+	Key       string `json:"key"`
+	Channel   string `json:"channel"`
+	Chaincode string `json:"chaincode"`
+	// Role is the organizational unit of the memory backend identity, client by default
+	Role string `json:"role"`
+}
+
+// defaultProfilesPath is fabcow/profiles.json in the user configuration directory
+func defaultProfilesPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "profiles.json"
+	}
+	return filepath.Join(dir, "fabcow", "profiles.json")
+}
+
+// LoadProfiles reads and checks a profiles file
+func LoadProfiles(path string) (*Profiles, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading connection profiles: %s", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	profiles := Profiles{}
+	if err := decoder.Decode(&profiles); err != nil {
+		return nil, fmt.Errorf("reading connection profiles %s: %s", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	for name, profile := range profiles.Profiles {
+		profile.Name = name
+		if err := profile.check(); err != nil {
+			return nil, fmt.Errorf("connection profile %s in %s: %s", name, path, err)
+		}
+		for _, file := range []*string{&profile.TLSCert, &profile.Cert, &profile.Key} {
+			if *file != "" && !filepath.IsAbs(*file) {
+				*file = filepath.Join(dir, *file)
+			}
+		}
+		profiles.Profiles[name] = profile
+	}
+	return &profiles, nil
+}
+
+// Select returns the profile called name, the default profile if name is empty
+func (p *Profiles) Select(name string) (Profile, error) {
+	if name == "" {
+		name = p.Default
+	}
+	if name == "" && len(p.Profiles) == 1 {
+		for only := range p.Profiles {
+			name = only
+		}
+	}
+	if name == "" {
+		return Profile{}, fmt.Errorf("no connection profile selected, use -profile with one of %s", p.names())
+	}
+	profile, ok := p.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown connection profile %s, expecting one of %s", name, p.names())
+	}
+	return profile, nil
+}
+
+func (p *Profiles) names() string {
+	names := []string{}
+	for name := range p.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// check reports the settings a profile can not connect without
+func (p Profile) check() error {
+	switch p.Backend {
+	case "fabric":
+		missing := []string{}
+		for field, value := range map[string]string{"peer": p.Peer, "tls_cert": p.TLSCert, "msp_id": p.MSPID, "cert": p.Cert, "key": p.Key, "channel": p.Channel, "chaincode": p.Chaincode} {
+			if value == "" {
+				missing = append(missing, field)
+			}
+		}
+		if len(missing) > 0 {
+			sort.Strings(missing)
+			return fmt.Errorf("missing %s", strings.Join(missing, ", "))
+		}
+	case "memory":
+	default:
+		return fmt.Errorf("unknown backend %q, expecting fabric or memory", p.Backend)
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package cli
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lotty02cho/fabcow-test/chaincode"
+)
+
+// The checks of each kind of record, shared by the commands that take one
+// record from flags and import that takes many from a CSV file. Messages name
+// the fields with name, so they point at the flag or the column to fix.
+
+func checkCow(payload chaincode.CowPayload, name label) error {
+	if err := requireFields(name, map[string]string{"id_no": payload.Id_no, "birth_date": payload.Birth_date, "sex": payload.Sex, "owner": payload.Owner}); err != nil {
+		return err
+	}
+	if err := checkBirthDate(name("birth_date"), payload.Birth_date); err != nil {
+		return err
+	}
+	return checkSex(name("sex"), payload.Sex)
+}
+
+// checkFMD checks a foot and mouth disease vaccination, the traceability number defaults to the cow
+func checkFMD(payload *chaincode.FAMDVaccinePayload, name label) error {
+	if payload.Id_no == "" {
+		payload.Id_no = payload.Cow
+	}
+	if err := requireFields(name, map[string]string{"cow": payload.Cow, "farm_id": payload.Farm_id, "item": payload.Item, "vaccination_date": payload.Vaccination_date}); err != nil {
+		return err
+	}
+	if err := checkDate(name("vaccination_date"), payload.Vaccination_date); err != nil {
+		return err
+	}
+	if payload.Farm_tel != "" {
+		if err := checkTel(name("farm_tel"), payload.Farm_tel); err != nil {
+			return err
+		}
+	}
+	if payload.Sex != "" {
+		return checkSex(name("sex"), payload.Sex)
+	}
+	return nil
+}
+
+// checkBT checks a tuberculosis and brucellosis inspection, the traceability number defaults to the cow
+func checkBT(payload *chaincode.BTVaccinePayload, name label) error {
+	if payload.Id_no == "" {
+		payload.Id_no = payload.Cow
+	}
+	if err := requireFields(name, map[string]string{"cow": payload.Cow, "farm_id": payload.Farm_id, "inspection_date": payload.Inspection_date, "kind": payload.Kind, "inspection_result": payload.Inspection_result}); err != nil {
+		return err
+	}
+	if err := checkDate(name("inspection_date"), payload.Inspection_date); err != nil {
+		return err
+	}
+	if payload.Sex != "" {
+		return checkSex(name("sex"), payload.Sex)
+	}
+	return nil
+}
+
+// checkWeighing checks a weighing of a cow, the weight is in kg
+func checkWeighing(payload chaincode.WeighingPayload, name label) error {
+	if err := requireFields(name, map[string]string{"cow": payload.Cow, "weigh_date": payload.Weigh_date, "weight": payload.Weight}); err != nil {
+		return err
+	}
+	if err := checkDate(name("weigh_date"), payload.Weigh_date); err != nil {
+		return err
+	}
+	if weight, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(payload.Weight), "kg"), 64); err != nil || weight <= 0 {
+		return usagef("%s %q is not a weight in kg", name("weight"), payload.Weight)
+	}
+	return nil
+}
+
+// checkAuctionSale checks the sale of a cow at an auction, the price is in won and the weight in kg
+func checkAuctionSale(payload chaincode.AuctionSalePayload, name label) error {
+	if err := requireFields(name, map[string]string{"cow": payload.Cow, "sale_date": payload.Sale_date, "auction_house": payload.Auction_house, "region": payload.Region, "lot_no": payload.Lot_no, "hammer_price": payload.Hammer_price, "seller": payload.Seller, "buyer": payload.Buyer}); err != nil {
+		return err
+	}
+	if err := checkDate(name("sale_date"), payload.Sale_date); err != nil {
+		return err
+	}
+	if price, err := strconv.ParseInt(strings.ReplaceAll(strings.TrimSpace(payload.Hammer_price), ",", ""), 10, 64); err != nil || price <= 0 {
+		return usagef("%s %q is not a price in won", name("hammer_price"), payload.Hammer_price)
+	}
+	if payload.Weight != "" {
+		if weight, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(payload.Weight), "kg"), 64); err != nil || weight <= 0 {
+			return usagef("%s %q is not a weight in kg", name("weight"), payload.Weight)
+		}
+	}
+	return nil
+}
+
+// ownerFields is an owner of any type. The registrations of the chaincode name
+// the same fields differently per type (farm_nm, slaughter_nm, ...), these are
+// the names of owner register and of an owners import.
+type ownerFields struct {
+	Owner_type       string `json:"owner_type"`
+	Legacy_key       string `json:"legacy_key"`
+	Id               string `json:"id"`
+	Name             string `json:"name"`
+	Addr             string `json:"addr"`
+	Livestock        string `json:"livestock"`
+	User_name        string `json:"user_name"`
+	User_birth       string `json:"user_birth"`
+	Biz_no           string `json:"biz_no"`
+	Tel              string `json:"tel"`
+	Market           string `json:"market"`
+	Import_reg_no    string `json:"import_reg_no"`
+	Origin_countries string `json:"origin_countries"`
+	License_no       string `json:"license_no"`
+}
+
+// typeFields are the fields of ownerFields that only some owner types take
+func (o ownerFields) typeFields() map[string]string {
+	return map[string]string{"biz_no": o.Biz_no, "tel": o.Tel, "market": o.Market, "import_reg_no": o.Import_reg_no, "origin_countries": o.Origin_countries, "license_no": o.License_no}
+}
+
+// ownerTypeFields lists the type fields of every owner type, the ones it requires first
+var ownerTypeFields = map[chaincode.OwnerType][]string{
+	chaincode.OwnerTypeFarm:           {},
+	chaincode.OwnerTypeSlaughterhouse: {"biz_no", "tel"},
+	chaincode.OwnerTypeProcessor:      {"biz_no"},
+	chaincode.OwnerTypeSeller:         {"biz_no"},
+	chaincode.OwnerTypeWholesaler:     {"biz_no", "tel", "market"},
+	chaincode.OwnerTypeImporter:       {"biz_no", "tel", "import_reg_no", "origin_countries"},
+	chaincode.OwnerTypeRestaurant:     {"biz_no", "tel", "license_no"},
+}
+
+// optionalTypeFields are the type fields the chaincode lets be empty
+var optionalTypeFields = map[string]bool{"market": true}
+
+// ownerRegistration is the registration transaction of an owner
+type ownerRegistration struct {
+	transaction string
+	payload     interface{}
+	// ref is the key to read the owner back with
+	ref string
+}
+
+// registerOwner checks o and picks the registration of its type
+func registerOwner(o ownerFields, name label) (ownerRegistration, error) {
+	ownerType, err := parseOwnerType(name("owner_type"), o.Owner_type)
+	if err != nil {
+		return ownerRegistration{}, err
+	}
+	typeFields := map[string]bool{}
+	for _, field := range ownerTypeFields[ownerType] {
+		typeFields[field] = true
+	}
+	for field, value := range o.typeFields() {
+		if value != "" && !typeFields[field] {
+			return ownerRegistration{}, usagef("%s does not apply to a %s", name(field), strings.ToLower(string(ownerType)))
+		}
+	}
+
+	required := map[string]string{"id": o.Id, "name": o.Name, "addr": o.Addr}
+	if ownerType == chaincode.OwnerTypeFarm {
+		required["livestock"] = o.Livestock
+	}
+	values := o.typeFields()
+	for _, field := range ownerTypeFields[ownerType] {
+		if !optionalTypeFields[field] {
+			required[field] = values[field]
+		}
+	}
+	if err := requireFields(name, required); err != nil {
+		return ownerRegistration{}, err
+	}
+	if typeFields["biz_no"] {
+		if err := checkBizNo(name("biz_no"), o.Biz_no); err != nil {
+			return ownerRegistration{}, err
+		}
+	}
+	if typeFields["tel"] {
+		if err := checkTel(name("tel"), o.Tel); err != nil {
+			return ownerRegistration{}, err
+		}
+	}
+
+	switch ownerType {
+	case chaincode.OwnerTypeFarm:
+		return ownerRegistration{"RegisterFarmJSON", chaincode.FarmPayload{Legacy_key: o.Legacy_key, Farm_id: o.Id, Farm_nm: o.Name, Farm_addr: o.Addr, Livestock: o.Livestock, Farm_user_nm: o.User_name, Farm_user_birth: o.User_birth}, o.Id}, nil
+	case chaincode.OwnerTypeSlaughterhouse:
+		return ownerRegistration{"RegisterSlaughterhouseJSON", chaincode.SlaughterhousePayload{Legacy_key: o.Legacy_key, Slaughter_id: o.Id, Slaughter_nm: o.Name, Slaughter_addr: o.Addr, Handle_livestock: o.Livestock, Slaughter_user_nm: o.User_name, Slaughter_user_birth: o.User_birth, Slaughter_tel: o.Tel, Slaughter_reg_no: o.Biz_no}, o.Biz_no}, nil
+	case chaincode.OwnerTypeProcessor:
+		return ownerRegistration{"RegisterProcessorJSON", chaincode.ProcessorPayload{Legacy_key: o.Legacy_key, Process_id: o.Id, Process_nm: o.Name, Process_addr: o.Addr, Livestock: o.Livestock, Process_user_nm: o.User_name, Process_user_birth: o.User_birth, Process_biz_no: o.Biz_no}, o.Biz_no}, nil
+	case chaincode.OwnerTypeSeller:
+		return ownerRegistration{"RegisterSellerJSON", chaincode.SellerPayload{Legacy_key: o.Legacy_key, Sale_id: o.Id, Sale_nm: o.Name, Sale_addr: o.Addr, Livestock: o.Livestock, Sale_user_nm: o.User_name, Sale_user_birth: o.User_birth, Sale_biz_no: o.Biz_no}, o.Biz_no}, nil
+	case chaincode.OwnerTypeWholesaler:
+		return ownerRegistration{"RegisterWholesalerJSON", chaincode.WholesalerPayload{Legacy_key: o.Legacy_key, Wholesale_id: o.Id, Wholesale_nm: o.Name, Wholesale_addr: o.Addr, Livestock: o.Livestock, Wholesale_user_nm: o.User_name, Wholesale_user_birth: o.User_birth, Wholesale_biz_no: o.Biz_no, Wholesale_tel: o.Tel, Market: o.Market}, o.Biz_no}, nil
+	case chaincode.OwnerTypeImporter:
+		return ownerRegistration{"RegisterImporterJSON", chaincode.ImporterPayload{Legacy_key: o.Legacy_key, Import_id: o.Id, Import_nm: o.Name, Import_addr: o.Addr, Livestock: o.Livestock, Import_user_nm: o.User_name, Import_user_birth: o.User_birth, Import_biz_no: o.Biz_no, Import_tel: o.Tel, Import_reg_no: o.Import_reg_no, Origin_countries: splitList(o.Origin_countries)}, o.Biz_no}, nil
+	}
+	return ownerRegistration{"RegisterRestaurantJSON", chaincode.RestaurantPayload{Legacy_key: o.Legacy_key, Restaurant_id: o.Id, Restaurant_nm: o.Name, Restaurant_addr: o.Addr, Livestock: o.Livestock, Restaurant_user_nm: o.User_name, Restaurant_user_birth: o.User_birth, Restaurant_biz_no: o.Biz_no, Restaurant_tel: o.Tel, License_no: o.License_no}, o.Biz_no}, nil
+}
+
+// parseOwnerType reads an owner type, the chaincode names in any case
+func parseOwnerType(fieldName string, typeName string) (chaincode.OwnerType, error) {
+	if typeName == "" {
+		return "", usagef("missing %s", fieldName)
+	}
+	ownerType := chaincode.OwnerType(strings.ToUpper(typeName))
+	if _, ok := ownerTypeFields[ownerType]; !ok {
+		return "", usagef("unknown owner type %s, expecting farm, slaughterhouse, processor, seller, wholesaler, importer or restaurant", typeName)
+	}
+	return ownerType, nil
+}
+
+// readingFields is a reading of a data logger, its numbers are read here since the columns are text
+type readingFields struct {
+	Bundle      string `json:"bundle"`
+	Logger_id   string `json:"logger_id"`
+	Read_at     string `json:"read_at"`
+	Temperature string `json:"temperature"`
+	Humidity    string `json:"humidity"`
+}
+
+// checkReading checks a reading and returns its payload, humidity is optional
+func checkReading(r readingFields, name label) (chaincode.ColdChainReadingPayload, error) {
+	payload := chaincode.ColdChainReadingPayload{Bundle: r.Bundle, Logger_id: r.Logger_id, Read_at: strings.TrimSpace(r.Read_at)}
+	if err := requireFields(name, map[string]string{"bundle": r.Bundle, "logger_id": r.Logger_id, "read_at": r.Read_at, "temperature": r.Temperature}); err != nil {
+		return payload, err
+	}
+	if _, err := time.Parse(time.RFC3339, payload.Read_at); err != nil {
+		return payload, usagef("%s %q is not a time, expecting RFC 3339 (2019-06-01T09:30:00+09:00)", name("read_at"), r.Read_at)
+	}
+	var err error
+	if payload.Temperature, err = strconv.ParseFloat(strings.TrimSpace(r.Temperature), 64); err != nil {
+		return payload, usagef("%s %q is not a number", name("temperature"), r.Temperature)
+	}
+	if strings.TrimSpace(r.Humidity) != "" {
+		if payload.Humidity, err = strconv.ParseFloat(strings.TrimSpace(r.Humidity), 64); err != nil || payload.Humidity < 0 || payload.Humidity > 100 {
+			return payload, usagef("%s %q is not a percentage", name("humidity"), r.Humidity)
+		}
+		payload.Humidity_measured = true
+	}
+	return payload, nil
+}
@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lotty02cho/fabcow-test/chaincode"
+)
+
+// The text output of the records, one "label  value" line per field. Personal
+// data of owners (manager names and birth dates) is left out, -o json has it.
+
+func newTable(w io.Writer) *tabwriter.Writer {
+	return tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+}
+
+func printCow(w io.Writer, cow chaincode.Cow) {
+	table := newTable(w)
+	fmt.Fprintf(table, "Cow\t%s\n", cow.Id_no)
+	fmt.Fprintf(table, "Birth date\t%s\n", cow.Birth_date)
+	fmt.Fprintf(table, "Sex\t%s\n", cow.Sex)
+	fmt.Fprintf(table, "Father\t%s\n", cow.Father_id)
+	fmt.Fprintf(table, "Mother\t%s\n", cow.Mother_id)
+	fmt.Fprintf(table, "Origin\t%s\n", cow.Origin)
+	fmt.Fprintf(table, "Owner\t%s\n", ownerLabel(cow.Owner))
+	status := "alive"
+	if cow.Dead {
+		status = "dead"
+	}
+	if cow.Archived != nil {
+		status += ", archived on " + cow.Archived.Archived_at + ": " + cow.Archived.Reason
+	}
+	fmt.Fprintf(table, "Status\t%s\n", status)
+	printStamp(table, cow.Stamp)
+	for i, remark := range cow.Remarks {
+		label := ""
+		if i == 0 {
+			label = "Remarks"
+		}
+		fmt.Fprintf(table, "%s\t%s = %s\n", label, remark.Key, remark.Value)
+	}
+	table.Flush()
+}
+
+func printOwner(w io.Writer, owner chaincode.Owner) {
+	table := newTable(w)
+	fmt.Fprintf(table, "Owner\t%s\n", owner.Biz_no)
+	fmt.Fprintf(table, "Type\t%s\n", owner.Owner_type)
+	fmt.Fprintf(table, "Id\t%s\n", owner.Owner_id)
+	fmt.Fprintf(table, "Name\t%s\n", owner.Owner_nm)
+	fmt.Fprintf(table, "Address\t%s\n", owner.Owner_addr)
+	fmt.Fprintf(table, "Livestock\t%s\n", owner.Livestock)
+	if tel := ownerTel(owner); tel != "" {
+		fmt.Fprintf(table, "Tel\t%s\n", tel)
+	}
+	if owner.Wholesaler != nil && owner.Wholesaler.Market != "" {
+		fmt.Fprintf(table, "Market\t%s\n", owner.Wholesaler.Market)
+	}
+	if owner.Importer != nil {
+		fmt.Fprintf(table, "Import reg no\t%s\n", owner.Importer.Import_reg_no)
+		fmt.Fprintf(table, "Origin countries\t%s\n", strings.Join(owner.Importer.Origin_countries, ", "))
+	}
+	if owner.Restaurant != nil {
+		fmt.Fprintf(table, "License no\t%s\n", owner.Restaurant.License_no)
+	}
+	printStamp(table, owner.Stamp)
+	table.Flush()
+}
+
+// ownerLabel is "name (biz no, type)"
+func ownerLabel(owner chaincode.Owner) string {
+	if owner.Owner_type == "" {
+		return fmt.Sprintf("%s (%s)", owner.Owner_nm, owner.Biz_no)
+	}
+	return fmt.Sprintf("%s (%s, %s)", owner.Owner_nm, owner.Biz_no, owner.Owner_type)
+}
+
+// ownerTel is the phone number in the details of the owner type, if it has one
+func ownerTel(owner chaincode.Owner) string {
+	switch {
+	case owner.Slaughterhouse != nil:
+		return owner.Slaughterhouse.Tel
+	case owner.Wholesaler != nil:
+		return owner.Wholesaler.Tel
+	case owner.Importer != nil:
+		return owner.Importer.Tel
+	case owner.Restaurant != nil:
+		return owner.Restaurant.Tel
+	}
+	return ""
+}
+
+func printStamp(table io.Writer, stamp *chaincode.Stamp) {
+	if stamp == nil {
+		return
+	}
+	fmt.Fprintf(table, "Last change\t%s by %s (%s), tx %s\n", stamp.Tx_time, stamp.Subject, stamp.Msp_id, stamp.Tx_id)
+}
+
+func printTraceCertificate(w io.Writer, certificate chaincode.TraceCertificate) {
+	body := certificate.Certificate
+	table := newTable(w)
+	fmt.Fprintf(table, "Trace certificate\t%s %s\n", body.Subject_type, body.Subject_id)
+	fmt.Fprintf(table, "Summary\t%s\n", body.Summary.En)
+	fmt.Fprintf(table, "\t%s\n", body.Summary.Ko)
+	fmt.Fprintf(table, "Cow\t%s, born %s, %s, %s\n", body.Cow.Id_no, body.Cow.Birth_date, body.Cow.Sex, body.Cow.Origin)
+	if body.Bundle != nil {
+		fmt.Fprintf(table, "Bundle\t%s, %s %s, packed %s for %s\n", body.Bundle.Barcode_id, body.Bundle.Part, body.Bundle.Weight, body.Bundle.Package_date, body.Bundle.Purchase_nm)
+	}
+	printTraceOwners(table, "Farms", body.Farms)
+	for i, vaccination := range body.Vaccinations {
+		label := ""
+		if i == 0 {
+			label = "Vaccinations"
+		}
+		fmt.Fprintf(table, "%s\t%s %s %s %s\n", label, vaccination.Date, vaccination.Type, vaccination.Method, vaccination.Result)
+	}
+	if body.Inspection != nil {
+		fmt.Fprintf(table, "Slaughter\t%s on %s, inspected %s, HACCP %s\n", body.Inspection.Slaughter_nm, body.Inspection.Slaughter_date, body.Inspection.Inspection_date, body.Inspection.Haccp_yn)
+	}
+	if body.Grade != nil {
+		fmt.Fprintf(table, "Grade\t%s/%s on %s, %s\n", body.Grade.Meat_quality_grade, body.Grade.Meat_weight_grade, body.Grade.Grade_date, body.Grade.Weight)
+	}
+	printTraceOwners(table, "Processors", body.Processors)
+	printTraceOwners(table, "Sellers", body.Sellers)
+	fmt.Fprintf(table, "Hash\t%s\n", certificate.Hash)
+	fmt.Fprintf(table, "QR\t%s\n", certificate.Qr_payload)
+	table.Flush()
+}
+
+func printTraceOwners(table io.Writer, label string, owners []chaincode.TraceOwner) {
+	for i, owner := range owners {
+		if i > 0 {
+			label = ""
+		}
+		fmt.Fprintf(table, "%s\t%s (%s), %s\n", label, owner.Owner_nm, owner.Biz_no, owner.Owner_addr)
+	}
+}
+
+func printTraceVerification(w io.Writer, ref string, verification chaincode.TraceVerification) {
+	switch {
+	case verification.Valid && verification.Current:
+		fmt.Fprintf(w, "valid: the certificate is the current one of %s\n", ref)
+	case verification.Valid:
+		fmt.Fprintf(w, "valid: the certificate is of an earlier state of %s, written by tx %s\n", ref, verification.Tx_id)
+		fmt.Fprintf(w, "the current hash is %s\n", verification.Current_hash)
+	default:
+		fmt.Fprintf(w, "not valid: no state of %s has this certificate\n", ref)
+	}
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lotty02cho/fabcow-test/chaincode"
+)
+
+// The regulatory files of a business, what it uploads to the national livestock
+// traceability system instead of keying the reports in: one CSV file per kind of
+// report, UTF-8 with a byte order mark, a header row of the Korean column names,
+// dates as YYYYMMDD and business numbers as their ten digits.
+
+// reportLayout is the file of one kind of report
+type reportLayout struct {
+	name    string
+	label   string
+	columns []string
+	entries func(report chaincode.TraceabilityReport) []chaincode.TraceabilityReportEntry
+}
+
+var reportLayouts = []reportLayout{
+	{"purchase", "구입", []string{"신고구분", "신고인사업자번호", "거래일자", "이력번호", "묶음번호", "원산지", "부위", "중량", "거래처명", "거래처사업자번호"},
+		func(report chaincode.TraceabilityReport) []chaincode.TraceabilityReportEntry { return report.Purchases }},
+	{"packing", "포장", []string{"신고구분", "신고인사업자번호", "포장일자", "이력번호", "묶음번호", "부위", "중량", "거래처명", "거래처사업자번호"},
+		func(report chaincode.TraceabilityReport) []chaincode.TraceabilityReportEntry { return report.Packings }},
+	{"sale", "판매", []string{"신고구분", "신고인사업자번호", "판매일자", "이력번호", "묶음번호", "부위", "중량", "판매처명", "판매처사업자번호"},
+		func(report chaincode.TraceabilityReport) []chaincode.TraceabilityReportEntry { return report.Sales }},
+}
+
+func (l reportLayout) row(report chaincode.TraceabilityReport, entry chaincode.TraceabilityReportEntry) []string {
+	row := []string{l.label, bizNoDigits(report.Biz_no), entry.Date, entry.Id_no, entry.Barcode_id}
+	if l.name == "purchase" {
+		row = append(row, entry.Origin)
+	}
+	return append(row, entry.Part, entry.Weight, entry.Partner_nm, bizNoDigits(entry.Partner_biz_no))
+}
+
+// fileName is BIZNO_KIND_FROM_TO.csv
+func (l reportLayout) fileName(report chaincode.TraceabilityReport) string {
+	return fmt.Sprintf("%s_%s_%s_%s.csv", bizNoDigits(report.Biz_no), l.name, report.From, report.To)
+}
+
+// report writes the regulatory files of a business for a period
+func (a *App) report(ctx context.Context, args []string) error {
+	flags := a.newFlags("report")
+	from := flags.String("from", "", "first report date, YYYYMMDD")
+	to := flags.String("to", "", "last report date, YYYYMMDD")
+	dir := flags.String("dir", ".", "directory to write the files to")
+	kind := flags.String("kind", "", "write only this kind of report: purchase, packing or sale")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usagef("usage: fabcow report BIZNO -from YYYYMMDD -to YYYYMMDD [-kind purchase|packing|sale] [-dir DIR]")
+	}
+	if err := requireFields(flagLabel(nil), map[string]string{"from": *from, "to": *to}); err != nil {
+		return err
+	}
+	if err := checkDate("-from", *from); err != nil {
+		return err
+	}
+	if err := checkDate("-to", *to); err != nil {
+		return err
+	}
+	layouts := reportLayouts
+	if *kind != "" {
+		layouts = nil
+		for _, layout := range reportLayouts {
+			if layout.name == strings.ToLower(*kind) {
+				layouts = append(layouts, layout)
+			}
+		}
+		if layouts == nil {
+			return usagef("unknown report kind %s, expecting purchase, packing or sale", *kind)
+		}
+	}
+
+	result, err := a.evaluate(ctx, "GetTraceabilityReport", positional[0], *from, *to)
+	if err != nil {
+		return err
+	}
+	report := chaincode.TraceabilityReport{}
+	if err := json.Unmarshal(result, &report); err != nil {
+		return fmt.Errorf("unexpected result: %s", err)
+	}
+
+	written := []string{}
+	for _, layout := range layouts {
+		path := filepath.Join(*dir, layout.fileName(report))
+		if err := writeReportFile(path, layout, report); err != nil {
+			return err
+		}
+		written = append(written, path)
+	}
+	return a.show(result, &report, func(w io.Writer) {
+		table := newTable(w)
+		fmt.Fprintf(table, "Business\t%s (%s)\n", report.Owner_nm, report.Biz_no)
+		fmt.Fprintf(table, "Period\t%s - %s\n", report.From, report.To)
+		for i, layout := range layouts {
+			fmt.Fprintf(table, "%s\t%d reports\t%s\n", layout.name, len(layout.entries(report)), written[i])
+		}
+		table.Flush()
+	})
+}
+
+// writeReportFile writes the file of one kind of report, also when there were none
+func writeReportFile(path string, layout reportLayout, report chaincode.TraceabilityReport) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	// the byte order mark, Excel takes a CSV without one for the local code page
+	if _, err := file.WriteString("\xef\xbb\xbf"); err != nil {
+		file.Close()
+		return err
+	}
+	writer := csv.NewWriter(file)
+	writer.Write(layout.columns)
+	for _, entry := range layout.entries(report) {
+		writer.Write(layout.row(report, entry))
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// bizNoDigits is a business number without its dashes
+func bizNoDigits(bizNo string) string {
+	return strings.ReplaceAll(bizNo, "-", "")
+}
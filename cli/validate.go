@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The checks of the input before it is sent. They mirror the rules of the
+// chaincode (validateOwner, normalizeDate), which stays the one that decides.
+var (
+	bizNoPattern = regexp.MustCompile(`^[0-9]{3}-?[0-9]{2}-?[0-9]{5}$`)
+	telPattern   = regexp.MustCompile(`^\+?[0-9][0-9 -]{5,18}[0-9]$`)
+)
+
+// label names a field in messages: the flag of a command, the column of an import
+type label func(field string) string
+
+// flagLabel names a payload field by its flag, "farm_id" is -farm-id unless
+// renamed lists another flag
+func flagLabel(renamed map[string]string) label {
+	return func(field string) string {
+		if flag, ok := renamed[field]; ok {
+			return "-" + flag
+		}
+		return "-" + strings.ReplaceAll(field, "_", "-")
+	}
+}
+
+// requireFields fails with every field of values that is empty
+func requireFields(name label, values map[string]string) error {
+	missing := []string{}
+	for field, value := range values {
+		if strings.TrimSpace(value) == "" {
+			missing = append(missing, name(field))
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	return usagef("missing %s", strings.Join(missing, ", "))
+}
+
+// checkDate accepts YYYYMMDD, with or without - . / separators. Unlike the
+// chaincode it also checks the month and the day, a typo here is cheap.
+func checkDate(name string, value string) error {
+	if _, err := time.Parse("20060102", dateDigits(value)); err != nil {
+		return usagef("%s %q is not a date, expecting YYYYMMDD", name, value)
+	}
+	return nil
+}
+
+// checkBirthDate also accepts the YYMMDD of the cows registered first
+func checkBirthDate(name string, value string) error {
+	if _, err := time.Parse("060102", dateDigits(value)); err == nil {
+		return nil
+	}
+	return checkDate(name, value)
+}
+
+func checkSex(name string, value string) error {
+	if value != "M" && value != "F" {
+		return usagef("%s %q is not a sex, expecting M or F", name, value)
+	}
+	return nil
+}
+
+func checkBizNo(name string, value string) error {
+	if !bizNoPattern.MatchString(value) {
+		return usagef("%s %q is not a business registration number (123-45-67890)", name, value)
+	}
+	return nil
+}
+
+func checkTel(name string, value string) error {
+	if !telPattern.MatchString(value) {
+		return usagef("%s %q is not a phone number", name, value)
+	}
+	return nil
+}
+
+// dateDigits drops the separators of a date
+func dateDigits(date string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' || r == '/' {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(date))
+}
+
+// splitList splits a comma separated flag, dropping empty entries
+func splitList(value string) []string {
+	list := []string{}
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
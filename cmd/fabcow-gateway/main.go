@@ -0,0 +1,146 @@
+// Command fabcow-gateway serves the fabcow chaincode over HTTP, see package gateway.
+//
+// With -backend fabric it connects to the Fabric Gateway of a peer as the
+// identity in -cert and -key. With -backend memory it runs the chaincode in
+// process on an in-memory ledger, for local development; nothing is kept when
+// it stops.
+//
+// Every request needs an "Authorization: Bearer <token>" header with a token
+// of the -tokens file, a JSON array of
+//
+//	{"token": "…", "name": "farmer1", "admin": false, "cert": "farmer1.pem", "key": "farmer1_sk"}
+//
+// The transactions of a token are signed with its cert and key, so the records
+// are stamped with the real submitter; a token without them submits as the
+// gateway identity. On the memory backend every token gets an identity named
+// name. The administrator routes are refused unless -admin-routes is set, and
+// then only served to the tokens with admin set.
+//
+// Only the memory backend can run without -tokens, as an open gateway for
+// local development, and then without the administrator routes.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/lotty02cho/fabcow-test/gateway"
+)
+
+// token is an entry of the -tokens file
+type token struct {
+	Token string `json:"token"`
+	Name  string `json:"name"`
+	Admin bool   `json:"admin"`
+	// Cert and Key are the Fabric identity of the token (PEM files), empty for the gateway identity
+	Cert string `json:"cert"`
+	Key  string `json:"key"`
+}
+
+func main() {
+	listen := flag.String("listen", ":8080", "address to serve HTTP on")
+	backendName := flag.String("backend", "fabric", "fabric or memory")
+	tokensPath := flag.String("tokens", "", "JSON file of the bearer tokens of the callers (optional with the memory backend only)")
+	adminRoutes := flag.Bool("admin-routes", false, "serve the administrator routes (restoring cows, cold chain rules, growth references)")
+
+	peerEndpoint := flag.String("peer", "localhost:7051", "gRPC endpoint of the peer")
+	peerHost := flag.String("peer-host", "", "TLS host name of the peer, if it differs from the endpoint")
+	tlsCertPath := flag.String("tls-cert", "", "TLS CA certificate of the peer (PEM)")
+	mspID := flag.String("msp-id", "Org1MSP", "MSP ID of the gateway identity")
+	certPath := flag.String("cert", "", "certificate of the gateway identity (PEM)")
+	keyPath := flag.String("key", "", "private key of the gateway identity (PEM)")
+	channel := flag.String("channel", "mychannel", "channel the chaincode runs on")
+	chaincodeName := flag.String("chaincode", "fabcow", "name the chaincode is deployed as")
+
+	localRole := flag.String("local-role", "client", "organizational unit of the memory backend identity (client or admin)")
+	flag.Parse()
+
+	if *tokensPath == "" && *backendName != "memory" {
+		log.Fatalf("-tokens is required with the %s backend, only the memory backend can serve an open gateway", *backendName)
+	}
+	if *tokensPath == "" && *adminRoutes {
+		log.Fatalf("-admin-routes needs -tokens, an open gateway can not tell the administrators")
+	}
+
+	fabricConfig := gateway.FabricConfig{Peer: *peerEndpoint, PeerHost: *peerHost, TLSCert: *tlsCertPath, MSPID: *mspID, Cert: *certPath, Key: *keyPath, Channel: *channel, Chaincode: *chaincodeName}
+
+	var backend gateway.Backend
+	// as returns the backend of a token
+	var as func(t token) (gateway.Backend, error)
+	switch *backendName {
+	case "fabric":
+		fabric, err := gateway.DialFabric(fabricConfig)
+		if err != nil {
+			log.Fatalf("Error connecting to %s: %s", *peerEndpoint, err)
+		}
+		defer fabric.Close()
+		backend = fabric
+		as = func(t token) (gateway.Backend, error) {
+			if t.Cert == "" {
+				return nil, nil
+			}
+			config := fabricConfig
+			config.Cert, config.Key = t.Cert, t.Key
+			return gateway.DialFabric(config)
+		}
+	case "memory":
+		memory, err := gateway.NewMemoryBackend(*mspID, *localRole)
+		if err != nil {
+			log.Fatalf("Error creating the memory backend: %s", err)
+		}
+		backend = memory
+		as = func(t token) (gateway.Backend, error) {
+			role := "client"
+			if t.Admin {
+				role = "admin"
+			}
+			return memory.As(t.Name, role)
+		}
+	default:
+		log.Fatalf("Unknown backend %s, expecting fabric or memory", *backendName)
+	}
+
+	config := gateway.ServerConfig{AdminRoutes: *adminRoutes}
+	if *tokensPath != "" {
+		tokens, err := readTokens(*tokensPath, as)
+		if err != nil {
+			log.Fatalf("Error reading the tokens of %s: %s", *tokensPath, err)
+		}
+		config.Authenticator = tokens
+		log.Printf("%d tokens", len(tokens))
+	} else {
+		log.Printf("no -tokens, every caller is served as the memory backend identity")
+	}
+
+	log.Printf("fabcow gateway on %s, %s backend", *listen, *backendName)
+	log.Fatal(http.ListenAndServe(*listen, gateway.NewServer(backend, config)))
+}
+
+// readTokens reads the tokens file at path, as connects the backend of every token
+func readTokens(path string, as func(t token) (gateway.Backend, error)) (gateway.BearerTokens, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	entries := []token{}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	tokens := gateway.BearerTokens{}
+	for _, t := range entries {
+		if t.Token == "" || t.Name == "" {
+			return nil, errors.New("every entry needs a token and a name")
+		}
+		backend, err := as(t)
+		if err != nil {
+			return nil, err
+		}
+		tokens[t.Token] = &gateway.Identity{Name: t.Name, Admin: t.Admin, Backend: backend}
+	}
+	return tokens, nil
+}
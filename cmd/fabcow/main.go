@@ -0,0 +1,37 @@
+// Command fabcow is the operator tool of the traceability ledger, see package cli.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/lotty02cho/fabcow-test/cli"
+	"github.com/lotty02cho/fabcow-test/gateway"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	app := cli.App{Stdout: os.Stdout, Stderr: os.Stderr, Connect: connect}
+	code := app.Run(ctx, os.Args[1:])
+	stop()
+	os.Exit(code)
+}
+
+func connect(profile cli.Profile) (gateway.Backend, error) {
+	switch profile.Backend {
+	case "fabric":
+		return gateway.DialFabric(gateway.FabricConfig{Peer: profile.Peer, PeerHost: profile.PeerHost, TLSCert: profile.TLSCert, MSPID: profile.MSPID, Cert: profile.Cert, Key: profile.Key, Channel: profile.Channel, Chaincode: profile.Chaincode})
+	case "memory":
+		mspID, role := profile.MSPID, profile.Role
+		if mspID == "" {
+			mspID = "Org1MSP"
+		}
+		if role == "" {
+			role = "client"
+		}
+		return gateway.NewMemoryBackend(mspID, role)
+	}
+	return nil, fmt.Errorf("unknown backend %s", profile.Backend)
+}
@@ -0,0 +1,74 @@
+package gateway
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// Identity is who a request is made as. Its transactions run on Backend, which
+// signs them as that identity, so the chaincode stamps the records with the
+// real submitter; a nil Backend runs them on the backend of the server. Only
+// identities with Admin set reach the administrator routes.
+type Identity struct {
+	Name    string
+	Admin   bool
+	Backend Backend
+}
+
+// Authenticator finds the identity a request is made as
+type Authenticator interface {
+	// Authenticate returns nil when the request carries no valid credentials
+	Authenticate(r *http.Request) *Identity
+}
+
+// BearerTokens authenticates the requests with an "Authorization: Bearer <token>"
+// header by their token
+type BearerTokens map[string]*Identity
+
+func (tokens BearerTokens) Authenticate(r *http.Request) *Identity {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil
+	}
+	// every token is compared, in constant time, so the time taken tells nothing about them
+	var found *Identity
+	for candidate, identity := range tokens {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1 {
+			found = identity
+		}
+	}
+	return found
+}
+
+// ServerConfig is who may call the gateway
+type ServerConfig struct {
+	// Authenticator identifies the callers, every request without an identity
+	// is refused. Without one the gateway is open and every transaction runs
+	// on the backend of the server.
+	Authenticator Authenticator
+	// AdminRoutes serves the routes of the administrator transactions
+	// (restoring cows, setting cold chain rules and growth references) to the
+	// identities with Admin set. They are refused otherwise, and always on an
+	// open gateway, which can not tell an administrator from anyone else.
+	AdminRoutes bool
+}
+
+// authorize finds the identity of a request to rt, or the error it is refused with
+func (s *Server) authorize(r *http.Request, rt route) (*Identity, *Error) {
+	var identity *Identity
+	if s.config.Authenticator != nil {
+		if identity = s.config.Authenticator.Authenticate(r); identity == nil {
+			return nil, &Error{Code: codeUnauthenticated, Message: "missing or invalid credentials"}
+		}
+	}
+	if rt.admin {
+		if !s.config.AdminRoutes || identity == nil {
+			return nil, &Error{Code: "forbidden", Message: "the administrator routes are not enabled on this gateway"}
+		}
+		if !identity.Admin {
+			return nil, &Error{Code: "forbidden", Message: "access denied: only administrators can call this route"}
+		}
+	}
+	return identity, nil
+}
@@ -0,0 +1,120 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/lotty02cho/fabcow-test/chaincode"
+	"github.com/lotty02cho/fabcow-test/ledgertest"
+)
+
+// Backend runs the transactions of the fabcow chaincode. FabricBackend sends
+// them to a Fabric network, LedgerBackend to an in-memory ledger for tests and
+// local development.
+type Backend interface {
+	// Submit runs a transaction that writes and waits until it is committed
+	Submit(ctx context.Context, transaction string, args ...string) ([]byte, error)
+	// Evaluate runs a read only transaction on one peer
+	Evaluate(ctx context.Context, transaction string, args ...string) ([]byte, error)
+}
+
+// Error codes the gateway adds to those of the chaincode
+const (
+	codeInvalidArgument = "invalid_argument"
+	codeNotFound        = "not_found"
+	codeInternal        = "internal"
+	// codeUnavailable is a backend that could not be reached
+	codeUnavailable = "unavailable"
+	// codeUnauthenticated is a request without valid credentials, see Authenticator
+	codeUnauthenticated = "unauthenticated"
+)
+
+// codeStatus is the HTTP status of every error code
+var codeStatus = map[string]int{
+	codeInvalidArgument: 400,
+	codeUnauthenticated: 401,
+	"forbidden":         403,
+	codeNotFound:        404,
+	"conflict":          409,
+	"invalid_state":     412,
+	codeInternal:        500,
+	codeUnavailable:     502,
+}
+
+// Error is the body of a failed request, the error body of the chaincode when it
+// rejected the transaction
+type Error struct {
+	Code    string          `json:"code"`
+	Message string          `json:"message"`
+	Details json.RawMessage `json:"details,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// status is the HTTP status of the error
+func (e *Error) status() int {
+	if status, ok := codeStatus[e.Code]; ok {
+		return status
+	}
+	return 500
+}
+
+// chaincodeError reads the error body out of the message of a failed transaction.
+// Peers put their own text in front of it ("chaincode response 404, {…}").
+func chaincodeError(message string) *Error {
+	if i := strings.Index(message, "{"); i >= 0 {
+		e := Error{}
+		if err := json.Unmarshal([]byte(message[i:]), &e); err == nil && e.Code != "" {
+			return &e
+		}
+	}
+	return &Error{Code: codeInternal, Message: message}
+}
+
+// LedgerBackend runs the transactions on an in-memory ledger as Identity
+type LedgerBackend struct {
+	Ledger   *ledgertest.Ledger
+	Identity *ledgertest.Identity
+}
+
+// NewMemoryBackend runs the fabcow chaincode on a new, empty in-memory ledger as
+// an identity of mspID with the organizational unit role (client or admin)
+func NewMemoryBackend(mspID string, role string) (*LedgerBackend, error) {
+	cc, err := chaincode.NewChaincode()
+	if err != nil {
+		return nil, err
+	}
+	id, err := ledgertest.NewIdentity(mspID, "gateway", role)
+	if err != nil {
+		return nil, err
+	}
+	return &LedgerBackend{Ledger: ledgertest.New(cc, "local"), Identity: id}, nil
+}
+
+// As returns a backend on the same ledger running the transactions as the
+// identity commonName of the same MSP, with the organizational unit role
+func (b *LedgerBackend) As(commonName string, role string) (*LedgerBackend, error) {
+	id, err := ledgertest.NewIdentity(b.Identity.MSPID, commonName, role)
+	if err != nil {
+		return nil, err
+	}
+	return &LedgerBackend{Ledger: b.Ledger, Identity: id}, nil
+}
+
+func (b *LedgerBackend) Submit(ctx context.Context, transaction string, args ...string) ([]byte, error) {
+	return ledgerResult(b.Ledger.Submit(b.Identity, transaction, args...))
+}
+
+func (b *LedgerBackend) Evaluate(ctx context.Context, transaction string, args ...string) ([]byte, error) {
+	return ledgerResult(b.Ledger.Evaluate(b.Identity, transaction, args...))
+}
+
+func ledgerResult(result ledgertest.Result) ([]byte, error) {
+	if err := result.Err(); err != nil {
+		return nil, chaincodeError(result.Message)
+	}
+	return result.Payload, nil
+}
@@ -0,0 +1,159 @@
+package gateway
+
+import (
+	"context"
+	"crypto/x509"
+	"os"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/hyperledger/fabric-protos-go/gateway"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// FabricBackend runs the transactions through the Fabric Gateway of a peer
+type FabricBackend struct {
+	Contract *client.Contract
+	// connection is closed by Close when DialFabric opened it
+	connection *grpc.ClientConn
+}
+
+// FabricConfig is where the chaincode runs and who to submit as
+type FabricConfig struct {
+	// Peer is the gRPC endpoint of the peer, PeerHost its TLS host name if it differs
+	Peer     string
+	PeerHost string
+	// TLSCert is the TLS CA certificate of the peer (PEM file)
+	TLSCert string
+	// MSPID, Cert and Key are the identity the transactions are signed with (PEM files)
+	MSPID     string
+	Cert      string
+	Key       string
+	Channel   string
+	Chaincode string
+}
+
+// DialFabric connects to the Fabric Gateway of the peer in config
+func DialFabric(config FabricConfig) (*FabricBackend, error) {
+	tlsCertPEM, err := os.ReadFile(config.TLSCert)
+	if err != nil {
+		return nil, err
+	}
+	tlsCert, err := identity.CertificateFromPEM(tlsCertPEM)
+	if err != nil {
+		return nil, err
+	}
+	certPool := x509.NewCertPool()
+	certPool.AddCert(tlsCert)
+	connection, err := grpc.NewClient(config.Peer, grpc.WithTransportCredentials(credentials.NewClientTLSFromCert(certPool, config.PeerHost)))
+	if err != nil {
+		return nil, err
+	}
+
+	id, sign, err := fabricIdentity(config)
+	if err != nil {
+		connection.Close()
+		return nil, err
+	}
+	gw, err := client.Connect(id, client.WithSign(sign), client.WithClientConnection(connection),
+		client.WithEvaluateTimeout(5*time.Second),
+		client.WithEndorseTimeout(15*time.Second),
+		client.WithSubmitTimeout(5*time.Second),
+		client.WithCommitStatusTimeout(time.Minute),
+	)
+	if err != nil {
+		connection.Close()
+		return nil, err
+	}
+	return &FabricBackend{Contract: gw.GetNetwork(config.Channel).GetContract(config.Chaincode), connection: connection}, nil
+}
+
+func fabricIdentity(config FabricConfig) (*identity.X509Identity, identity.Sign, error) {
+	certPEM, err := os.ReadFile(config.Cert)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := identity.CertificateFromPEM(certPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	id, err := identity.NewX509Identity(config.MSPID, cert)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyPEM, err := os.ReadFile(config.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := identity.PrivateKeyFromPEM(keyPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	sign, err := identity.NewPrivateKeySign(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return id, sign, nil
+}
+
+// Close closes the connection DialFabric opened
+func (b *FabricBackend) Close() error {
+	if b.connection == nil {
+		return nil
+	}
+	return b.connection.Close()
+}
+
+// Submit endorses the transaction, orders it and waits for its commit, each
+// step bounded by ctx
+func (b *FabricBackend) Submit(ctx context.Context, transaction string, args ...string) ([]byte, error) {
+	proposal, err := b.Contract.NewProposal(transaction, client.WithArguments(args...))
+	if err != nil {
+		return nil, fabricError(err)
+	}
+	endorsed, err := proposal.EndorseWithContext(ctx)
+	if err != nil {
+		return nil, fabricError(err)
+	}
+	commit, err := endorsed.SubmitWithContext(ctx)
+	if err != nil {
+		return nil, fabricError(err)
+	}
+	commitStatus, err := commit.StatusWithContext(ctx)
+	if err != nil {
+		return nil, fabricError(err)
+	}
+	if !commitStatus.Successful {
+		return nil, &Error{Code: "conflict", Message: "transaction " + commitStatus.TransactionID + " was not committed: " + commitStatus.Code.String()}
+	}
+	return endorsed.Result(), nil
+}
+
+func (b *FabricBackend) Evaluate(ctx context.Context, transaction string, args ...string) ([]byte, error) {
+	proposal, err := b.Contract.NewProposal(transaction, client.WithArguments(args...))
+	if err != nil {
+		return nil, fabricError(err)
+	}
+	result, err := proposal.EvaluateWithContext(ctx)
+	if err != nil {
+		return nil, fabricError(err)
+	}
+	return result, nil
+}
+
+// fabricError finds the error body of the chaincode in the details the peers
+// attach to a failed endorsement. Errors without one are failures to reach or
+// use the network.
+func fabricError(err error) error {
+	for _, detail := range status.Convert(err).Details() {
+		if detail, ok := detail.(*gateway.ErrorDetail); ok {
+			if e := chaincodeError(detail.GetMessage()); e.Code != codeInternal {
+				return e
+			}
+		}
+	}
+	return &Error{Code: codeUnavailable, Message: err.Error()}
+}
@@ -0,0 +1,10 @@
+package gateway
+
+import (
+	_ "embed"
+)
+
+// openAPISpec is the OpenAPI description of the gateway
+//
+//go:embed openapi.yaml
+var openAPISpec []byte
@@ -0,0 +1,447 @@
+// Package gateway serves the fabcow chaincode as a resource oriented HTTP API,
+// described in openapi.yaml (served at /openapi.yaml). Every request runs one
+// transaction on a Backend: writes are submitted, reads evaluated. Request
+// bodies are the payloads of the JSON transactions, and the record a path
+// names fills in the cow or owner field of the payload:
+//
+//	POST /cows/180501-2/fmd-vaccinations  {"farm_id": "FARM0", …}
+//	-> addFAMDVaccineJSON {"cow": "180501-2", "farm_id": "FARM0", …}
+//
+// Failures answer with the error body of the chaincode and the HTTP status of
+// its code. Callers are identified by the Authenticator of the ServerConfig,
+// and the routes of the administrator transactions are only served when the
+// config enables them, see ServerConfig.
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxBodyBytes is the largest request body accepted
+const maxBodyBytes = 1 << 20
+
+// ownerTransactions registers an owner of each owner_type
+var ownerTransactions = map[string]string{
+	"FARM":           "RegisterFarmJSON",
+	"SLAUGHTERHOUSE": "RegisterSlaughterhouseJSON",
+	"PROCESSOR":      "RegisterProcessorJSON",
+	"SELLER":         "RegisterSellerJSON",
+	"WHOLESALER":     "RegisterWholesalerJSON",
+	"IMPORTER":       "RegisterImporterJSON",
+	"RESTAURANT":     "RegisterRestaurantJSON",
+}
+
+// bundleTransactions registers a bundle packed at each stage
+var bundleTransactions = map[string]string{
+	"processing": "RegisterInProcessesBundleNumJSON",
+	"sale":       "RegisterInSalesBundleNumJSON",
+}
+
+// Server is the HTTP handler of the gateway
+type Server struct {
+	backend Backend
+	config  ServerConfig
+	routes  []route
+}
+
+// route maps a method and path pattern to a transaction. Pattern segments in
+// braces are path parameters.
+type route struct {
+	method  string
+	pattern []string
+	// submit is set for the transactions that write
+	submit bool
+	// created answers 201 instead of 200
+	created bool
+	// admin is set for the transactions only administrators can submit
+	admin bool
+	call  func(req *request) (string, []string, error)
+}
+
+// request is an HTTP request with the parameters of its route
+type request struct {
+	*http.Request
+	params map[string]string
+}
+
+// NewServer returns the gateway running its transactions on backend, or on the
+// backends of the identities config authenticates
+func NewServer(backend Backend, config ServerConfig) *Server {
+	s := &Server{backend: backend, config: config}
+
+	s.evaluate("GET /cows", func(req *request) (string, []string, error) {
+		if req.URL.Query().Get("archived") == "true" {
+			return "QueryArchivedCows", nil, nil
+		}
+		return "QueryAllCows", nil, nil
+	})
+	s.create("POST /cows", body("RegisterCowJSON", "", ""))
+	s.evaluate("GET /cows/{id}", pathArgs("ReadCow", "id"))
+	s.submit("DELETE /cows/{id}", func(req *request) (string, []string, error) {
+		// the reason may come as a body or as a query parameter
+		if reason := req.URL.Query().Get("reason"); reason != "" {
+			return "ArchiveCow", []string{req.params["id"], reason}, nil
+		}
+		return body("ArchiveCowJSON", "cow", "id")(req)
+	})
+	s.administer("POST /cows/{id}/restore", pathArgs("RestoreCow", "id"))
+	s.submit("POST /cows/{id}/transfers", body("ChangeCowOwnerJSON", "cow", "id"))
+	s.submit("POST /cows/{id}/rfid", body("RegisterRFIDJSON", "cow", "id"))
+	s.submit("POST /cows/{id}/remarks", body("AddRemarkJSON", "cow", "id"))
+	s.submit("POST /cows/{id}/fmd-vaccinations", body("AddFAMDVaccineJSON", "cow", "id"))
+	s.submit("POST /cows/{id}/bt-inspections", body("AddBTVaccineJSON", "cow", "id"))
+	s.submit("POST /cows/{id}/death", body("AddInfoDeadJSON", "cow", "id"))
+	s.submit("POST /cows/{id}/death/confirmation", body("ConfirmDeathJSON", "cow", "id"))
+	s.submit("POST /cows/{id}/deliveries", body("AddInfoDeliverJSON", "cow", "id"))
+	s.submit("POST /cows/{id}/inspections", body("AddInfoInspectJSON", "cow", "id"))
+	s.submit("POST /cows/{id}/grade-results", body("AddInfoGradeResultJSON", "cow", "id"))
+	s.submit("POST /cows/{id}/processor-purchases", body("AddInfoInProcessesReportPurchaseJSON", "cow", "id"))
+	s.submit("POST /cows/{id}/packings", body("AddInfoReportPackingJSON", "cow", "id"))
+	s.submit("POST /cows/{id}/sales", body("AddInfoReportSaleJSON", "cow", "id"))
+	s.submit("POST /cows/{id}/seller-purchases", body("AddInfoInSalesReportPurchaseJSON", "cow", "id"))
+	s.evaluate("GET /cows/{id}/trace", pathArgs("GetTraceCertificate", "id"))
+	s.evaluate("GET /cows/{id}/trace/verification", verification("id"))
+	s.evaluate("GET /cows/{id}/epcis", pathArgs("GetEPCISDocument", "id"))
+	s.submit("POST /cows/{id}/weighings", body("AddWeighingJSON", "cow", "id"))
+	s.evaluate("GET /cows/{id}/growth", func(req *request) (string, []string, error) {
+		return "GetGrowthCurve", []string{req.params["id"], req.URL.Query().Get("breed")}, nil
+	})
+	s.submit("POST /weighings", arrayBody("AddWeighingBatch"))
+	s.submit("POST /cows/{id}/treatments", body("AddTreatmentJSON", "cow", "id"))
+	s.evaluate("GET /withdrawal-holds", queryArgs("GetWithdrawalHolds", "owner", "date"))
+	s.submit("POST /cows/{id}/inseminations", body("AddInseminationJSON", "cow", "id"))
+	s.submit("POST /cows/{id}/pregnancy-checks", body("AddPregnancyCheckJSON", "cow", "id"))
+	// a calving registers the calf
+	s.create("POST /cows/{id}/calvings", body("AddCalvingJSON", "cow", "id"))
+	// an auction sale transfers the cow to the buyer
+	s.submit("POST /cows/{id}/auction-sales", body("AddAuctionSaleJSON", "cow", "id"))
+	s.submit("POST /auction-sales", arrayBody("AddAuctionSaleBatch"))
+	s.evaluate("GET /auction-sales", queryArgs("GetAuctionPriceHistory", "region", "from", "to"))
+
+	s.evaluate("GET /owners", func(req *request) (string, []string, error) {
+		if ownerType := req.URL.Query().Get("type"); ownerType != "" {
+			return "QueryOwnersByType", []string{ownerType}, nil
+		}
+		return "QueryAllOwners", nil, nil
+	})
+	s.create("POST /owners", typedBody("owner_type", ownerTransactions, ""))
+	s.evaluate("GET /owners/{bizNo}", pathArgs("ReadOwner", "bizNo"))
+	s.submit("POST /owners/{bizNo}/haccp", body("RegisterHACCPJSON", "owner", "bizNo"))
+	s.submit("POST /owners/{bizNo}/certifications", body("AddAutJSON", "owner", "bizNo"))
+	s.evaluate("GET /owners/{bizNo}/traceability-reports", func(req *request) (string, []string, error) {
+		query := req.URL.Query()
+		return "GetTraceabilityReport", []string{req.params["bizNo"], query.Get("from"), query.Get("to")}, nil
+	})
+
+	s.create("POST /bundles", typedBody("stage", bundleTransactions, "processing"))
+	s.evaluate("GET /bundles/{barcode}", pathArgs("ReadBundle", "barcode"))
+	s.evaluate("GET /bundles/{barcode}/trace", pathArgs("GetTraceCertificate", "barcode"))
+	s.evaluate("GET /bundles/{barcode}/trace/verification", verification("barcode"))
+	s.evaluate("GET /bundles/{barcode}/epcis", pathArgs("GetEPCISDocument", "barcode"))
+	s.submit("POST /bundles/{barcode}/readings", body("AddColdChainReadingJSON", "bundle", "barcode"))
+	s.evaluate("GET /bundles/{barcode}/cold-chain", pathArgs("ReadColdChainLog", "barcode"))
+
+	// a data logger uploads its readings of every bundle at once
+	s.submit("POST /readings", arrayBody("AddColdChainReadingBatch"))
+	s.evaluate("GET /cold-chain-rules", pathArgs("QueryColdChainRules"))
+	s.administer("PUT /cold-chain-rules/{part}", body("SetColdChainRuleJSON", "part", "part"))
+
+	s.evaluate("GET /epcis", queryArgs("GetEPCISEvents", "from", "to"))
+
+	s.evaluate("GET /sires/{id}/progeny", pathArgs("GetSireProgenyReport", "id"))
+	s.evaluate("GET /stats/grades", queryArgs("GetGradeStats", "farm", "from", "to"))
+	s.evaluate("GET /stats/deaths", queryArgs("GetDeathStats", "farm", "from", "to"))
+	s.evaluate("GET /stats/growth", queryArgs("GetGrowthReport", "farm", "from", "to"))
+	s.evaluate("GET /stats/calvings", queryArgs("GetCalvingStats", "farm", "from", "to"))
+	s.evaluate("GET /stats/auction-prices", queryArgs("GetAuctionPriceStats", "region", "from", "to"))
+
+	s.evaluate("GET /growth-references", pathArgs("QueryGrowthReferences"))
+	s.administer("PUT /growth-references/{breed}/{sex}", func(req *request) (string, []string, error) {
+		payload, err := readPayload(req, "breed", "breed")
+		if err != nil {
+			return "", nil, err
+		}
+		payload["sex"], _ = json.Marshal(req.params["sex"])
+		arg, err := json.Marshal(payload)
+		if err != nil {
+			return "", nil, err
+		}
+		return "SetGrowthReferenceJSON", []string{string(arg)}, nil
+	})
+
+	return s
+}
+
+func (s *Server) evaluate(route string, call func(req *request) (string, []string, error)) {
+	s.add(route, false, false, false, call)
+}
+
+func (s *Server) submit(route string, call func(req *request) (string, []string, error)) {
+	s.add(route, true, false, false, call)
+}
+
+func (s *Server) create(route string, call func(req *request) (string, []string, error)) {
+	s.add(route, true, true, false, call)
+}
+
+// administer is submit for the administrator transactions
+func (s *Server) administer(route string, call func(req *request) (string, []string, error)) {
+	s.add(route, true, false, true, call)
+}
+
+func (s *Server) add(methodAndPattern string, submit bool, created bool, admin bool, call func(req *request) (string, []string, error)) {
+	fields := strings.Fields(methodAndPattern)
+	s.routes = append(s.routes, route{method: fields[0], pattern: splitPath(fields[1]), submit: submit, created: created, admin: admin, call: call})
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/openapi.yaml" && r.Method == http.MethodGet {
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Write(openAPISpec)
+		return
+	}
+
+	segments := splitPath(r.URL.EscapedPath())
+	allowed := []string{}
+	for _, rt := range s.routes {
+		params, ok := matchPath(rt.pattern, segments)
+		if !ok {
+			continue
+		}
+		if rt.method != r.Method {
+			allowed = append(allowed, rt.method)
+			continue
+		}
+		s.serve(w, &request{Request: r, params: params}, rt)
+		return
+	}
+
+	if len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeError(w, http.StatusMethodNotAllowed, &Error{Code: codeInvalidArgument, Message: "method " + r.Method + " is not allowed on " + r.URL.Path})
+		return
+	}
+	writeError(w, http.StatusNotFound, &Error{Code: codeNotFound, Message: "no resource at " + r.URL.Path})
+}
+
+func (s *Server) serve(w http.ResponseWriter, req *request, rt route) {
+	identity, refused := s.authorize(req.Request, rt)
+	if refused != nil {
+		if refused.Code == codeUnauthenticated {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+		}
+		writeError(w, 0, refused)
+		return
+	}
+	backend := s.backend
+	if identity != nil && identity.Backend != nil {
+		backend = identity.Backend
+	}
+
+	transaction, args, err := rt.call(req)
+	if err != nil {
+		writeError(w, 0, err)
+		return
+	}
+
+	var result []byte
+	if rt.submit {
+		result, err = backend.Submit(req.Context(), transaction, args...)
+	} else {
+		result, err = backend.Evaluate(req.Context(), transaction, args...)
+	}
+	if err != nil {
+		writeError(w, 0, err)
+		return
+	}
+
+	status := http.StatusOK
+	if rt.created {
+		status = http.StatusCreated
+	}
+	if len(result) == 0 {
+		if !rt.created {
+			status = http.StatusNoContent
+		}
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(result)
+}
+
+// pathArgs calls transaction with path parameters as its arguments
+func pathArgs(transaction string, params ...string) func(req *request) (string, []string, error) {
+	return func(req *request) (string, []string, error) {
+		args := []string{}
+		for _, param := range params {
+			args = append(args, req.params[param])
+		}
+		return transaction, args, nil
+	}
+}
+
+// queryArgs calls transaction with query parameters as its arguments, missing ones are empty
+func queryArgs(transaction string, params ...string) func(req *request) (string, []string, error) {
+	return func(req *request) (string, []string, error) {
+		args := []string{}
+		for _, param := range params {
+			args = append(args, req.URL.Query().Get(param))
+		}
+		return transaction, args, nil
+	}
+}
+
+// verification checks the certificate hash of the hash query parameter
+func verification(param string) func(req *request) (string, []string, error) {
+	return func(req *request) (string, []string, error) {
+		hash := req.URL.Query().Get("hash")
+		if hash == "" {
+			return "", nil, &Error{Code: codeInvalidArgument, Message: "the hash query parameter is missing"}
+		}
+		return "VerifyTraceCertificate", []string{req.params[param], hash}, nil
+	}
+}
+
+// body calls a JSON transaction with the request body as its payload, field of
+// the payload is set to the path parameter param
+func body(transaction string, field string, param string) func(req *request) (string, []string, error) {
+	return func(req *request) (string, []string, error) {
+		payload, err := readPayload(req, field, param)
+		if err != nil {
+			return "", nil, err
+		}
+		arg, err := json.Marshal(payload)
+		if err != nil {
+			return "", nil, err
+		}
+		return transaction, []string{string(arg)}, nil
+	}
+}
+
+// arrayBody calls a batch transaction with the JSON array of the request body
+func arrayBody(transaction string) func(req *request) (string, []string, error) {
+	return func(req *request) (string, []string, error) {
+		data, err := io.ReadAll(http.MaxBytesReader(nil, req.Body, maxBodyBytes))
+		if err != nil {
+			return "", nil, &Error{Code: codeInvalidArgument, Message: "could not read the request body: " + err.Error()}
+		}
+		items := []json.RawMessage{}
+		if err := json.Unmarshal(data, &items); err != nil {
+			return "", nil, &Error{Code: codeInvalidArgument, Message: "the request body is not a JSON array: " + err.Error()}
+		}
+		arg, err := json.Marshal(items)
+		if err != nil {
+			return "", nil, err
+		}
+		return transaction, []string{string(arg)}, nil
+	}
+}
+
+// typedBody is body for resources registered by different transactions, picked
+// by the typeField of the body out of transactions. The type field is not passed on.
+func typedBody(typeField string, transactions map[string]string, defaultType string) func(req *request) (string, []string, error) {
+	return func(req *request) (string, []string, error) {
+		payload, err := readPayload(req, "", "")
+		if err != nil {
+			return "", nil, err
+		}
+
+		typeName := defaultType
+		if raw, ok := payload[typeField]; ok {
+			if err := json.Unmarshal(raw, &typeName); err != nil {
+				return "", nil, &Error{Code: codeInvalidArgument, Message: typeField + " must be a string"}
+			}
+			delete(payload, typeField)
+		}
+		transaction, ok := transactions[typeName]
+		if !ok {
+			transaction, ok = transactions[strings.ToUpper(typeName)]
+		}
+		if !ok {
+			return "", nil, &Error{Code: codeInvalidArgument, Message: "unknown " + typeField + " " + typeName}
+		}
+
+		arg, err := json.Marshal(payload)
+		if err != nil {
+			return "", nil, err
+		}
+		return transaction, []string{string(arg)}, nil
+	}
+}
+
+func readPayload(req *request, field string, param string) (map[string]json.RawMessage, error) {
+	data, err := io.ReadAll(http.MaxBytesReader(nil, req.Body, maxBodyBytes))
+	if err != nil {
+		return nil, &Error{Code: codeInvalidArgument, Message: "could not read the request body: " + err.Error()}
+	}
+	payload := map[string]json.RawMessage{}
+	if len(strings.TrimSpace(string(data))) > 0 {
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return nil, &Error{Code: codeInvalidArgument, Message: "the request body is not a JSON object: " + err.Error()}
+		}
+	}
+
+	if field != "" {
+		value := req.params[param]
+		if raw, ok := payload[field]; ok {
+			var given string
+			if err := json.Unmarshal(raw, &given); err != nil || given != value {
+				return nil, &Error{Code: codeInvalidArgument, Message: field + " of the body does not match the path"}
+			}
+		}
+		payload[field], _ = json.Marshal(value)
+	}
+	return payload, nil
+}
+
+// writeError answers with the error body, status 0 takes the status of its code
+func writeError(w http.ResponseWriter, status int, err error) {
+	var e *Error
+	if !errors.As(err, &e) {
+		e = &Error{Code: codeInternal, Message: err.Error()}
+	}
+	if status == 0 {
+		status = e.status()
+	}
+	body, _ := json.Marshal(e)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// splitPath splits an escaped path into its unescaped segments
+func splitPath(path string) []string {
+	segments := []string{}
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		if unescaped, err := url.PathUnescape(segment); err == nil {
+			segment = unescaped
+		}
+		segments = append(segments, segment)
+	}
+	return segments
+}
+
+func matchPath(pattern []string, segments []string) (map[string]string, bool) {
+	if len(pattern) != len(segments) {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, part := range pattern {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			params[part[1:len(part)-1]] = segments[i]
+		} else if part != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
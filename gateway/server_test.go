@@ -0,0 +1,158 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTestServer serves a memory backend with the administrator routes to a
+// farmer, an administrator and a stranger token
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	backend, err := NewMemoryBackend("Org1MSP", "client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	farmer, err := backend.As("farmer1", "client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin, err := backend.As("admin1", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// an administrator certificate without the admin flag of the gateway
+	stranger, err := backend.As("stranger1", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens := BearerTokens{
+		"farmer-token":   {Name: "farmer1", Backend: farmer},
+		"admin-token":    {Name: "admin1", Admin: true, Backend: admin},
+		"stranger-token": {Name: "stranger1", Backend: stranger},
+	}
+	return NewServer(backend, ServerConfig{Authenticator: tokens, AdminRoutes: true})
+}
+
+// call serves one request and returns the response
+func call(s *Server, token string, method string, path string, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	return w
+}
+
+func TestServerAuthentication(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		name   string
+		token  string
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{"no token", "", "GET", "/cows", "", 401, codeUnauthenticated},
+		{"unknown token", "guessed", "GET", "/cows", "", 401, codeUnauthenticated},
+		{"specification", "", "GET", "/openapi.yaml", "", 200, ""},
+		{"register a farm", "farmer-token", "POST", "/owners", `{"owner_type":"FARM","farm_id":"FARM0","farm_nm":"ChukLim1","farm_addr":"Iksan","livestock":"C","farm_user_nm":"Kim","farm_user_birth":"530118"}`, 201, ""},
+		{"register a cow", "farmer-token", "POST", "/cows", `{"id_no":"180501-1","birth_date":"180501","sex":"F","father_id":"901027","mother_id":"910101","origin":"Iksan","owner":"FARM0"}`, 201, ""},
+		{"read a cow", "farmer-token", "GET", "/cows/180501-1", "", 200, ""},
+		{"unknown cow", "farmer-token", "GET", "/cows/180501-9", "", 404, "not_found"},
+		{"archive a cow", "farmer-token", "DELETE", "/cows/180501-1?reason=registered%20twice", "", 204, ""},
+		{"restore as a farmer", "farmer-token", "POST", "/cows/180501-1/restore", "", 403, "forbidden"},
+		{"restore as an administrator of the ledger only", "stranger-token", "POST", "/cows/180501-1/restore", "", 403, "forbidden"},
+		{"restore", "admin-token", "POST", "/cows/180501-1/restore", "", 204, ""},
+		{"cold chain rule as a farmer", "farmer-token", "PUT", "/cold-chain-rules/chilled", `{"temperature":{"min":-1,"max":4}}`, 403, "forbidden"},
+		{"cold chain rule", "admin-token", "PUT", "/cold-chain-rules/chilled", `{"temperature":{"min":-1,"max":4}}`, 204, ""},
+		{"growth reference as a farmer", "farmer-token", "PUT", "/growth-references/hanwoo/M", `{"points":[{"age_months":6,"weight":180}]}`, 403, "forbidden"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := call(s, test.token, test.method, test.path, test.body)
+			if w.Code != test.status {
+				t.Fatalf("%s %s answered %d, want %d: %s", test.method, test.path, w.Code, test.status, w.Body)
+			}
+			if test.code != "" {
+				e := Error{}
+				if err := json.Unmarshal(w.Body.Bytes(), &e); err != nil || e.Code != test.code {
+					t.Errorf("%s %s answered %s, want code %s", test.method, test.path, w.Body, test.code)
+				}
+			}
+			if test.status == 401 && w.Header().Get("WWW-Authenticate") != "Bearer" {
+				t.Errorf("401 without a WWW-Authenticate challenge")
+			}
+		})
+	}
+
+	t.Run("stamped with the submitter", func(t *testing.T) {
+		w := call(s, "farmer-token", "GET", "/cows/180501-1", "")
+		cow := struct {
+			Stamp struct {
+				Subject string
+			}
+			Remarks []struct {
+				Key   string
+				Stamp struct {
+					Subject string
+				}
+			}
+		}{}
+		if err := json.Unmarshal(w.Body.Bytes(), &cow); err != nil {
+			t.Fatal(err)
+		}
+		// the restore was the last write
+		if !strings.Contains(cow.Stamp.Subject, "CN=admin1") {
+			t.Errorf("cow stamped by %q, want admin1", cow.Stamp.Subject)
+		}
+		for _, remark := range cow.Remarks {
+			if remark.Key == "archiveCow.reason" && !strings.Contains(remark.Stamp.Subject, "CN=farmer1") {
+				t.Errorf("archive remark stamped by %q, want farmer1", remark.Stamp.Subject)
+			}
+		}
+	})
+}
+
+func TestServerAdminRoutes(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      ServerConfig
+		status      int
+		wantApplied bool
+	}{
+		{"open gateway", ServerConfig{}, 403, false},
+		{"open gateway with the administrator routes", ServerConfig{AdminRoutes: true}, 403, false},
+		{"administrator token without the administrator routes", ServerConfig{Authenticator: BearerTokens{"admin-token": {Name: "admin1", Admin: true}}}, 403, false},
+		{"administrator token", ServerConfig{Authenticator: BearerTokens{"admin-token": {Name: "admin1", Admin: true}}, AdminRoutes: true}, 204, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backend, err := NewMemoryBackend("Org1MSP", "admin")
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := NewServer(backend, test.config)
+
+			w := call(s, "admin-token", "PUT", "/cold-chain-rules/chilled", `{"temperature":{"min":-1,"max":4}}`)
+			if w.Code != test.status {
+				t.Fatalf("PUT /cold-chain-rules/chilled answered %d, want %d: %s", w.Code, test.status, w.Body)
+			}
+
+			w = call(s, "admin-token", "GET", "/cold-chain-rules", "")
+			if w.Code != 200 {
+				t.Fatalf("GET /cold-chain-rules answered %d: %s", w.Code, w.Body)
+			}
+			if applied := strings.Contains(w.Body.String(), "chilled"); applied != test.wantApplied {
+				t.Errorf("rule applied: %v, want %v", applied, test.wantApplied)
+			}
+		})
+	}
+}
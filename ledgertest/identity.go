@@ -0,0 +1,62 @@
+package ledgertest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/msp"
+)
+
+// Identity is a transaction submitter with a self signed certificate, enough
+// for the client identity checks of a chaincode (MSP ID, subject, OUs)
+type Identity struct {
+	MSPID       string
+	Certificate *x509.Certificate
+	creator     []byte
+}
+
+// NewIdentity creates a submitter of mspID named commonName. Fabric NodeOUs put
+// the role in the organizational units ("client", "admin", "peer").
+func NewIdentity(mspID string, commonName string, organizationalUnits ...string) (*Identity, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName, OrganizationalUnit: organizationalUnits},
+		NotBefore:    startTime.Add(-24 * time.Hour),
+		NotAfter:     startTime.Add(100 * 365 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	creator, err := proto.Marshal(&msp.SerializedIdentity{Mspid: mspID, IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})})
+	if err != nil {
+		return nil, err
+	}
+	return &Identity{MSPID: mspID, Certificate: certificate, creator: creator}, nil
+}
+
+// Creator is the serialized identity a peer passes to the chaincode
+func (id *Identity) Creator() []byte {
+	return id.creator
+}
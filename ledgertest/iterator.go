@@ -0,0 +1,59 @@
+package ledgertest
+
+import (
+	"errors"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+)
+
+// stateIterator iterates the results of a query, read when the query ran
+type stateIterator struct {
+	results []*queryresult.KV
+	next    int
+	closed  bool
+}
+
+func newStateIterator(results []*queryresult.KV) *stateIterator {
+	return &stateIterator{results: results}
+}
+
+func (it *stateIterator) HasNext() bool {
+	return !it.closed && it.next < len(it.results)
+}
+
+func (it *stateIterator) Next() (*queryresult.KV, error) {
+	if !it.HasNext() {
+		return nil, errors.New("no more results")
+	}
+	it.next++
+	return it.results[it.next-1], nil
+}
+
+func (it *stateIterator) Close() error {
+	it.closed = true
+	return nil
+}
+
+// historyIterator iterates the modifications of a key
+type historyIterator struct {
+	modifications []*queryresult.KeyModification
+	next          int
+	closed        bool
+}
+
+func (it *historyIterator) HasNext() bool {
+	return !it.closed && it.next < len(it.modifications)
+}
+
+func (it *historyIterator) Next() (*queryresult.KeyModification, error) {
+	if !it.HasNext() {
+		return nil, errors.New("no more results")
+	}
+	it.next++
+	return it.modifications[it.next-1], nil
+}
+
+func (it *historyIterator) Close() error {
+	it.closed = true
+	return nil
+}
@@ -0,0 +1,224 @@
+// Package ledgertest runs a chaincode against an in-memory ledger, so the whole
+// fabcow contract can be exercised with go test and no Fabric network:
+//
+//	ledger := ledgertest.New(cc, "mychannel")
+//	farmer, _ := ledgertest.NewIdentity("Org1MSP", "farmer1", "client")
+//	result := ledger.Submit(farmer, "registerFarm", "", "FARM0", "ChukLim1", "Iksan", "C", "Kim", "530118")
+//	if err := result.Err(); err != nil { … }
+//
+// It behaves like a peer where the contract can tell: a transaction reads the
+// committed state only and never its own writes, its writes are committed when
+// it succeeds and dropped when it fails, range queries skip composite keys,
+// a value written empty is deleted, the history of a key is returned newest
+// first and only the last event a transaction sets is emitted. Rich queries
+// take the CouchDB selector subset listed in selector.go.
+package ledgertest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+)
+
+// startTime is the clock of a new ledger, fixed so runs are repeatable
+var startTime = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Ledger is the world state, private data, history and events of one chaincode.
+// Transactions run one at a time.
+type Ledger struct {
+	mu        sync.Mutex
+	chaincode shim.Chaincode
+	channel   string
+
+	state      map[string][]byte
+	parameters map[string][]byte
+	history    map[string][]*queryresult.KeyModification
+	// private holds the collections, by name
+	private map[string]map[string][]byte
+	events  []Event
+
+	clock    time.Time
+	txNumber int
+}
+
+// Event is the chaincode event of a committed transaction
+type Event struct {
+	TxID    string
+	Name    string
+	Payload []byte
+}
+
+// Result is the outcome of a transaction
+type Result struct {
+	TxID    string
+	Status  int32
+	Message string
+	Payload []byte
+	// Committed reports whether the writes of the transaction were applied
+	Committed bool
+	// Event is the event the transaction set, nil if it set none or was not committed
+	Event *Event
+}
+
+// Err is the failure of the transaction, nil if it succeeded
+func (r Result) Err() error {
+	if r.Status >= shim.ERRORTHRESHOLD {
+		return errors.New(r.Message)
+	}
+	return nil
+}
+
+// New returns an empty ledger running chaincode on channel
+func New(chaincode shim.Chaincode, channel string) *Ledger {
+	return &Ledger{
+		chaincode:  chaincode,
+		channel:    channel,
+		state:      map[string][]byte{},
+		parameters: map[string][]byte{},
+		history:    map[string][]*queryresult.KeyModification{},
+		private:    map[string]map[string][]byte{},
+		clock:      startTime,
+	}
+}
+
+// Init calls the Init of the chaincode and commits its writes if it succeeds
+func (l *Ledger) Init(identity *Identity, args ...string) Result {
+	return l.run(identity, nil, args, true, func(stub shim.ChaincodeStubInterface) (int32, string, []byte) {
+		response := l.chaincode.Init(stub)
+		return response.Status, response.Message, response.Payload
+	})
+}
+
+// Submit runs a transaction and commits its writes if it succeeds
+func (l *Ledger) Submit(identity *Identity, function string, args ...string) Result {
+	return l.invoke(identity, nil, function, args, true)
+}
+
+// SubmitTransient is Submit with transient data, the way private data is passed in
+func (l *Ledger) SubmitTransient(identity *Identity, transient map[string][]byte, function string, args ...string) Result {
+	return l.invoke(identity, transient, function, args, true)
+}
+
+// Evaluate runs a transaction without committing anything, like a query
+func (l *Ledger) Evaluate(identity *Identity, function string, args ...string) Result {
+	return l.invoke(identity, nil, function, args, false)
+}
+
+func (l *Ledger) invoke(identity *Identity, transient map[string][]byte, function string, args []string, commit bool) Result {
+	return l.run(identity, transient, append([]string{function}, args...), commit, func(stub shim.ChaincodeStubInterface) (int32, string, []byte) {
+		response := l.chaincode.Invoke(stub)
+		return response.Status, response.Message, response.Payload
+	})
+}
+
+func (l *Ledger) run(identity *Identity, transient map[string][]byte, args []string, commit bool, call func(shim.ChaincodeStubInterface) (int32, string, []byte)) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.txNumber++
+	l.clock = l.clock.Add(time.Second)
+	txHash := sha256.Sum256([]byte(fmt.Sprintf("%s/%d", l.channel, l.txNumber)))
+	stub := newStub(l, hex.EncodeToString(txHash[:]), args, identity, transient)
+
+	result := Result{TxID: stub.txID}
+	result.Status, result.Message, result.Payload = call(stub)
+	if !commit || result.Err() != nil {
+		return result
+	}
+
+	l.commit(stub)
+	result.Committed = true
+	if stub.event != nil {
+		event := Event{TxID: stub.txID, Name: stub.event.EventName, Payload: stub.event.Payload}
+		l.events = append(l.events, event)
+		result.Event = &event
+	}
+	return result
+}
+
+// commit applies the writes of stub, in key order
+func (l *Ledger) commit(stub *stub) {
+	txTime := &timestamp.Timestamp{Seconds: l.clock.Unix(), Nanos: int32(l.clock.Nanosecond())}
+
+	for _, key := range sortedKeys(stub.writes) {
+		value := stub.writes[key]
+		modification := &queryresult.KeyModification{TxId: stub.txID, Timestamp: txTime}
+		if len(value) == 0 {
+			delete(l.state, key)
+			delete(l.parameters, key)
+			modification.IsDelete = true
+		} else {
+			l.state[key] = value
+			modification.Value = value
+		}
+		l.history[key] = append(l.history[key], modification)
+	}
+	for key, parameter := range stub.parameterWrites {
+		l.parameters[key] = parameter
+	}
+
+	for collection, writes := range stub.privateWrites {
+		if l.private[collection] == nil {
+			l.private[collection] = map[string][]byte{}
+		}
+		for key, value := range writes {
+			if len(value) == 0 {
+				delete(l.private[collection], key)
+			} else {
+				l.private[collection][key] = value
+			}
+		}
+	}
+}
+
+// State returns the committed value of key, nil if there is none
+func (l *Ledger) State(key string) []byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.state[key]
+}
+
+// PrivateData returns the committed value of key in collection, nil if there is none
+func (l *Ledger) PrivateData(collection string, key string) []byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.private[collection][key]
+}
+
+// Events returns the events of the committed transactions, oldest first
+func (l *Ledger) Events() []Event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Event{}, l.events...)
+}
+
+// SetTime sets the clock, the next transaction is timestamped one second later
+func (l *Ledger) SetTime(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.clock = now.UTC()
+}
+
+// Now is the timestamp of the last transaction
+func (l *Ledger) Now() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.clock
+}
+
+func sortedKeys(values map[string][]byte) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,368 @@
+package ledgertest_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/lotty02cho/fabcow-test/ledgertest"
+)
+
+// kvChaincode runs the stub calls the tests need as transactions
+type kvChaincode struct{}
+
+// page is the payload of the paginated queries of kvChaincode
+type page struct {
+	Keys     []string `json:"keys"`
+	Bookmark string   `json:"bookmark"`
+}
+
+func (kvChaincode) Init(stub shim.ChaincodeStubInterface) peer.Response {
+	return shim.Success(nil)
+}
+
+func (kvChaincode) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+	function, args := stub.GetFunctionAndParameters()
+	switch function {
+	case "put":
+		// put KEY VALUE [KEY VALUE]…
+		for i := 0; i+1 < len(args); i += 2 {
+			if err := stub.PutState(args[i], []byte(args[i+1])); err != nil {
+				return shim.Error(err.Error())
+			}
+		}
+		return shim.Success(nil)
+	case "putComposite":
+		// putComposite TYPE ATTRIBUTE… stores "x" under the composite key
+		key, err := stub.CreateCompositeKey(args[0], args[1:])
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		if err := stub.PutState(key, []byte("x")); err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Success(nil)
+	case "putThenGet":
+		// putThenGet KEY VALUE returns what the transaction reads back
+		if err := stub.PutState(args[0], []byte(args[1])); err != nil {
+			return shim.Error(err.Error())
+		}
+		value, err := stub.GetState(args[0])
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Success(value)
+	case "putThenFail":
+		if err := stub.PutState(args[0], []byte(args[1])); err != nil {
+			return shim.Error(err.Error())
+		}
+		if err := stub.SetEvent("failed", nil); err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Error("refused")
+	case "get":
+		value, err := stub.GetState(args[0])
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Success(value)
+	case "range":
+		return keys(stub.GetStateByRange(args[0], args[1]))
+	case "partial":
+		return keys(stub.GetStateByPartialCompositeKey(args[0], args[1:]))
+	case "query":
+		return keys(stub.GetQueryResult(args[0]))
+	case "rangePage", "putThenRangePage", "rangePageThenPut":
+		// rangePage START END SIZE BOOKMARK
+		if function == "putThenRangePage" {
+			if err := stub.PutState("written", []byte("x")); err != nil {
+				return shim.Error(err.Error())
+			}
+		}
+		size, _ := strconv.Atoi(args[2])
+		response := pageOf(stub.GetStateByRangeWithPagination(args[0], args[1], int32(size), args[3]))
+		if function == "rangePageThenPut" && response.Status == shim.OK {
+			if err := stub.PutState("written", []byte("x")); err != nil {
+				return shim.Error(err.Error())
+			}
+		}
+		return response
+	case "queryPage":
+		// queryPage QUERY SIZE BOOKMARK
+		size, _ := strconv.Atoi(args[1])
+		return pageOf(stub.GetQueryResultWithPagination(args[0], int32(size), args[2]))
+	case "history":
+		// history KEY returns the values, newest first, "" for a delete
+		it, err := stub.GetHistoryForKey(args[0])
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		defer it.Close()
+		values := []string{}
+		for it.HasNext() {
+			modification, err := it.Next()
+			if err != nil {
+				return shim.Error(err.Error())
+			}
+			values = append(values, string(modification.Value))
+		}
+		return success(values)
+	case "putPrivate":
+		// putPrivate COLLECTION KEY, the value is the transient field "value"
+		transient, err := stub.GetTransient()
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		if err := stub.PutPrivateData(args[0], args[1], transient["value"]); err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Success(nil)
+	case "getPrivate":
+		value, err := stub.GetPrivateData(args[0], args[1])
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Success(value)
+	case "events":
+		// events NAME… sets every event in turn
+		for _, name := range args {
+			if err := stub.SetEvent(name, []byte(name)); err != nil {
+				return shim.Error(err.Error())
+			}
+		}
+		return shim.Success(nil)
+	case "now":
+		now, err := stub.GetTxTimestamp()
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		return shim.Success([]byte(time.Unix(now.Seconds, int64(now.Nanos)).UTC().Format(time.RFC3339)))
+	}
+	return shim.Error("unknown function " + function)
+}
+
+func keys(it shim.StateQueryIteratorInterface, err error) peer.Response {
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	defer it.Close()
+	found := []string{}
+	for it.HasNext() {
+		kv, err := it.Next()
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		found = append(found, kv.Key)
+	}
+	return success(found)
+}
+
+func pageOf(it shim.StateQueryIteratorInterface, metadata *peer.QueryResponseMetadata, err error) peer.Response {
+	response := keys(it, err)
+	if response.Status != shim.OK {
+		return response
+	}
+	p := page{}
+	if err := json.Unmarshal(response.Payload, &p.Keys); err != nil {
+		return shim.Error(err.Error())
+	}
+	if int(metadata.FetchedRecordsCount) != len(p.Keys) {
+		return shim.Error("fetched records count " + strconv.Itoa(int(metadata.FetchedRecordsCount)) + ", want " + strconv.Itoa(len(p.Keys)))
+	}
+	p.Bookmark = metadata.Bookmark
+	return success(p)
+}
+
+func success(v interface{}) peer.Response {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(payload)
+}
+
+// newLedger returns a ledger of kvChaincode and its submitter
+func newLedger(t *testing.T) (*ledgertest.Ledger, *ledgertest.Identity) {
+	t.Helper()
+	client, err := ledgertest.NewIdentity("Org1MSP", "client1", "client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ledgertest.New(kvChaincode{}, "mychannel"), client
+}
+
+// decode unmarshals the payload of a transaction that has to succeed into v
+func decode(t *testing.T, result ledgertest.Result, v interface{}) {
+	t.Helper()
+	if err := result.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(result.Payload, v); err != nil {
+		t.Fatalf("payload %q: %v", result.Payload, err)
+	}
+}
+
+func TestTransactions(t *testing.T) {
+	ledger, client := newLedger(t)
+
+	t.Run("own writes are not read", func(t *testing.T) {
+		result := ledger.Submit(client, "putThenGet", "a", "1")
+		if err := result.Err(); err != nil || len(result.Payload) != 0 || !result.Committed {
+			t.Fatalf("putThenGet read %q (%v), want nothing and a commit", result.Payload, err)
+		}
+		if value := string(ledger.Evaluate(client, "get", "a").Payload); value != "1" {
+			t.Errorf("a is %q after the commit, want 1", value)
+		}
+	})
+
+	t.Run("failed transactions are dropped", func(t *testing.T) {
+		result := ledger.Submit(client, "putThenFail", "b", "2")
+		if result.Err() == nil || result.Status != shim.ERROR || result.Committed || result.Event != nil {
+			t.Fatalf("putThenFail gave %+v, want an uncommitted failure without an event", result)
+		}
+		if ledger.State("b") != nil {
+			t.Errorf("b is %q, want nothing", ledger.State("b"))
+		}
+	})
+
+	t.Run("evaluations are not committed", func(t *testing.T) {
+		if result := ledger.Evaluate(client, "put", "c", "3"); result.Err() != nil || result.Committed {
+			t.Fatalf("evaluated put gave %+v", result)
+		}
+		if ledger.State("c") != nil {
+			t.Errorf("c is %q, want nothing", ledger.State("c"))
+		}
+	})
+
+	t.Run("history", func(t *testing.T) {
+		ledger.Submit(client, "put", "a", "2")
+		// an empty value deletes
+		ledger.Submit(client, "put", "a", "")
+		ledger.Submit(client, "put", "a", "3")
+		history := []string{}
+		decode(t, ledger.Evaluate(client, "history", "a"), &history)
+		if want := []string{"3", "", "2", "1"}; !reflect.DeepEqual(history, want) {
+			t.Errorf("history of a is %q, want %q", history, want)
+		}
+	})
+
+	t.Run("last event", func(t *testing.T) {
+		result := ledger.Submit(client, "events", "first", "second")
+		if result.Event == nil || result.Event.Name != "second" || string(result.Event.Payload) != "second" {
+			t.Fatalf("events emitted %+v, want second", result.Event)
+		}
+		if events := ledger.Events(); len(events) != 1 || events[0].TxID != result.TxID {
+			t.Errorf("ledger has the events %+v, want the one of %s", events, result.TxID)
+		}
+	})
+
+	t.Run("clock", func(t *testing.T) {
+		ledger.SetTime(time.Date(2021, 3, 1, 9, 0, 0, 0, time.UTC))
+		if now := string(ledger.Submit(client, "now").Payload); now != "2021-03-01T09:00:01Z" {
+			t.Errorf("transaction timestamped %s, want one second after the clock", now)
+		}
+		if now := ledger.Now(); !now.Equal(time.Date(2021, 3, 1, 9, 0, 1, 0, time.UTC)) {
+			t.Errorf("Now is %s", now)
+		}
+	})
+
+	t.Run("private data", func(t *testing.T) {
+		result := ledger.SubmitTransient(client, map[string][]byte{"value": []byte("secret")}, "putPrivate", "farms", "p")
+		if err := result.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if ledger.State("p") != nil || string(ledger.PrivateData("farms", "p")) != "secret" {
+			t.Errorf("private value in the state %q and the collection %q", ledger.State("p"), ledger.PrivateData("farms", "p"))
+		}
+		if value := string(ledger.Evaluate(client, "getPrivate", "farms", "p").Payload); value != "secret" {
+			t.Errorf("getPrivate read %q", value)
+		}
+		if value := ledger.Evaluate(client, "getPrivate", "other", "p").Payload; len(value) != 0 {
+			t.Errorf("another collection read %q", value)
+		}
+	})
+}
+
+func TestRangeQueries(t *testing.T) {
+	ledger, client := newLedger(t)
+	ledger.Submit(client, "put", "a", "1", "b", "2", "c", "3", "d", "4", "e", "5")
+	ledger.Submit(client, "putComposite", "cow", "FARM0", "1")
+	ledger.Submit(client, "putComposite", "cow", "FARM1", "2")
+
+	tests := []struct {
+		name     string
+		function string
+		args     []string
+		want     page
+		// err is set when the query has to fail
+		err bool
+	}{
+		{"whole range", "range", []string{"", ""}, page{Keys: []string{"a", "b", "c", "d", "e"}}, false},
+		{"end excluded", "range", []string{"b", "d"}, page{Keys: []string{"b", "c"}}, false},
+		{"composite start", "range", []string{"\x00cow\x00", ""}, page{}, true},
+		{"partial composite key", "partial", []string{"cow", "FARM1"}, page{Keys: []string{"\x00cow\x00FARM1\x002\x00"}}, false},
+		{"all composite keys of a type", "partial", []string{"cow"}, page{Keys: []string{"\x00cow\x00FARM0\x001\x00", "\x00cow\x00FARM1\x002\x00"}}, false},
+		{"first page", "rangePage", []string{"", "", "2", ""}, page{Keys: []string{"a", "b"}, Bookmark: "c"}, false},
+		{"next page", "rangePage", []string{"", "", "2", "c"}, page{Keys: []string{"c", "d"}, Bookmark: "e"}, false},
+		{"last page", "rangePage", []string{"", "", "2", "e"}, page{Keys: []string{"e"}}, false},
+		{"page of a bounded range", "rangePage", []string{"b", "d", "5", ""}, page{Keys: []string{"b", "c"}}, false},
+		{"bookmark outside the range", "rangePage", []string{"b", "d", "2", "e"}, page{}, true},
+		{"no page size", "rangePage", []string{"", "", "0", ""}, page{}, true},
+		{"page in a writing transaction", "putThenRangePage", []string{"", "", "2", ""}, page{}, true},
+		{"write after a page", "rangePageThenPut", []string{"", "", "2", ""}, page{}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := ledger.Submit(client, test.function, test.args...)
+			if test.err {
+				if result.Err() == nil {
+					t.Fatalf("%s %q succeeded, want an error", test.function, test.args)
+				}
+				return
+			}
+			got := page{}
+			if test.function == "range" || test.function == "partial" {
+				decode(t, result, &got.Keys)
+			} else {
+				decode(t, result, &got)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("%s %q gave %+v, want %+v", test.function, test.args, got, test.want)
+			}
+		})
+	}
+	if ledger.State("written") != nil {
+		t.Errorf("a transaction mixing pagination and writes was committed")
+	}
+}
+
+func TestQueryPagination(t *testing.T) {
+	ledger, client := newLedger(t)
+	ledger.Submit(client, "put", "c1", `{"n":1}`, "c2", `{"n":2}`, "c3", `{"n":3}`, "c4", `{"n":4}`, "c5", `{"n":5}`)
+
+	// the page size replaces the limit of the query, the bookmark is an offset
+	query := `{"selector":{"n":{"$gt":1}},"sort":[{"n":"desc"}],"limit":1}`
+	pages := []page{}
+	bookmark := ""
+	for {
+		p := page{}
+		decode(t, ledger.Evaluate(client, "queryPage", query, "3", bookmark), &p)
+		pages = append(pages, p)
+		if bookmark = p.Bookmark; bookmark == "" || len(pages) > 3 {
+			break
+		}
+	}
+	want := []page{{Keys: []string{"c5", "c4", "c3"}, Bookmark: "offset:3"}, {Keys: []string{"c2"}}}
+	if !reflect.DeepEqual(pages, want) {
+		t.Errorf("pages %+v, want %+v", pages, want)
+	}
+
+	if result := ledger.Evaluate(client, "queryPage", query, "3", "c3"); result.Err() == nil {
+		t.Errorf("a range bookmark was accepted")
+	}
+}
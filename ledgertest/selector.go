@@ -0,0 +1,459 @@
+package ledgertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+)
+
+// Rich queries take the CouchDB query JSON with this subset of it:
+//
+//	selector  field conditions, on nested objects or "a.b" paths, "_id" is the key:
+//	          $eq $ne $gt $gte $lt $lte $exists $type $in $nin $size $all
+//	          $elemMatch $regex, combined with $and $or $nor $not
+//	sort      [{"field": "asc"}, "field", …]
+//	limit     the most results to return
+//	skip      the results to leave out first
+//	fields    the fields to return
+//
+// use_index is accepted and ignored, every query scans the whole state. Values
+// that are not JSON objects are never returned, like CouchDB. Anything else is
+// refused so a test does not pass on a query a peer would reject.
+type query struct {
+	Selector map[string]interface{} `json:"selector"`
+	Sort     []interface{}          `json:"sort"`
+	Limit    int                    `json:"limit"`
+	Skip     int                    `json:"skip"`
+	Fields   []string               `json:"fields"`
+	UseIndex interface{}            `json:"use_index"`
+}
+
+// sortField is one field of the sort of a query
+type sortField struct {
+	path       string
+	descending bool
+}
+
+func parseQuery(queryString string) (*query, error) {
+	decoder := json.NewDecoder(strings.NewReader(queryString))
+	decoder.DisallowUnknownFields()
+	decoder.UseNumber()
+	q := query{}
+	if err := decoder.Decode(&q); err != nil {
+		return nil, fmt.Errorf("invalid query: %s", err)
+	}
+	if q.Selector == nil {
+		return nil, fmt.Errorf("invalid query: no selector")
+	}
+	if q.Limit < 0 || q.Skip < 0 {
+		return nil, fmt.Errorf("invalid query: negative limit or skip")
+	}
+	return &q, nil
+}
+
+// run returns the matching documents of values in query order
+func (q *query) run(values map[string][]byte) ([]*queryresult.KV, error) {
+	sortFields, err := q.sortFields()
+	if err != nil {
+		return nil, err
+	}
+
+	type match struct {
+		key      string
+		document map[string]interface{}
+	}
+	matches := []match{}
+	for key, value := range values {
+		document, ok := decodeDocument(value)
+		if !ok {
+			continue
+		}
+		document["_id"] = key
+		matched, err := matchSelector(q.Selector, document)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			matches = append(matches, match{key: key, document: document})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		for _, field := range sortFields {
+			a, _ := lookupField(matches[i].document, field.path)
+			b, _ := lookupField(matches[j].document, field.path)
+			if c := compareValues(a, b); c != 0 {
+				return (c < 0) != field.descending
+			}
+		}
+		return matches[i].key < matches[j].key
+	})
+
+	if q.Skip >= len(matches) {
+		matches = nil
+	} else {
+		matches = matches[q.Skip:]
+	}
+	if q.Limit > 0 && len(matches) > q.Limit {
+		matches = matches[:q.Limit]
+	}
+
+	results := []*queryresult.KV{}
+	for _, m := range matches {
+		value := values[m.key]
+		if len(q.Fields) > 0 {
+			if value, err = json.Marshal(projectFields(m.document, q.Fields)); err != nil {
+				return nil, err
+			}
+		}
+		results = append(results, &queryresult.KV{Key: m.key, Value: value})
+	}
+	return results, nil
+}
+
+func (q *query) sortFields() ([]sortField, error) {
+	fields := []sortField{}
+	for _, entry := range q.Sort {
+		switch entry := entry.(type) {
+		case string:
+			fields = append(fields, sortField{path: entry})
+		case map[string]interface{}:
+			if len(entry) != 1 {
+				return nil, fmt.Errorf("invalid sort: %v", entry)
+			}
+			for path, direction := range entry {
+				switch direction {
+				case "asc":
+					fields = append(fields, sortField{path: path})
+				case "desc":
+					fields = append(fields, sortField{path: path, descending: true})
+				default:
+					return nil, fmt.Errorf("invalid sort direction %v of %s", direction, path)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("invalid sort: %v", entry)
+		}
+	}
+	return fields, nil
+}
+
+func decodeDocument(value []byte) (map[string]interface{}, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.UseNumber()
+	document := map[string]interface{}{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, false
+	}
+	return document, true
+}
+
+// matchSelector reports whether document matches every condition of selector
+func matchSelector(selector map[string]interface{}, document interface{}) (bool, error) {
+	for field, condition := range selector {
+		var matched bool
+		var err error
+		switch field {
+		case "$and", "$or", "$nor":
+			matched, err = matchCombination(field, condition, document)
+		case "$not":
+			subSelector, ok := condition.(map[string]interface{})
+			if !ok {
+				return false, fmt.Errorf("$not takes a selector")
+			}
+			matched, err = matchSelector(subSelector, document)
+			matched = !matched
+		default:
+			if strings.HasPrefix(field, "$") {
+				return false, fmt.Errorf("unsupported selector operator %s", field)
+			}
+			value, exists := lookupField(document, field)
+			matched, err = matchCondition(value, exists, condition)
+		}
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchCombination(operator string, condition interface{}, document interface{}) (bool, error) {
+	selectors, ok := condition.([]interface{})
+	if !ok {
+		return false, fmt.Errorf("%s takes an array of selectors", operator)
+	}
+	matches := 0
+	for _, selector := range selectors {
+		subSelector, ok := selector.(map[string]interface{})
+		if !ok {
+			return false, fmt.Errorf("%s takes an array of selectors", operator)
+		}
+		matched, err := matchSelector(subSelector, document)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			matches++
+		}
+	}
+	switch operator {
+	case "$and":
+		return matches == len(selectors), nil
+	case "$or":
+		return matches > 0, nil
+	}
+	return matches == 0, nil
+}
+
+// matchCondition matches a field value against a condition: a value it must
+// equal, an object of operators, or a selector for an object value
+func matchCondition(value interface{}, exists bool, condition interface{}) (bool, error) {
+	operators, ok := condition.(map[string]interface{})
+	if !ok {
+		return exists && compareValues(value, condition) == 0, nil
+	}
+	if !isOperatorObject(operators) {
+		subDocument, ok := value.(map[string]interface{})
+		if !exists || !ok {
+			return false, nil
+		}
+		return matchSelector(operators, subDocument)
+	}
+
+	for operator, argument := range operators {
+		// every operator but $exists needs the field
+		if operator != "$exists" && !exists {
+			return false, nil
+		}
+		matched, err := matchOperator(operator, argument, value, exists)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchOperator(operator string, argument interface{}, value interface{}, exists bool) (bool, error) {
+	switch operator {
+	case "$eq":
+		return compareValues(value, argument) == 0, nil
+	case "$ne":
+		return compareValues(value, argument) != 0, nil
+	case "$gt", "$gte", "$lt", "$lte":
+		// values of different types compare by the collation order of their types
+		c := compareValues(value, argument)
+		switch operator {
+		case "$gt":
+			return c > 0, nil
+		case "$gte":
+			return c >= 0, nil
+		case "$lt":
+			return c < 0, nil
+		}
+		return c <= 0, nil
+	case "$exists":
+		want, ok := argument.(bool)
+		if !ok {
+			return false, fmt.Errorf("$exists takes a boolean")
+		}
+		return exists == want, nil
+	case "$type":
+		return typeName(value) == argument, nil
+	case "$in", "$nin":
+		candidates, ok := argument.([]interface{})
+		if !ok {
+			return false, fmt.Errorf("%s takes an array", operator)
+		}
+		found := false
+		for _, candidate := range candidates {
+			if compareValues(value, candidate) == 0 {
+				found = true
+				break
+			}
+		}
+		return found == (operator == "$in"), nil
+	case "$size":
+		elements, ok := value.([]interface{})
+		size, isNumber := argument.(json.Number)
+		if !isNumber {
+			return false, fmt.Errorf("$size takes a number")
+		}
+		return ok && size.String() == fmt.Sprint(len(elements)), nil
+	case "$all":
+		elements, ok := value.([]interface{})
+		required, isArray := argument.([]interface{})
+		if !isArray {
+			return false, fmt.Errorf("$all takes an array")
+		}
+		if !ok {
+			return false, nil
+		}
+		for _, want := range required {
+			found := false
+			for _, element := range elements {
+				if compareValues(element, want) == 0 {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false, nil
+			}
+		}
+		return true, nil
+	case "$elemMatch":
+		elements, ok := value.([]interface{})
+		if !ok {
+			return false, nil
+		}
+		for _, element := range elements {
+			matched, err := matchCondition(element, true, argument)
+			if err != nil {
+				return false, err
+			}
+			if matched {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "$regex":
+		pattern, ok := argument.(string)
+		if !ok {
+			return false, fmt.Errorf("$regex takes a string")
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, fmt.Errorf("invalid $regex: %s", err)
+		}
+		text, ok := value.(string)
+		return ok && re.MatchString(text), nil
+	}
+	return false, fmt.Errorf("unsupported selector operator %s", operator)
+}
+
+// isOperatorObject tells {"$gt": 1} from a nested selector like {"Biz_no": "01"}
+func isOperatorObject(condition map[string]interface{}) bool {
+	for key := range condition {
+		if !strings.HasPrefix(key, "$") {
+			return false
+		}
+	}
+	return len(condition) > 0
+}
+
+// lookupField finds a field by its path, "Owner.Biz_no" is the Biz_no of the Owner object
+func lookupField(document interface{}, path string) (interface{}, bool) {
+	value := document
+	for _, part := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[part]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// projectFields keeps the fields of document listed in paths
+func projectFields(document map[string]interface{}, paths []string) map[string]interface{} {
+	projection := map[string]interface{}{}
+	for _, path := range paths {
+		value, ok := lookupField(document, path)
+		if !ok {
+			continue
+		}
+		parts := strings.Split(path, ".")
+		target := projection
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := target[part].(map[string]interface{})
+			if !ok {
+				next = map[string]interface{}{}
+				target[part] = next
+			}
+			target = next
+		}
+		target[parts[len(parts)-1]] = value
+	}
+	return projection
+}
+
+// typeRanks is the CouchDB collation order of the JSON types
+var typeRanks = map[string]int{"null": 0, "boolean": 1, "number": 2, "string": 3, "array": 4, "object": 5}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number, float64, int:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
+
+// compareValues orders JSON values like CouchDB: by type, then by value
+func compareValues(a, b interface{}) int {
+	ta, tb := typeName(a), typeName(b)
+	if ta != tb {
+		return typeRanks[ta] - typeRanks[tb]
+	}
+
+	switch ta {
+	case "boolean":
+		x, y := a.(bool), b.(bool)
+		if x == y {
+			return 0
+		} else if !x {
+			return -1
+		}
+		return 1
+	case "number":
+		x, y := toFloat(a), toFloat(b)
+		if x < y {
+			return -1
+		} else if x > y {
+			return 1
+		}
+		return 0
+	case "string":
+		return strings.Compare(a.(string), b.(string))
+	case "array":
+		x, y := a.([]interface{}), b.([]interface{})
+		for i := 0; i < len(x) && i < len(y); i++ {
+			if c := compareValues(x[i], y[i]); c != 0 {
+				return c
+			}
+		}
+		return len(x) - len(y)
+	case "object":
+		x, _ := json.Marshal(a)
+		y, _ := json.Marshal(b)
+		return bytes.Compare(x, y)
+	}
+	return 0
+}
+
+func toFloat(value interface{}) float64 {
+	switch value := value.(type) {
+	case json.Number:
+		f, _ := value.Float64()
+		return f
+	case float64:
+		return value
+	case int:
+		return float64(value)
+	}
+	return 0
+}
@@ -0,0 +1,86 @@
+package ledgertest
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelector(t *testing.T) {
+	documents := map[string][]byte{
+		"COW0": []byte(`{"Id_no":"180501-1","Sex":"F","Weight":285,"Dead":false,"Owner":{"Biz_no":"FARM0","Type":"FARM"},"Remarks":[{"Key":"grade","Value":"1++"},{"Key":"dead","Value":"no"}]}`),
+		"COW1": []byte(`{"Id_no":"180501-2","Sex":"M","Weight":310.5,"Dead":true,"Owner":{"Biz_no":"FARM1","Type":"FARM"},"Remarks":[]}`),
+		"COW2": []byte(`{"Id_no":"180502-1","Sex":"M","Weight":"unknown","Owner":{"Biz_no":"SLAU0","Type":"SLAUGHTERHOUSE"}}`),
+		"RAW0": []byte(`not a document`),
+		"ARR0": []byte(`["not","an","object"]`),
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+		// values are the results of a query with fields, by key
+		values map[string]string
+		err    bool
+	}{
+		{"equality", `{"selector":{"Sex":"M"}}`, []string{"COW1", "COW2"}, nil, false},
+		{"path", `{"selector":{"Owner.Biz_no":"FARM1"}}`, []string{"COW1"}, nil, false},
+		{"nested selector", `{"selector":{"Owner":{"Type":"FARM","Biz_no":{"$ne":"FARM0"}}}}`, []string{"COW1"}, nil, false},
+		{"key", `{"selector":{"_id":{"$gte":"COW1"}}}`, []string{"COW1", "COW2"}, nil, false},
+		{"numbers", `{"selector":{"Weight":{"$gt":285}}}`, []string{"COW1", "COW2"}, nil, false},
+		{"range of numbers", `{"selector":{"Weight":{"$gt":280,"$lt":300}}}`, []string{"COW0"}, nil, false},
+		{"type", `{"selector":{"Weight":{"$type":"number"}}}`, []string{"COW0", "COW1"}, nil, false},
+		{"missing field", `{"selector":{"Dead":{"$exists":false}}}`, []string{"COW2"}, nil, false},
+		{"operators need the field", `{"selector":{"Dead":{"$ne":true}}}`, []string{"COW0"}, nil, false},
+		{"in", `{"selector":{"Owner.Biz_no":{"$in":["FARM0","SLAU0"]}}}`, []string{"COW0", "COW2"}, nil, false},
+		{"not in", `{"selector":{"Owner.Biz_no":{"$nin":["FARM0","SLAU0"]}}}`, []string{"COW1"}, nil, false},
+		{"regex", `{"selector":{"Id_no":{"$regex":"^180501-"}}}`, []string{"COW0", "COW1"}, nil, false},
+		{"size", `{"selector":{"Remarks":{"$size":0}}}`, []string{"COW1"}, nil, false},
+		{"element", `{"selector":{"Remarks":{"$elemMatch":{"Key":"grade","Value":"1++"}}}}`, []string{"COW0"}, nil, false},
+		{"all", `{"selector":{"Remarks":{"$all":[{"Key":"dead","Value":"no"}]}}}`, []string{"COW0"}, nil, false},
+		{"or", `{"selector":{"$or":[{"Sex":"F"},{"Dead":true}]}}`, []string{"COW0", "COW1"}, nil, false},
+		{"nor", `{"selector":{"$nor":[{"Sex":"F"},{"Dead":true}]}}`, []string{"COW2"}, nil, false},
+		{"not", `{"selector":{"$not":{"Sex":"M"}}}`, []string{"COW0"}, nil, false},
+		{"and", `{"selector":{"$and":[{"Sex":"M"},{"Owner.Type":"FARM"}]}}`, []string{"COW1"}, nil, false},
+		{"sort", `{"selector":{"Sex":{"$gt":null}},"sort":[{"Sex":"desc"},"Id_no"]}`, []string{"COW1", "COW2", "COW0"}, nil, false},
+		// a number sorts before a string like in CouchDB
+		{"sort across types", `{"selector":{"Weight":{"$exists":true}},"sort":[{"Weight":"desc"}]}`, []string{"COW2", "COW1", "COW0"}, nil, false},
+		{"skip and limit", `{"selector":{"_id":{"$gt":null}},"skip":1,"limit":1}`, []string{"COW1"}, nil, false},
+		{"skip everything", `{"selector":{"_id":{"$gt":null}},"skip":5}`, []string{}, nil, false},
+		{"fields", `{"selector":{"Sex":"F"},"fields":["Id_no","Owner.Biz_no"]}`, []string{"COW0"}, map[string]string{"COW0": `{"Id_no":"180501-1","Owner":{"Biz_no":"FARM0"}}`}, false},
+		{"index", `{"selector":{"Sex":"F"},"use_index":["_design/sexDoc","sex"]}`, []string{"COW0"}, nil, false},
+		{"no selector", `{"sort":["Sex"]}`, nil, nil, true},
+		{"unknown query field", `{"selector":{},"bookmark":"x"}`, nil, nil, true},
+		{"unknown operator", `{"selector":{"Weight":{"$mod":[2,0]}}}`, nil, nil, true},
+		{"bad sort direction", `{"selector":{},"sort":[{"Sex":"up"}]}`, nil, nil, true},
+		{"bad regex", `{"selector":{"Id_no":{"$regex":"("}}}`, nil, nil, true},
+		{"negative limit", `{"selector":{},"limit":-1}`, nil, nil, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parseQuery(test.query)
+			if err == nil {
+				results, runErr := q.run(documents)
+				if runErr == nil {
+					if test.err {
+						t.Fatalf("%s ran, want an error", test.query)
+					}
+					keys := []string{}
+					for _, kv := range results {
+						keys = append(keys, kv.Key)
+						if want, ok := test.values[kv.Key]; ok && string(kv.Value) != want {
+							t.Errorf("%s returned %s for %s, want %s", test.query, kv.Value, kv.Key, want)
+						}
+					}
+					if !reflect.DeepEqual(keys, test.want) {
+						t.Errorf("%s matched %q, want %q", test.query, keys, test.want)
+					}
+					return
+				}
+				err = runErr
+			}
+			if !test.err {
+				t.Errorf("%s failed: %v", test.query, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,481 @@
+package ledgertest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+const (
+	// compositeKeyNamespace starts every composite key and separates its parts
+	compositeKeyNamespace = "\x00"
+	// maxUnicodeRune ends the range of a partial composite key
+	maxUnicodeRune = utf8.MaxRune
+	// emptyKeySubstitute is the start of a range query without a start key, it skips composite keys
+	emptyKeySubstitute = "\x01"
+)
+
+// stub is the ChaincodeStubInterface of one transaction
+type stub struct {
+	ledger    *Ledger
+	txID      string
+	args      [][]byte
+	creator   []byte
+	transient map[string][]byte
+	timestamp *timestamp.Timestamp
+
+	// writes are the values put by the transaction, an empty value deletes
+	writes          map[string][]byte
+	parameterWrites map[string][]byte
+	privateWrites   map[string]map[string][]byte
+	event           *peer.ChaincodeEvent
+	// paginated is set by the first query with pagination, which a writing transaction may not run
+	paginated bool
+}
+
+var _ shim.ChaincodeStubInterface = (*stub)(nil)
+
+func newStub(ledger *Ledger, txID string, args []string, identity *Identity, transient map[string][]byte) *stub {
+	s := &stub{
+		ledger:          ledger,
+		txID:            txID,
+		transient:       transient,
+		timestamp:       &timestamp.Timestamp{Seconds: ledger.clock.Unix(), Nanos: int32(ledger.clock.Nanosecond())},
+		writes:          map[string][]byte{},
+		parameterWrites: map[string][]byte{},
+		privateWrites:   map[string]map[string][]byte{},
+	}
+	for _, arg := range args {
+		s.args = append(s.args, []byte(arg))
+	}
+	if identity != nil {
+		s.creator = identity.Creator()
+	}
+	return s
+}
+
+func (s *stub) GetArgs() [][]byte {
+	return s.args
+}
+
+func (s *stub) GetStringArgs() []string {
+	args := []string{}
+	for _, arg := range s.args {
+		args = append(args, string(arg))
+	}
+	return args
+}
+
+func (s *stub) GetFunctionAndParameters() (string, []string) {
+	args := s.GetStringArgs()
+	if len(args) == 0 {
+		return "", []string{}
+	}
+	return args[0], args[1:]
+}
+
+func (s *stub) GetArgsSlice() ([]byte, error) {
+	return bytes.Join(s.args, nil), nil
+}
+
+func (s *stub) GetTxID() string {
+	return s.txID
+}
+
+func (s *stub) GetChannelID() string {
+	return s.ledger.channel
+}
+
+func (s *stub) InvokeChaincode(chaincodeName string, args [][]byte, channel string) peer.Response {
+	return shim.Error("ledgertest runs a single chaincode, " + chaincodeName + " can not be invoked")
+}
+
+func (s *stub) GetState(key string) ([]byte, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	return s.ledger.state[key], nil
+}
+
+func (s *stub) PutState(key string, value []byte) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	if err := s.write(); err != nil {
+		return err
+	}
+	s.writes[key] = append([]byte{}, value...)
+	return nil
+}
+
+func (s *stub) DelState(key string) error {
+	return s.PutState(key, nil)
+}
+
+func (s *stub) SetStateValidationParameter(key string, ep []byte) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	if err := s.write(); err != nil {
+		return err
+	}
+	s.parameterWrites[key] = append([]byte{}, ep...)
+	return nil
+}
+
+func (s *stub) GetStateValidationParameter(key string) ([]byte, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	return s.ledger.parameters[key], nil
+}
+
+func (s *stub) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	startKey, err := simpleRangeStart(startKey, endKey)
+	if err != nil {
+		return nil, err
+	}
+	return newStateIterator(rangeOf(s.ledger.state, startKey, endKey)), nil
+}
+
+func (s *stub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	startKey, err := simpleRangeStart(startKey, endKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.rangePage(startKey, endKey, pageSize, bookmark)
+}
+
+func (s *stub) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	startKey, endKey, err := partialCompositeKeyRange(objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+	return newStateIterator(rangeOf(s.ledger.state, startKey, endKey)), nil
+}
+
+func (s *stub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	startKey, endKey, err := partialCompositeKeyRange(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.rangePage(startKey, endKey, pageSize, bookmark)
+}
+
+// rangePage is a page of a range, the bookmark is the key the next page starts at
+func (s *stub) rangePage(startKey, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	if err := s.paginate(pageSize); err != nil {
+		return nil, nil, err
+	}
+	if bookmark != "" {
+		if bookmark < startKey || (endKey != "" && bookmark >= endKey) {
+			return nil, nil, fmt.Errorf("bookmark %q is outside the range", bookmark)
+		}
+		startKey = bookmark
+	}
+
+	results := rangeOf(s.ledger.state, startKey, endKey)
+	metadata := &peer.QueryResponseMetadata{}
+	if len(results) > int(pageSize) {
+		metadata.Bookmark = results[pageSize].Key
+		results = results[:pageSize]
+	}
+	metadata.FetchedRecordsCount = int32(len(results))
+	return newStateIterator(results), metadata, nil
+}
+
+func (s *stub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	if err := validateCompositeKeyAttribute(objectType); err != nil {
+		return "", err
+	}
+	key := compositeKeyNamespace + objectType + compositeKeyNamespace
+	for _, attribute := range attributes {
+		if err := validateCompositeKeyAttribute(attribute); err != nil {
+			return "", err
+		}
+		key += attribute + compositeKeyNamespace
+	}
+	return key, nil
+}
+
+func (s *stub) SplitCompositeKey(compositeKey string) (string, []string, error) {
+	if !strings.HasPrefix(compositeKey, compositeKeyNamespace) || !strings.HasSuffix(compositeKey, compositeKeyNamespace) {
+		return "", nil, fmt.Errorf("%q is not a composite key", compositeKey)
+	}
+	parts := strings.Split(compositeKey[1:len(compositeKey)-1], compositeKeyNamespace)
+	return parts[0], parts[1:], nil
+}
+
+func (s *stub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	q, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	results, err := q.run(s.ledger.state)
+	if err != nil {
+		return nil, err
+	}
+	return newStateIterator(results), nil
+}
+
+// GetQueryResultWithPagination pages the results of a query, the bookmark is the offset of the next page
+func (s *stub) GetQueryResultWithPagination(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	if err := s.paginate(pageSize); err != nil {
+		return nil, nil, err
+	}
+	q, err := parseQuery(query)
+	if err != nil {
+		return nil, nil, err
+	}
+	// a page size replaces the limit of the query
+	q.Limit = 0
+	results, err := q.run(s.ledger.state)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	offset := 0
+	if bookmark != "" {
+		if _, err := fmt.Sscanf(bookmark, "offset:%d", &offset); err != nil || offset < 0 {
+			return nil, nil, fmt.Errorf("invalid bookmark %q", bookmark)
+		}
+	}
+	if offset > len(results) {
+		offset = len(results)
+	}
+	results = results[offset:]
+
+	metadata := &peer.QueryResponseMetadata{}
+	if len(results) > int(pageSize) {
+		metadata.Bookmark = fmt.Sprintf("offset:%d", offset+int(pageSize))
+		results = results[:pageSize]
+	}
+	metadata.FetchedRecordsCount = int32(len(results))
+	return newStateIterator(results), metadata, nil
+}
+
+// GetHistoryForKey returns the committed modifications of key, newest first like a Fabric 2 peer
+func (s *stub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	history := s.ledger.history[key]
+	modifications := make([]*queryresult.KeyModification, 0, len(history))
+	for i := len(history) - 1; i >= 0; i-- {
+		modifications = append(modifications, history[i])
+	}
+	return &historyIterator{modifications: modifications}, nil
+}
+
+func (s *stub) GetPrivateData(collection, key string) ([]byte, error) {
+	if err := validatePrivateKey(collection, key); err != nil {
+		return nil, err
+	}
+	return s.ledger.private[collection][key], nil
+}
+
+func (s *stub) GetPrivateDataHash(collection, key string) ([]byte, error) {
+	value, err := s.GetPrivateData(collection, key)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(value)
+	return hash[:], nil
+}
+
+func (s *stub) PutPrivateData(collection string, key string, value []byte) error {
+	if err := validatePrivateKey(collection, key); err != nil {
+		return err
+	}
+	if err := s.write(); err != nil {
+		return err
+	}
+	if s.privateWrites[collection] == nil {
+		s.privateWrites[collection] = map[string][]byte{}
+	}
+	s.privateWrites[collection][key] = append([]byte{}, value...)
+	return nil
+}
+
+func (s *stub) DelPrivateData(collection, key string) error {
+	return s.PutPrivateData(collection, key, nil)
+}
+
+// PurgePrivateData deletes the private data, there is no private history to purge
+func (s *stub) PurgePrivateData(collection, key string) error {
+	return s.DelPrivateData(collection, key)
+}
+
+func (s *stub) SetPrivateDataValidationParameter(collection, key string, ep []byte) error {
+	return errors.New("ledgertest does not support private data validation parameters")
+}
+
+func (s *stub) GetPrivateDataValidationParameter(collection, key string) ([]byte, error) {
+	return nil, errors.New("ledgertest does not support private data validation parameters")
+}
+
+func (s *stub) GetPrivateDataByRange(collection, startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	if collection == "" {
+		return nil, errors.New("collection must not be an empty string")
+	}
+	startKey, err := simpleRangeStart(startKey, endKey)
+	if err != nil {
+		return nil, err
+	}
+	return newStateIterator(rangeOf(s.ledger.private[collection], startKey, endKey)), nil
+}
+
+func (s *stub) GetPrivateDataByPartialCompositeKey(collection, objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	if collection == "" {
+		return nil, errors.New("collection must not be an empty string")
+	}
+	startKey, endKey, err := partialCompositeKeyRange(objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+	return newStateIterator(rangeOf(s.ledger.private[collection], startKey, endKey)), nil
+}
+
+func (s *stub) GetPrivateDataQueryResult(collection, query string) (shim.StateQueryIteratorInterface, error) {
+	if collection == "" {
+		return nil, errors.New("collection must not be an empty string")
+	}
+	q, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	results, err := q.run(s.ledger.private[collection])
+	if err != nil {
+		return nil, err
+	}
+	return newStateIterator(results), nil
+}
+
+func (s *stub) GetCreator() ([]byte, error) {
+	return s.creator, nil
+}
+
+func (s *stub) GetTransient() (map[string][]byte, error) {
+	return s.transient, nil
+}
+
+func (s *stub) GetBinding() ([]byte, error) {
+	return nil, nil
+}
+
+func (s *stub) GetDecorations() map[string][]byte {
+	return nil
+}
+
+func (s *stub) GetSignedProposal() (*peer.SignedProposal, error) {
+	return &peer.SignedProposal{}, nil
+}
+
+func (s *stub) GetTxTimestamp() (*timestamp.Timestamp, error) {
+	return s.timestamp, nil
+}
+
+// SetEvent sets the event of the transaction, a later call replaces it
+func (s *stub) SetEvent(name string, payload []byte) error {
+	if name == "" {
+		return errors.New("event name can not be empty string")
+	}
+	s.event = &peer.ChaincodeEvent{TxId: s.txID, EventName: name, Payload: append([]byte{}, payload...)}
+	return nil
+}
+
+// write and paginate refuse paginated queries in a transaction that writes, like the peer
+func (s *stub) write() error {
+	if s.paginated {
+		return errors.New("txid [" + s.txID + "]: paginated queries are only supported in read only transactions")
+	}
+	return nil
+}
+
+func (s *stub) paginate(pageSize int32) error {
+	if pageSize <= 0 {
+		return fmt.Errorf("page size must be positive, got %d", pageSize)
+	}
+	if len(s.writes) > 0 || len(s.parameterWrites) > 0 || len(s.privateWrites) > 0 {
+		return errors.New("txid [" + s.txID + "]: paginated queries are only supported in read only transactions")
+	}
+	s.paginated = true
+	return nil
+}
+
+// rangeOf is the values of the keys from startKey up to endKey, excluded, in key order.
+// An empty endKey has no upper bound.
+func rangeOf(values map[string][]byte, startKey, endKey string) []*queryresult.KV {
+	results := []*queryresult.KV{}
+	for key, value := range values {
+		if key >= startKey && (endKey == "" || key < endKey) {
+			results = append(results, &queryresult.KV{Key: key, Value: value})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Key < results[j].Key
+	})
+	return results
+}
+
+// simpleRangeStart checks the keys of a range query and substitutes an empty start key
+func simpleRangeStart(startKey, endKey string) (string, error) {
+	for _, key := range []string{startKey, endKey} {
+		if strings.HasPrefix(key, compositeKeyNamespace) {
+			return "", errors.New("range query keys must not be composite keys")
+		}
+		if !utf8.ValidString(key) {
+			return "", fmt.Errorf("key %q is not valid UTF-8", key)
+		}
+	}
+	if startKey == "" {
+		startKey = emptyKeySubstitute
+	}
+	return startKey, nil
+}
+
+func partialCompositeKeyRange(objectType string, keys []string) (string, string, error) {
+	startKey, err := (&stub{}).CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return "", "", err
+	}
+	return startKey, startKey + string(maxUnicodeRune), nil
+}
+
+func validateKey(key string) error {
+	if key == "" {
+		return errors.New("key must not be an empty string")
+	}
+	if !utf8.ValidString(key) {
+		return fmt.Errorf("key %q is not valid UTF-8", key)
+	}
+	return nil
+}
+
+func validatePrivateKey(collection, key string) error {
+	if collection == "" {
+		return errors.New("collection must not be an empty string")
+	}
+	return validateKey(key)
+}
+
+func validateCompositeKeyAttribute(attribute string) error {
+	if !utf8.ValidString(attribute) {
+		return fmt.Errorf("not a valid utf8 string: [%x]", attribute)
+	}
+	for _, r := range attribute {
+		if r == 0 || r == maxUnicodeRune {
+			return fmt.Errorf("input contains unicode %#U starting at position [%d]. %#U and %#U are not allowed in the input attribute of a composite key", r, strings.IndexRune(attribute, r), 0, maxUnicodeRune)
+		}
+	}
+	return nil
+}